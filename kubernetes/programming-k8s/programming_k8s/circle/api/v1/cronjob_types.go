@@ -17,6 +17,7 @@ limitations under the License.
 package v1
 
 import (
+	kbatch "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -40,7 +41,60 @@ const (
 	ReplaceConcurrent ConcurrencyPolicy = "Replace"
 )
 
+// CatchUpPolicy describes how the runs missed by a CronJob are handled, e.g.
+// while the CronJob is suspended or the controller is down. If none of the
+// following policies is specified, the default one is RunLatestCatchUp.
+// +kubebuilder:validation:Enum=RunLatest;RunAll;Skip
+type CatchUpPolicy string
+
+const (
+	// RunLatestCatchUp runs only the latest missed run, the earlier ones are missed.
+	RunLatestCatchUp CatchUpPolicy = "RunLatest"
+
+	// RunAllCatchUp runs all the missed runs one after another, the next one is
+	// started once the previous one is finished. Only the latest 100 missed
+	// runs are kept in the backlog, the earlier ones are missed.
+	RunAllCatchUp CatchUpPolicy = "RunAll"
+
+	// SkipCatchUp never runs the missed runs, only the run on schedule is
+	// started, i.e. the run within the starting deadline, or within 10 seconds
+	// of its scheduled time if no deadline is specified.
+	SkipCatchUp CatchUpPolicy = "Skip"
+)
+
+// These are the condition types of a CronJob.
+const (
+	// CronJobReady means the CronJob is scheduling its runs, it's false if the
+	// CronJob is suspended, or its schedule or time zone is invalid.
+	CronJobReady = "Ready"
+
+	// CronJobActive means the CronJob has active runs.
+	CronJobActive = "Active"
+
+	// CronJobFailed means the last finished run of the CronJob failed.
+	CronJobFailed = "Failed"
+
+	// CronJobMissedRuns is a warning that the CronJob missed runs at its last
+	// evaluation, it's false once a run is started on schedule.
+	CronJobMissedRuns = "MissedRuns"
+)
+
+// These are the reasons of the conditions of a CronJob.
+const (
+	ReasonScheduling      = "Scheduling"
+	ReasonSuspended       = "Suspended"
+	ReasonInvalidSchedule = "InvalidSchedule"
+	ReasonInvalidTimeZone = "InvalidTimeZone"
+	ReasonRunsActive      = "RunsActive"
+	ReasonNoActiveRuns    = "NoActiveRuns"
+	ReasonRunSucceeded    = "RunSucceeded"
+	ReasonRunFailed       = "RunFailed"
+	ReasonRunsMissed      = "RunsMissed"
+	ReasonRunsOnSchedule  = "RunsOnSchedule"
+)
+
 // CronJobSpec defines the desired state of CronJob
+// +kubebuilder:validation:XValidation:rule="has(self.jobTemplate) != has(self.batchJobTemplate)",message="exactly one of jobTemplate and batchJobTemplate must be specified"
 type CronJobSpec struct {
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
@@ -49,6 +103,17 @@ type CronJobSpec struct {
 	// +kubebuilder:validation:Required
 	Schedule string `json:"schedule"`
 
+	// The time zone name for the given schedule, see https://en.wikipedia.org/wiki/List_of_tz_database_time_zones.
+	// If not specified, the schedule is evaluated in the time zone of the controller.
+	// The schedule is evaluated on the wall clock of the time zone: a run whose time
+	// is skipped by a daylight saving transition fires once at the transition, and a
+	// run whose time is repeated fires only once at its first occurrence. Schedules
+	// which fire every hour are not shifted by the transitions.
+	// +optional
+	// +kubebuilder:validation:MaxLength=64
+	// +kubebuilder:validation:Pattern=`^[A-Za-z0-9_+\-]+(/[A-Za-z0-9_+\-]+)*$`
+	TimeZone *string `json:"timeZone,omitempty"`
+
 	// Optional deadline in seconds for starting the job if it misses scheduled
 	// time for any reason.  Missed jobs executions will be counted as failed ones.
 	// +optional
@@ -63,13 +128,33 @@ type CronJobSpec struct {
 	// +optional
 	ConcurrencyPolicy ConcurrencyPolicy `json:"concurrencyPolicy,omitempty"`
 
+	// Specifies how to treat the runs which are missed, e.g. while the CronJob
+	// is suspended or the controller is down.
+	// Valid values are:
+	// - "RunLatest" (default): runs only the latest missed run;
+	// - "RunAll": runs the latest 100 missed runs one after another;
+	// - "Skip": never runs the missed runs, only the run on schedule (within 10 seconds
+	//   if no startingDeadlineSeconds is specified) is started
+	// The runs which are past the startingDeadlineSeconds are always missed.
+	// +optional
+	CatchUpPolicy CatchUpPolicy `json:"catchUpPolicy,omitempty"`
+
 	// This flag tells the controller to suspend subsequent executions, it does
 	// not apply to already started executions.  Defaults to false.
 	// +optional
 	Suspend *bool `json:"suspend,omitempty"`
 
 	// Specifies the pod that will be created when executing a CronJob.
-	JobTemplate corev1.PodTemplateSpec `json:"jobTemplate"`
+	// Exactly one of jobTemplate and batchJobTemplate must be specified.
+	// +optional
+	JobTemplate *corev1.PodTemplateSpec `json:"jobTemplate,omitempty"`
+
+	// Specifies the batch/v1 Job that will be created when executing a CronJob,
+	// the Job retries its pods and runs them with the completions, parallelism
+	// and deadline of the Job. Exactly one of jobTemplate and batchJobTemplate
+	// must be specified.
+	// +optional
+	BatchJobTemplate *kbatch.JobTemplateSpec `json:"batchJobTemplate,omitempty"`
 
 	// The number of successful finished jobs to retain.
 	// This is a pointer to distinguish between explicit zero and not specified.
@@ -89,13 +174,59 @@ type CronJobStatus struct {
 	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
 
-	// A list of pointers to currently running jobs.
+	// A list of pointers to currently running pods or jobs.
 	// +optional
 	Active []corev1.ObjectReference `json:"active,omitempty"`
 
 	// Information when was the last time the job was successfully scheduled.
 	// +optional
 	LastScheduleTime *metav1.Time `json:"lastScheduleTime,omitempty"`
+
+	// Information when was the last scheduled time of a missed run.
+	// +optional
+	LastMissedTime *metav1.Time `json:"lastMissedTime,omitempty"`
+
+	// The number of the runs which are missed by the catch-up policy or the
+	// starting deadline.
+	// +optional
+	MissedRuns int64 `json:"missedRuns,omitempty"`
+
+	// Information when was the last time a run successfully completed.
+	// +optional
+	LastSuccessfulTime *metav1.Time `json:"lastSuccessfulTime,omitempty"`
+
+	// The total number of the runs which succeeded, counted when the runs
+	// finish. The runs deleted by the history limit are still counted.
+	// +optional
+	Succeeded int64 `json:"succeeded,omitempty"`
+
+	// The total number of the runs which failed, counted when the runs
+	// finish. The runs deleted by the history limit are still counted.
+	// +optional
+	Failed int64 `json:"failed,omitempty"`
+
+	// The number of the successful runs retained by the successful jobs
+	// history limit. It's not a running total, see succeeded instead.
+	// +optional
+	RetainedSucceeded int32 `json:"retainedSucceeded,omitempty"`
+
+	// The number of the failed runs retained by the failed jobs history
+	// limit. It's not a running total, see failed instead.
+	// +optional
+	RetainedFailed int32 `json:"retainedFailed,omitempty"`
+
+	// Represents the latest available observations of the CronJob's current state.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	// +patchStrategy=merge
+	// +patchMergeKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+
+	// The time zone the schedule is evaluated in, "Local" means the time zone of
+	// the controller. It's empty if the time zone of the CronJob is unknown.
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
 }
 
 //+kubebuilder:object:root=true
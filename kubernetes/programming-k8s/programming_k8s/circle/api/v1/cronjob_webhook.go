@@ -0,0 +1,199 @@
+/*
+Copyright 2023 Jayson Wang.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	validationutils "k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const (
+	// DefaultSuccessfulJobsHistoryLimit is the number of the successful runs
+	// retained if not specified.
+	DefaultSuccessfulJobsHistoryLimit int32 = 3
+
+	// DefaultFailedJobsHistoryLimit is the number of the failed runs retained
+	// if not specified.
+	DefaultFailedJobsHistoryLimit int32 = 1
+
+	// maxCronJobNameLength is the max length of the name of a CronJob. The runs
+	// are named after the CronJob with a suffix of 11 characters, i.e. the dash
+	// and the scheduled unix time of a job, and the name of the job is also a
+	// label value of its pods, which is limited to 63 characters.
+	maxCronJobNameLength = validationutils.DNS1035LabelMaxLength - 11
+)
+
+// log is for logging in this package.
+var cronjoblog = logf.Log.WithName("cronjob-resource")
+
+// SetupWebhookWithManager registers the defaulting and validating webhooks
+// of the CronJob to the manager.
+func (r *CronJob) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-batch-example-org-v1-cronjob,mutating=true,failurePolicy=fail,sideEffects=None,groups=batch.example.org,resources=cronjobs,verbs=create;update,versions=v1,name=mcronjob.kb.io,admissionReviewVersions=v1
+
+var _ webhook.Defaulter = &CronJob{}
+
+// Default implements webhook.Defaulter so a webhook will be registered for the type
+func (r *CronJob) Default() {
+	cronjoblog.Info("default", "name", r.Name)
+
+	if r.Spec.ConcurrencyPolicy == "" {
+		r.Spec.ConcurrencyPolicy = AllowConcurrent
+	}
+	if r.Spec.CatchUpPolicy == "" {
+		r.Spec.CatchUpPolicy = RunLatestCatchUp
+	}
+	if r.Spec.Suspend == nil {
+		r.Spec.Suspend = new(bool)
+	}
+	if r.Spec.SuccessfulJobsHistoryLimit == nil {
+		r.Spec.SuccessfulJobsHistoryLimit = new(int32)
+		*r.Spec.SuccessfulJobsHistoryLimit = DefaultSuccessfulJobsHistoryLimit
+	}
+	if r.Spec.FailedJobsHistoryLimit == nil {
+		r.Spec.FailedJobsHistoryLimit = new(int32)
+		*r.Spec.FailedJobsHistoryLimit = DefaultFailedJobsHistoryLimit
+	}
+
+	// a pod without the restart policy is restarted always, it never finishes
+	if r.Spec.JobTemplate != nil && r.Spec.JobTemplate.Spec.RestartPolicy == "" {
+		r.Spec.JobTemplate.Spec.RestartPolicy = corev1.RestartPolicyNever
+	}
+}
+
+//+kubebuilder:webhook:path=/validate-batch-example-org-v1-cronjob,mutating=false,failurePolicy=fail,sideEffects=None,groups=batch.example.org,resources=cronjobs,verbs=create;update,versions=v1,name=vcronjob.kb.io,admissionReviewVersions=v1
+
+var _ webhook.Validator = &CronJob{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *CronJob) ValidateCreate() (admission.Warnings, error) {
+	cronjoblog.Info("validate create", "name", r.Name)
+
+	return nil, r.validateCronJob()
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *CronJob) ValidateUpdate(old runtime.Object) (admission.Warnings, error) {
+	cronjoblog.Info("validate update", "name", r.Name)
+
+	return nil, r.validateCronJob()
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *CronJob) ValidateDelete() (admission.Warnings, error) {
+	cronjoblog.Info("validate delete", "name", r.Name)
+
+	// nothing to validate upon deletion
+	return nil, nil
+}
+
+// validateCronJob validates the name and the spec of the CronJob, all the
+// errors are reported at once.
+func (r *CronJob) validateCronJob() error {
+	var allErrs field.ErrorList
+	if err := r.validateCronJobName(); err != nil {
+		allErrs = append(allErrs, err)
+	}
+	allErrs = append(allErrs, r.validateCronJobSpec()...)
+	if len(allErrs) == 0 {
+		return nil
+	}
+
+	return apierrors.NewInvalid(schema.GroupKind{Group: GroupVersion.Group, Kind: "CronJob"}, r.Name, allErrs)
+}
+
+// validateCronJobName validates the name is short enough for the names of
+// its runs, the other rules are validated by the api server.
+func (r *CronJob) validateCronJobName() *field.Error {
+	if len(r.Name) > maxCronJobNameLength {
+		return field.TooLong(field.NewPath("metadata").Child("name"), r.Name, maxCronJobNameLength)
+	}
+	return nil
+}
+
+// validateCronJobSpec validates the fields which can't be validated by the
+// OpenAPI schema of the CRD.
+func (r *CronJob) validateCronJobSpec() field.ErrorList {
+	var allErrs field.ErrorList
+	specPath := field.NewPath("spec")
+
+	if _, err := cron.ParseStandard(r.Spec.Schedule); err != nil {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("schedule"), r.Spec.Schedule, err.Error()))
+	}
+	if r.Spec.TimeZone != nil {
+		if err := validateTimeZone(*r.Spec.TimeZone); err != nil {
+			allErrs = append(allErrs, field.Invalid(specPath.Child("timeZone"), *r.Spec.TimeZone, err.Error()))
+		}
+	}
+
+	switch {
+	case r.Spec.JobTemplate != nil && r.Spec.BatchJobTemplate != nil:
+		allErrs = append(allErrs, field.Forbidden(specPath.Child("batchJobTemplate"), "may not be specified with jobTemplate"))
+	case r.Spec.JobTemplate != nil:
+		restartPolicyPath := specPath.Child("jobTemplate", "spec", "restartPolicy")
+		if err := validateRestartPolicy(restartPolicyPath, r.Spec.JobTemplate.Spec.RestartPolicy); err != nil {
+			allErrs = append(allErrs, err)
+		}
+	case r.Spec.BatchJobTemplate != nil:
+		restartPolicyPath := specPath.Child("batchJobTemplate", "spec", "template", "spec", "restartPolicy")
+		if err := validateRestartPolicy(restartPolicyPath, r.Spec.BatchJobTemplate.Spec.Template.Spec.RestartPolicy); err != nil {
+			allErrs = append(allErrs, err)
+		}
+	default:
+		allErrs = append(allErrs, field.Required(specPath.Child("jobTemplate"), "one of jobTemplate and batchJobTemplate must be specified"))
+	}
+
+	return allErrs
+}
+
+// validateTimeZone validates the time zone is a known IANA time zone name.
+func validateTimeZone(name string) error {
+	// The host dependent "Local" is not an IANA time zone name
+	if len(name) == 0 || strings.EqualFold(name, "Local") {
+		return fmt.Errorf("unknown time zone %q", name)
+	}
+
+	_, err := time.LoadLocation(name)
+	return err
+}
+
+// validateRestartPolicy validates the pods of a run are able to finish, an
+// empty restart policy is defaulted to Always by the api server.
+func validateRestartPolicy(path *field.Path, restartPolicy corev1.RestartPolicy) *field.Error {
+	if restartPolicy == "" || restartPolicy == corev1.RestartPolicyAlways {
+		return field.NotSupported(path, restartPolicy, []string{string(corev1.RestartPolicyOnFailure), string(corev1.RestartPolicyNever)})
+	}
+	return nil
+}
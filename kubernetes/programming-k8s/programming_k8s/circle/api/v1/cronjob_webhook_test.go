@@ -0,0 +1,255 @@
+/*
+Copyright 2023 Jayson Wang.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	_ "time/tzdata"
+
+	kbatch "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newCronJob(name string, spec CronJobSpec) *CronJob {
+	return &CronJob{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec:       spec,
+	}
+}
+
+func newPodTemplate(restartPolicy corev1.RestartPolicy) *corev1.PodTemplateSpec {
+	return &corev1.PodTemplateSpec{
+		Spec: corev1.PodSpec{
+			RestartPolicy: restartPolicy,
+			Containers:    []corev1.Container{{Name: "hello", Image: "busybox"}},
+		},
+	}
+}
+
+func newBatchJobTemplate(restartPolicy corev1.RestartPolicy) *kbatch.JobTemplateSpec {
+	return &kbatch.JobTemplateSpec{
+		Spec: kbatch.JobSpec{Template: *newPodTemplate(restartPolicy)},
+	}
+}
+
+// causes returns the "<type> <field>" of the causes of the invalid error,
+// e.g. "FieldValueInvalid spec.schedule".
+func causes(t *testing.T, err error) []string {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+
+	var statusErr *apierrors.StatusError
+	if !errors.As(err, &statusErr) || !apierrors.IsInvalid(err) {
+		t.Fatalf("expected an invalid error, got %v", err)
+	}
+	var fields []string
+	for _, cause := range statusErr.ErrStatus.Details.Causes {
+		fields = append(fields, string(cause.Type)+" "+cause.Field)
+	}
+	return fields
+}
+
+func TestCronJobDefault(t *testing.T) {
+	suspend, successful, failed := true, int32(5), int32(0)
+	defaultSuccessful, defaultFailed := DefaultSuccessfulJobsHistoryLimit, DefaultFailedJobsHistoryLimit
+
+	tests := []struct {
+		name     string
+		spec     CronJobSpec
+		expected CronJobSpec
+	}{
+		{
+			name: "defaults",
+			spec: CronJobSpec{Schedule: "*/1 * * * *", JobTemplate: newPodTemplate("")},
+			expected: CronJobSpec{
+				Schedule:                   "*/1 * * * *",
+				ConcurrencyPolicy:          AllowConcurrent,
+				CatchUpPolicy:              RunLatestCatchUp,
+				Suspend:                    new(bool),
+				SuccessfulJobsHistoryLimit: &defaultSuccessful,
+				FailedJobsHistoryLimit:     &defaultFailed,
+				JobTemplate:                newPodTemplate(corev1.RestartPolicyNever),
+			},
+		},
+		{
+			name: "specified",
+			spec: CronJobSpec{
+				Schedule:                   "*/1 * * * *",
+				ConcurrencyPolicy:          ForbidConcurrent,
+				CatchUpPolicy:              SkipCatchUp,
+				Suspend:                    &suspend,
+				SuccessfulJobsHistoryLimit: &successful,
+				FailedJobsHistoryLimit:     &failed,
+				JobTemplate:                newPodTemplate(corev1.RestartPolicyOnFailure),
+			},
+			expected: CronJobSpec{
+				Schedule:                   "*/1 * * * *",
+				ConcurrencyPolicy:          ForbidConcurrent,
+				CatchUpPolicy:              SkipCatchUp,
+				Suspend:                    &suspend,
+				SuccessfulJobsHistoryLimit: &successful,
+				FailedJobsHistoryLimit:     &failed,
+				JobTemplate:                newPodTemplate(corev1.RestartPolicyOnFailure),
+			},
+		},
+		{
+			// The restart policy of a Job is required by the api server
+			name: "batch job template",
+			spec: CronJobSpec{Schedule: "*/1 * * * *", BatchJobTemplate: newBatchJobTemplate("")},
+			expected: CronJobSpec{
+				Schedule:                   "*/1 * * * *",
+				ConcurrencyPolicy:          AllowConcurrent,
+				CatchUpPolicy:              RunLatestCatchUp,
+				Suspend:                    new(bool),
+				SuccessfulJobsHistoryLimit: &defaultSuccessful,
+				FailedJobsHistoryLimit:     &defaultFailed,
+				BatchJobTemplate:           newBatchJobTemplate(""),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cronJob := newCronJob("hello", tt.spec)
+			cronJob.Default()
+			if !reflect.DeepEqual(cronJob.Spec, tt.expected) {
+				t.Fatalf("expected spec %+v, got %+v", tt.expected, cronJob.Spec)
+			}
+		})
+	}
+}
+
+func TestValidateCronJob(t *testing.T) {
+	newYork, local, unknown := "America/New_York", "Local", "Mars/Olympus_Mons"
+
+	tests := []struct {
+		name     string
+		cronJob  *CronJob
+		expected []string
+	}{
+		{
+			name:    "job template",
+			cronJob: newCronJob("hello", CronJobSpec{Schedule: "*/1 * * * *", JobTemplate: newPodTemplate(corev1.RestartPolicyNever)}),
+		},
+		{
+			name:    "batch job template",
+			cronJob: newCronJob("hello", CronJobSpec{Schedule: "@hourly", BatchJobTemplate: newBatchJobTemplate(corev1.RestartPolicyOnFailure)}),
+		},
+		{
+			name: "time zone",
+			cronJob: newCronJob("hello", CronJobSpec{
+				Schedule:    "30 2 * * *",
+				TimeZone:    &newYork,
+				JobTemplate: newPodTemplate(corev1.RestartPolicyNever),
+			}),
+		},
+		{
+			name:    "longest name",
+			cronJob: newCronJob(strings.Repeat("a", maxCronJobNameLength), CronJobSpec{Schedule: "*/1 * * * *", JobTemplate: newPodTemplate(corev1.RestartPolicyNever)}),
+		},
+		{
+			name:     "name too long",
+			cronJob:  newCronJob(strings.Repeat("a", maxCronJobNameLength+1), CronJobSpec{Schedule: "*/1 * * * *", JobTemplate: newPodTemplate(corev1.RestartPolicyNever)}),
+			expected: []string{"FieldValueTooLong metadata.name"},
+		},
+		{
+			name:     "malformed schedule",
+			cronJob:  newCronJob("hello", CronJobSpec{Schedule: "every minute", JobTemplate: newPodTemplate(corev1.RestartPolicyNever)}),
+			expected: []string{"FieldValueInvalid spec.schedule"},
+		},
+		{
+			name:     "schedule with seconds",
+			cronJob:  newCronJob("hello", CronJobSpec{Schedule: "0 */1 * * * *", JobTemplate: newPodTemplate(corev1.RestartPolicyNever)}),
+			expected: []string{"FieldValueInvalid spec.schedule"},
+		},
+		{
+			name: "unknown time zone",
+			cronJob: newCronJob("hello", CronJobSpec{
+				Schedule:    "*/1 * * * *",
+				TimeZone:    &unknown,
+				JobTemplate: newPodTemplate(corev1.RestartPolicyNever),
+			}),
+			expected: []string{"FieldValueInvalid spec.timeZone"},
+		},
+		{
+			name: "local time zone",
+			cronJob: newCronJob("hello", CronJobSpec{
+				Schedule:    "*/1 * * * *",
+				TimeZone:    &local,
+				JobTemplate: newPodTemplate(corev1.RestartPolicyNever),
+			}),
+			expected: []string{"FieldValueInvalid spec.timeZone"},
+		},
+		{
+			name:     "no template",
+			cronJob:  newCronJob("hello", CronJobSpec{Schedule: "*/1 * * * *"}),
+			expected: []string{"FieldValueRequired spec.jobTemplate"},
+		},
+		{
+			name: "both templates",
+			cronJob: newCronJob("hello", CronJobSpec{
+				Schedule:         "*/1 * * * *",
+				JobTemplate:      newPodTemplate(corev1.RestartPolicyNever),
+				BatchJobTemplate: newBatchJobTemplate(corev1.RestartPolicyNever),
+			}),
+			expected: []string{"FieldValueForbidden spec.batchJobTemplate"},
+		},
+		{
+			name:     "job template restarted always",
+			cronJob:  newCronJob("hello", CronJobSpec{Schedule: "*/1 * * * *", JobTemplate: newPodTemplate(corev1.RestartPolicyAlways)}),
+			expected: []string{"FieldValueNotSupported spec.jobTemplate.spec.restartPolicy"},
+		},
+		{
+			name:     "batch job template without restart policy",
+			cronJob:  newCronJob("hello", CronJobSpec{Schedule: "*/1 * * * *", BatchJobTemplate: newBatchJobTemplate("")}),
+			expected: []string{"FieldValueNotSupported spec.batchJobTemplate.spec.template.spec.restartPolicy"},
+		},
+		{
+			// All the errors are reported at once
+			name:     "everything wrong",
+			cronJob:  newCronJob(strings.Repeat("a", maxCronJobNameLength+1), CronJobSpec{Schedule: "", TimeZone: &unknown}),
+			expected: []string{"FieldValueTooLong metadata.name", "FieldValueInvalid spec.schedule", "FieldValueInvalid spec.timeZone", "FieldValueRequired spec.jobTemplate"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.cronJob.ValidateCreate()
+			if fields := causes(t, err); !reflect.DeepEqual(fields, tt.expected) {
+				t.Fatalf("expected errors %v on create, got %v", tt.expected, err)
+			}
+
+			// The same rules are validated on update
+			_, err = tt.cronJob.ValidateUpdate(tt.cronJob.DeepCopy())
+			if fields := causes(t, err); !reflect.DeepEqual(fields, tt.expected) {
+				t.Fatalf("expected errors %v on update, got %v", tt.expected, err)
+			}
+
+			// Nothing is validated on deletion
+			if _, err = tt.cronJob.ValidateDelete(); err != nil {
+				t.Fatalf("expected no error on delete, got %v", err)
+			}
+		})
+	}
+}
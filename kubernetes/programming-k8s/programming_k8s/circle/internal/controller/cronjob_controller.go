@@ -19,17 +19,23 @@ package controller
 import (
 	"context"
 	"errors"
+	"fmt"
 	"sort"
 	"time"
 
-	"github.com/robfig/cron"
+	kbatch "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/reference"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
 	batchv1 "github.com/wjiec/programming_k8s/circle/api/v1"
 )
@@ -44,11 +50,26 @@ type CronJobReconciler struct {
 //+kubebuilder:rbac:groups=batch.example.org,resources=cronjobs,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=batch.example.org,resources=cronjobs/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=batch.example.org,resources=cronjobs/finalizers,verbs=update
-//+kubebuilder:rbac:groups=v1,resources=pods,verbs=get;list;watch;create;update;patch;delete
-//+kubebuilder:rbac:groups=v1,resources=pods/status,verbs=get
+//+kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=core,resources=pods/status,verbs=get
+//+kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=batch,resources=jobs/status,verbs=get
 
 const (
 	jobOwnerKey = ".metadata.controlled-by"
+
+	// maxCatchUpRuns bounds the backlog of the missed runs run by the RunAll
+	// catch-up policy, the earlier runs are missed.
+	maxCatchUpRuns = 100
+
+	// maxScheduledTimes bounds the scheduled times evaluated in a reconcile,
+	// the rest are evaluated in the following reconciles.
+	maxScheduledTimes = 10 * maxCatchUpRuns
+
+	// skipGracePeriod is how late the run on schedule may be started by the
+	// Skip catch-up policy if no starting deadline is specified, it covers the
+	// delay of the reconcile requeued for the run.
+	skipGracePeriod = 10 * time.Second
 )
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
@@ -78,40 +99,85 @@ func (r *CronJobReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 	}
 	logger.V(1).Info("child of the cronjob", "pods", childPods)
 
+	var childJobs kbatch.JobList
+	if err := r.List(ctx, &childJobs, client.InNamespace(req.Namespace), client.MatchingFields{jobOwnerKey: req.Name}); err != nil {
+		logger.Error(err, "unable to list child Jobs")
+		return ctrl.Result{}, err
+	}
+	logger.V(1).Info("child of the cronjob", "jobs", childJobs)
+
+	// the pods and jobs are both tracked whatever the template is, so the runs
+	// created before the template is changed are still limited and cleaned up.
+	var children []client.Object
+	for idx := range childPods.Items {
+		children = append(children, &childPods.Items[idx])
+	}
+	for idx := range childJobs.Items {
+		children = append(children, &childJobs.Items[idx])
+	}
+
+	// the last schedule time is kept once the runs are deleted by the history limits
 	var lastScheduledTime = cronJob.CreationTimestamp.Time
-	var activePods, failedPods, successfulPods []*corev1.Pod
-	for idx, pod := range childPods.Items {
-		switch pod.Status.Phase {
-		case corev1.PodSucceeded:
-			successfulPods = append(successfulPods, &childPods.Items[idx])
-		case corev1.PodFailed:
-			failedPods = append(failedPods, &childPods.Items[idx])
+	if cronJob.Status.LastScheduleTime != nil && cronJob.Status.LastScheduleTime.After(lastScheduledTime) {
+		lastScheduledTime = cronJob.Status.LastScheduleTime.Time
+	}
+	var activeRuns, failedRuns, successfulRuns []client.Object
+	for _, child := range children {
+		switch finished, succeeded := getRunResult(child); {
+		case !finished:
+			activeRuns = append(activeRuns, child)
+		case succeeded:
+			successfulRuns = append(successfulRuns, child)
 		default:
-			activePods = append(activePods, &childPods.Items[idx])
+			failedRuns = append(failedRuns, child)
 		}
 
-		podLastScheduledTime, err := getScheduleTimeForPod(&pod)
+		runScheduledTime, err := getScheduleTimeForRun(child)
 		if err != nil {
-			logger.Error(err, "unable to parse schedule time for child pod", "pod", &pod)
+			logger.Error(err, "unable to parse schedule time for child run", "run", child)
 			continue
 		}
 
-		if podLastScheduledTime.After(lastScheduledTime) {
-			lastScheduledTime = podLastScheduledTime
+		if runScheduledTime.After(lastScheduledTime) {
+			lastScheduledTime = runScheduledTime
 		}
 	}
-	logger.V(1).Info("job count", "active", len(activePods), "failed", len(failedPods), "successful", len(successfulPods))
+	logger.V(1).Info("job count", "active", len(activeRuns), "failed", len(failedRuns), "successful", len(successfulRuns))
+
+	// the schedule is evaluated in the time zone of the CronJob, an unknown time
+	// zone is shown as empty in the status.
+	location, timeZoneErr := r.getTimeZone(&cronJob)
+
+	// the finished runs are counted against the last status, before the
+	// active runs and the last schedule time of it are updated.
+	countFinishedRuns(&cronJob, successfulRuns, failedRuns)
 
 	cronJob.Status.Active = nil
 	cronJob.Status.LastScheduleTime = &metav1.Time{Time: lastScheduledTime}
-	for _, pod := range activePods {
-		podRef, err := reference.GetReference(r.Scheme, pod)
+	cronJob.Status.TimeZone = ""
+	if timeZoneErr == nil {
+		cronJob.Status.TimeZone = location.String()
+	}
+	for _, run := range activeRuns {
+		runRef, err := reference.GetReference(r.Scheme, run)
 		if err != nil {
-			logger.Error(err, "unable to make reference to active job", "pod", pod)
+			logger.Error(err, "unable to make reference to active job", "run", run)
 			continue
 		}
-		cronJob.Status.Active = append(cronJob.Status.Active, *podRef)
+		cronJob.Status.Active = append(cronJob.Status.Active, *runRef)
+	}
+
+	// the finished runs beyond the history limits are deleted right after, so
+	// they're not retained. the last successful time is kept once they're gone.
+	cronJob.Status.RetainedSucceeded = getRetainedRuns(successfulRuns, cronJob.Spec.SuccessfulJobsHistoryLimit)
+	cronJob.Status.RetainedFailed = getRetainedRuns(failedRuns, cronJob.Spec.FailedJobsHistoryLimit)
+	for _, run := range successfulRuns {
+		completionTime := getCompletionTimeForRun(run)
+		if completionTime != nil && (cronJob.Status.LastSuccessfulTime == nil || completionTime.After(cronJob.Status.LastSuccessfulTime.Time)) {
+			cronJob.Status.LastSuccessfulTime = completionTime
+		}
 	}
+	setStatusConditions(&cronJob, activeRuns, successfulRuns, failedRuns, timeZoneErr)
 
 	if err := r.Status().Update(ctx, &cronJob); err != nil {
 		logger.Error(err, "unable to update CronJob status")
@@ -123,36 +189,10 @@ func (r *CronJobReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 	// NB: deleting these are "best effort" -- if we fail on a particular one,
 	// we won't requeue just to finish the deleting.
 	if cronJob.Spec.FailedJobsHistoryLimit != nil {
-		sort.Slice(failedPods, func(i, j int) bool {
-			if failedPods[i].Status.StartTime == nil {
-				return failedPods[j].Status.StartTime != nil
-			}
-			return failedPods[i].Status.StartTime.Before(failedPods[j].Status.StartTime)
-		})
-
-		for i := 0; i <= len(failedPods)-int(*cronJob.Spec.FailedJobsHistoryLimit); i++ {
-			if err := r.Delete(ctx, failedPods[i], client.PropagationPolicy(metav1.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
-				logger.Error(err, "unable to delete old failed pod", "pod", failedPods[i])
-			} else {
-				logger.V(0).Info("deleted old failed pod", "pod", failedPods[i])
-			}
-		}
+		r.deleteOldRuns(ctx, failedRuns, int(*cronJob.Spec.FailedJobsHistoryLimit))
 	}
 	if cronJob.Spec.SuccessfulJobsHistoryLimit != nil {
-		sort.Slice(successfulPods, func(i, j int) bool {
-			if successfulPods[i].Status.StartTime == nil {
-				return successfulPods[j].Status.StartTime != nil
-			}
-			return successfulPods[i].Status.StartTime.Before(successfulPods[j].Status.StartTime)
-		})
-
-		for i := 0; i <= len(successfulPods)-int(*cronJob.Spec.SuccessfulJobsHistoryLimit); i++ {
-			if err := r.Delete(ctx, successfulPods[i], client.PropagationPolicy(metav1.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
-				logger.Error(err, "unable to delete old failed pod", "pod", successfulPods[i])
-			} else {
-				logger.V(0).Info("deleted old failed pod", "pod", successfulPods[i])
-			}
-		}
+		r.deleteOldRuns(ctx, successfulRuns, int(*cronJob.Spec.SuccessfulJobsHistoryLimit))
 	}
 
 	// Stage 4: Check if we’re suspended
@@ -164,67 +204,165 @@ func (r *CronJobReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 
 	// Stage 5: Get the next scheduled run
 
-	// figure out the next times that we need to create jobs at (or anything we missed).
-	missedRun, nextRun, err := getNextScheduledTime(&cronJob, r.Now())
+	if timeZoneErr != nil {
+		logger.Error(timeZoneErr, "unable to load the time zone of CronJob", "timeZone", *cronJob.Spec.TimeZone)
+		// we don't really care about requeuing until we get an update that
+		// fixes the time zone, so don't return an error
+		return ctrl.Result{}, nil
+	}
+
+	// figure out the times that we need to create jobs at (or anything we missed).
+	dueRuns, nextRun, moreDueRuns, err := getDueRuns(&cronJob, r.Now().In(location))
 	if err != nil {
 		logger.Error(err, "unable to figure out CronJob schedule")
-		// we don't really care about requeuing until we get an update that
-		// fixes the schedule, so don't return an error
+		// the schedule is validated by the webhook, an invalid schedule is only
+		// admitted when the webhooks are disabled and reported by the Ready
+		// condition. we don't really care about requeuing until we get an
+		// update that fixes the schedule, so don't return an error
 		return ctrl.Result{}, nil
 	}
 
+	// the catch-up policy decides which of the due runs are run, the others
+	// are missed and counted in the status.
+	pendingRuns, missedRuns := catchUp(&cronJob, dueRuns, moreDueRuns, r.Now())
+	if len(missedRuns) != 0 {
+		lastMissedRun := missedRuns[len(missedRuns)-1]
+		cronJob.Status.MissedRuns += int64(len(missedRuns))
+		cronJob.Status.LastMissedTime = &metav1.Time{Time: lastMissedRun}
+		setCondition(&cronJob, batchv1.CronJobMissedRuns, metav1.ConditionTrue, batchv1.ReasonRunsMissed,
+			fmt.Sprintf("%d runs are missed, the last one was scheduled at %s", len(missedRuns), lastMissedRun.Format(time.RFC3339)))
+	} else if len(pendingRuns) != 0 {
+		setCondition(&cronJob, batchv1.CronJobMissedRuns, metav1.ConditionFalse, batchv1.ReasonRunsOnSchedule, "No runs are missed")
+	}
+	if len(missedRuns) != 0 || len(pendingRuns) != 0 {
+		if err := r.Status().Update(ctx, &cronJob); err != nil {
+			logger.Error(err, "unable to update CronJob status")
+			return ctrl.Result{}, err
+		}
+	}
+	if moreDueRuns {
+		logger.V(1).Info("too many missed runs, evaluating the rest later", "missed", len(missedRuns))
+		return ctrl.Result{RequeueAfter: time.Second}, nil
+	}
+
 	// Stage 6: Run a new job if it’s on schedule, not past the deadline, and not blocked by our concurrency policy
 	waitingNextScheduleResult := ctrl.Result{RequeueAfter: nextRun.Sub(r.Now())}
-	if missedRun.IsZero() {
+	if len(pendingRuns) == 0 {
 		logger.V(1).Info("no upcoming scheduled times, sleeping until next")
 		return waitingNextScheduleResult, nil
 	}
 
-	// If we’ve missed a run, and we’re still within the deadline to start it, we’ll need to run a job.
-	if cronJob.Spec.StartingDeadlineSeconds != nil {
-		// make sure we're not too late to start the run
-		schedulingDeadline := missedRun.Add(time.Second * time.Duration(*cronJob.Spec.StartingDeadlineSeconds))
-		if schedulingDeadline.Before(r.Now()) {
-			logger.V(1).Info("missed starting deadline for last run, sleeping till next")
-			return waitingNextScheduleResult, nil
-		}
+	// the backlog of the missed runs is run one after another, we'll requeue
+	// once we see the active runs finished.
+	if len(pendingRuns) > 1 && len(activeRuns) > 0 {
+		logger.V(1).Info("catching up missed runs after active runs", "backlog", len(pendingRuns), "num active", len(activeRuns))
+		return waitingNextScheduleResult, nil
 	}
 
 	// now, we actually have to run a job, we’ll need to either wait till existing
 	// ones finish, replace the existing ones, or just add new ones.
-	if cronJob.Spec.ConcurrencyPolicy == batchv1.ForbidConcurrent && len(activePods) > 0 {
-		logger.V(1).Info("concurrency policy blocks concurrent runs, skipping", "num active", len(activePods))
+	if cronJob.Spec.ConcurrencyPolicy == batchv1.ForbidConcurrent && len(activeRuns) > 0 {
+		logger.V(1).Info("concurrency policy blocks concurrent runs, skipping", "num active", len(activeRuns))
 		return waitingNextScheduleResult, nil
 	}
 	if cronJob.Spec.ConcurrencyPolicy == batchv1.ReplaceConcurrent {
-		for _, activePod := range activePods {
+		for _, activeRun := range activeRuns {
 			// we don't care if the job was already deleted
-			if err = r.Delete(ctx, activePod, client.PropagationPolicy(metav1.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
-				logger.Error(err, "unable to delete active pod", "pod", activePod)
+			if err = r.Delete(ctx, activeRun, client.PropagationPolicy(metav1.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
+				logger.Error(err, "unable to delete active run", "run", activeRun)
 				return ctrl.Result{}, err
 			}
 		}
 	}
 
 	// we’ll actually create our desired job
-	pod, err := r.newPodForCronJob(&cronJob, missedRun)
+	run, err := r.newRunForCronJob(&cronJob, pendingRuns[0])
 	if err != nil {
 		logger.Error(err, "unable to construct job from template")
 		// don't requeue until we get a change to the spec
 		return waitingNextScheduleResult, nil
 	}
-	if err = r.Create(ctx, pod); err != nil {
-		logger.Error(err, "unable to create Pod for CronJob", "pod", pod)
+	if err = r.Create(ctx, run); err != nil {
+		// the job of the run is named after the scheduled time, it's already
+		// created if our cache is not up-to-date.
+		if apierrors.IsAlreadyExists(err) {
+			logger.V(1).Info("run of CronJob already created", "run", run)
+			return waitingNextScheduleResult, nil
+		}
+		logger.Error(err, "unable to create run for CronJob", "run", run)
 		return ctrl.Result{}, err
 	}
 
-	// Stage 7: Requeue when we either see a running pod or it’s time for the next scheduled run
+	// Stage 7: Requeue when we either see a running job or it’s time for the next scheduled run
 
-	logger.V(1).Info("created Pod for CronJob run", "pod", pod)
-	// we'll requeue once we see the running pod, and update our status
+	logger.V(1).Info("created run for CronJob", "run", run)
+	// we'll requeue once we see the running job, and update our status
 	return waitingNextScheduleResult, nil
 }
 
+// deleteOldRuns deletes the oldest finished runs beyond the history limit.
+func (r *CronJobReconciler) deleteOldRuns(ctx context.Context, runs []client.Object, limit int) {
+	logger := log.FromContext(ctx)
+
+	sort.Slice(runs, func(i, j int) bool {
+		startTime := getStartTimeForRun(runs[i])
+		if startTime == nil {
+			return getStartTimeForRun(runs[j]) != nil
+		}
+		return startTime.Before(getStartTimeForRun(runs[j]))
+	})
+
+	for i := 0; i < len(runs)-limit; i++ {
+		// the pods of the deleted jobs are deleted in the background
+		if err := r.Delete(ctx, runs[i], client.PropagationPolicy(metav1.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
+			logger.Error(err, "unable to delete old finished run", "run", runs[i])
+		} else {
+			logger.V(0).Info("deleted old finished run", "run", runs[i])
+		}
+	}
+}
+
+// newRunForCronJob construct a job or a pod for the run of the CronJob,
+// depending on which template is specified.
+func (r *CronJobReconciler) newRunForCronJob(cronJob *batchv1.CronJob, scheduledTime time.Time) (client.Object, error) {
+	switch {
+	case cronJob.Spec.BatchJobTemplate != nil:
+		return r.newJobForCronJob(cronJob, scheduledTime)
+	case cronJob.Spec.JobTemplate != nil:
+		return r.newPodForCronJob(cronJob, scheduledTime)
+	}
+	return nil, errors.New("neither jobTemplate nor batchJobTemplate is specified")
+}
+
+// newJobForCronJob construct a job based on our CronJob’s template. The job is
+// named after the scheduled time, so that a run is never created twice.
+func (r *CronJobReconciler) newJobForCronJob(cronJob *batchv1.CronJob, scheduledTime time.Time) (*kbatch.Job, error) {
+	template := cronJob.Spec.BatchJobTemplate
+	job := &kbatch.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels:      map[string]string{},
+			Annotations: map[string]string{},
+			Name:        fmt.Sprintf("%s-%d", cronJob.Name, scheduledTime.Unix()),
+			Namespace:   cronJob.Namespace,
+		},
+		Spec: *template.Spec.DeepCopy(),
+	}
+
+	for k, v := range template.Labels {
+		job.Labels[k] = v
+	}
+	for k, v := range template.Annotations {
+		job.Annotations[k] = v
+	}
+	job.Annotations[scheduledTimeAnnotation] = scheduledTime.Format(time.RFC3339)
+
+	if err := ctrl.SetControllerReference(cronJob, job, r.Scheme); err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
 // newPodForCronJob construct a pod based on our CronJob’s template.
 // We’ll copy over the spec from the template and copy some basic object meta.
 func (r *CronJobReconciler) newPodForCronJob(cronJob *batchv1.CronJob, scheduledTime time.Time) (*corev1.Pod, error) {
@@ -259,95 +397,290 @@ func (r *CronJobReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		r.Clock = realClock{}
 	}
 
-	err := mgr.GetFieldIndexer().IndexField(context.Background(), &corev1.Pod{}, jobOwnerKey, func(object client.Object) []string {
-		if pod, ok := object.(*corev1.Pod); ok {
-			if ownerRef := metav1.GetControllerOf(pod); ownerRef != nil {
-				if ownerRef.APIVersion == batchv1.GroupVersion.String() && ownerRef.Kind == "CronJob" {
-					return []string{ownerRef.Name}
-				}
+	// the runs are indexed by their owner CronJob, whichever kind they are
+	indexByOwner := func(object client.Object) []string {
+		if ownerRef := metav1.GetControllerOf(object); ownerRef != nil {
+			if ownerRef.APIVersion == batchv1.GroupVersion.String() && ownerRef.Kind == "CronJob" {
+				return []string{ownerRef.Name}
 			}
 		}
 		return nil
-	})
-	if err != nil {
-		return err
+	}
+	for _, object := range []client.Object{&corev1.Pod{}, &kbatch.Job{}} {
+		if err := mgr.GetFieldIndexer().IndexField(context.Background(), object, jobOwnerKey, indexByOwner); err != nil {
+			return err
+		}
 	}
 
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&batchv1.CronJob{}).
+		Owns(&corev1.Pod{}, builder.WithPredicates(runPhaseChanged)).
+		Owns(&kbatch.Job{}, builder.WithPredicates(runPhaseChanged)).
 		Complete(r)
 }
 
+// runPhaseChanged only passes the updates of the runs whose phase is changed.
+// The runs keep updating their status while running, e.g. the statuses of the
+// containers, which don't change the status of the CronJob.
+var runPhaseChanged = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		return getRunPhase(e.ObjectOld) != getRunPhase(e.ObjectNew)
+	},
+}
+
 var (
 	scheduledTimeAnnotation = "batch.example.org/scheduled-at"
 
 	ErrScheduleTimeNotFound = errors.New("scheduled time not found in the pod")
 )
 
-// getScheduleTimeForPod extract the scheduled time from the annotation
+// getScheduleTimeForRun extract the scheduled time from the annotation
 // that we added during job creation.
-func getScheduleTimeForPod(pod *corev1.Pod) (time.Time, error) {
-	scheduledTime := pod.Annotations[scheduledTimeAnnotation]
+func getScheduleTimeForRun(run client.Object) (time.Time, error) {
+	scheduledTime := run.GetAnnotations()[scheduledTimeAnnotation]
 	if len(scheduledTime) != 0 {
 		return time.Parse(time.RFC3339, scheduledTime)
 	}
 	return time.Time{}, ErrScheduleTimeNotFound
 }
 
-// getNextScheduledTime calculate what time we should execute the new jobs based on
-// the earliest time, as well as calculate the next run time after the current time.
-func getNextScheduledTime(cronJob *batchv1.CronJob, now time.Time) (time.Time, time.Time, error) {
-	schedule, err := cron.ParseStandard(cronJob.Spec.Schedule)
-	if err != nil {
-		return time.Time{}, time.Time{}, err
+// getRunResult returns whether the pod or job of the run is finished, and
+// whether it succeeded. A job is finished once it's complete or failed.
+func getRunResult(run client.Object) (finished bool, succeeded bool) {
+	switch run := run.(type) {
+	case *corev1.Pod:
+		return run.Status.Phase == corev1.PodSucceeded || run.Status.Phase == corev1.PodFailed, run.Status.Phase == corev1.PodSucceeded
+	case *kbatch.Job:
+		for _, c := range run.Status.Conditions {
+			if (c.Type == kbatch.JobComplete || c.Type == kbatch.JobFailed) && c.Status == corev1.ConditionTrue {
+				return true, c.Type == kbatch.JobComplete
+			}
+		}
+	}
+	return false, false
+}
+
+// getRunPhase returns the phase of the pod, or whether the job is active,
+// complete or failed.
+func getRunPhase(run client.Object) string {
+	if pod, ok := run.(*corev1.Pod); ok {
+		return string(pod.Status.Phase)
+	}
+
+	switch finished, succeeded := getRunResult(run); {
+	case !finished:
+		return "Active"
+	case succeeded:
+		return string(kbatch.JobComplete)
+	default:
+		return string(kbatch.JobFailed)
+	}
+}
+
+// getCompletionTimeForRun returns the time the pod or job of the run is
+// completed, or nil if unknown.
+func getCompletionTimeForRun(run client.Object) *metav1.Time {
+	switch run := run.(type) {
+	case *corev1.Pod:
+		var completionTime *metav1.Time
+		for _, containerStatus := range run.Status.ContainerStatuses {
+			if terminated := containerStatus.State.Terminated; terminated != nil {
+				if completionTime == nil || terminated.FinishedAt.After(completionTime.Time) {
+					completionTime = terminated.FinishedAt.DeepCopy()
+				}
+			}
+		}
+		return completionTime
+	case *kbatch.Job:
+		return run.Status.CompletionTime.DeepCopy()
+	}
+	return nil
+}
+
+// countFinishedRuns adds the finished runs to the running totals of the
+// CronJob, each run is counted once. The runs not counted yet are either
+// active in the last status, or scheduled after its last schedule time, i.e.
+// they're not seen by the last status at all.
+func countFinishedRuns(cronJob *batchv1.CronJob, successfulRuns, failedRuns []client.Object) {
+	active := make(map[string]bool, len(cronJob.Status.Active))
+	for _, ref := range cronJob.Status.Active {
+		active[ref.Name] = true
+	}
+
+	counted := func(run client.Object) bool {
+		if active[run.GetName()] {
+			return false
+		}
+		if cronJob.Status.LastScheduleTime == nil {
+			return false
+		}
+		scheduledTime, err := getScheduleTimeForRun(run)
+		return err != nil || !scheduledTime.After(cronJob.Status.LastScheduleTime.Time)
+	}
+	for _, run := range successfulRuns {
+		if !counted(run) {
+			cronJob.Status.Succeeded++
+		}
+	}
+	for _, run := range failedRuns {
+		if !counted(run) {
+			cronJob.Status.Failed++
+		}
+	}
+}
+
+// getRetainedRuns returns the number of the runs kept in the history limit.
+func getRetainedRuns(runs []client.Object, limit *int32) int32 {
+	if limit != nil && int(*limit) < len(runs) {
+		return *limit
+	}
+	return int32(len(runs))
+}
+
+// setStatusConditions sets the conditions of the CronJob from its runs, and
+// the errors of its time zone and schedule.
+func setStatusConditions(cronJob *batchv1.CronJob, activeRuns, successfulRuns, failedRuns []client.Object, timeZoneErr error) {
+	// the schedule is validated regardless of the time zone
+	_, scheduleErr := newSchedule(cronJob.Spec.Schedule, time.UTC)
+	switch {
+	case timeZoneErr != nil:
+		setCondition(cronJob, batchv1.CronJobReady, metav1.ConditionFalse, batchv1.ReasonInvalidTimeZone, timeZoneErr.Error())
+	case scheduleErr != nil:
+		setCondition(cronJob, batchv1.CronJobReady, metav1.ConditionFalse, batchv1.ReasonInvalidSchedule, scheduleErr.Error())
+	case cronJob.Spec.Suspend != nil && *cronJob.Spec.Suspend:
+		setCondition(cronJob, batchv1.CronJobReady, metav1.ConditionFalse, batchv1.ReasonSuspended, "CronJob is suspended")
+	default:
+		setCondition(cronJob, batchv1.CronJobReady, metav1.ConditionTrue, batchv1.ReasonScheduling,
+			fmt.Sprintf("Schedule %q is evaluated in time zone %s", cronJob.Spec.Schedule, cronJob.Status.TimeZone))
 	}
 
-	// we’ll start calculating appropriate times from our last run, or
-	// the creation of the CronJob if we can’t find a last run.
-	var earliestTime time.Time
-	if cronJob.Status.LastScheduleTime != nil {
-		earliestTime = cronJob.Status.LastScheduleTime.Time
+	if len(activeRuns) != 0 {
+		setCondition(cronJob, batchv1.CronJobActive, metav1.ConditionTrue, batchv1.ReasonRunsActive,
+			fmt.Sprintf("%d runs are active", len(activeRuns)))
 	} else {
-		earliestTime = cronJob.CreationTimestamp.Time
+		setCondition(cronJob, batchv1.CronJobActive, metav1.ConditionFalse, batchv1.ReasonNoActiveRuns, "No runs are active")
 	}
 
-	if cronJob.Spec.StartingDeadlineSeconds != nil {
-		// controller is not going to schedule anything below this point
-		schedulingDeadline := now.Add(-time.Second * time.Duration(*cronJob.Spec.StartingDeadlineSeconds))
-		if schedulingDeadline.After(earliestTime) {
-			earliestTime = schedulingDeadline
+	// the result of the last finished run is kept once it's deleted
+	var lastRun client.Object
+	var lastScheduledTime time.Time
+	for _, run := range append(append([]client.Object{}, successfulRuns...), failedRuns...) {
+		if scheduledTime, err := getScheduleTimeForRun(run); err == nil && !scheduledTime.Before(lastScheduledTime) {
+			lastRun, lastScheduledTime = run, scheduledTime
+		}
+	}
+	if lastRun != nil {
+		if _, succeeded := getRunResult(lastRun); succeeded {
+			setCondition(cronJob, batchv1.CronJobFailed, metav1.ConditionFalse, batchv1.ReasonRunSucceeded,
+				fmt.Sprintf("Run %q succeeded", lastRun.GetName()))
+		} else {
+			setCondition(cronJob, batchv1.CronJobFailed, metav1.ConditionTrue, batchv1.ReasonRunFailed,
+				fmt.Sprintf("Run %q failed", lastRun.GetName()))
 		}
 	}
+}
+
+// setCondition sets the condition of the CronJob to the given status, the
+// transition time is only changed when the status changes.
+func setCondition(cronJob *batchv1.CronJob, conditionType string, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&cronJob.Status.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		ObservedGeneration: cronJob.Generation,
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
+// getStartTimeForRun returns the time the pod or job of the run is started.
+func getStartTimeForRun(run client.Object) *metav1.Time {
+	switch run := run.(type) {
+	case *corev1.Pod:
+		return run.Status.StartTime
+	case *kbatch.Job:
+		return run.Status.StartTime
+	}
+	return nil
+}
+
+// getDueRuns returns the scheduled times of the runs which are due, i.e. neither
+// started nor missed yet, and the next scheduled time after now. At most
+// maxScheduledTimes are returned, more is true if there are more due runs.
+func getDueRuns(cronJob *batchv1.CronJob, now time.Time) (due []time.Time, next time.Time, more bool, err error) {
+	schedule, err := newSchedule(cronJob.Spec.Schedule, now.Location())
+	if err != nil {
+		return nil, time.Time{}, false, err
+	}
 
-	// There are currently no jobs to execute
-	if earliestTime.After(now) {
-		return time.Time{}, schedule.Next(now), nil
+	// we’ll start calculating appropriate times from our last run or missed
+	// run, or the creation of the CronJob if we can’t find them.
+	earliestTime := cronJob.CreationTimestamp.Time
+	for _, t := range []*metav1.Time{cronJob.Status.LastScheduleTime, cronJob.Status.LastMissedTime} {
+		if t != nil && t.After(earliestTime) {
+			earliestTime = t.Time
+		}
 	}
 
-	lastMissed, missingJobs := time.Time{}, 0
-	for t := schedule.Next(earliestTime); !t.After(now); t = schedule.Next(t) {
-		lastMissed = t
+	for t := schedule.Next(earliestTime); !t.IsZero() && !t.After(now); t = schedule.Next(t) {
 		// An object might miss several starts. For example, if
 		// controller gets wedged on Friday at 5:01pm when everyone has
 		// gone home, and someone comes in on Tuesday AM and discovers
 		// the problem and restarts the controller, then all the hourly
-		// jobs, more than 80 of them for one hourly scheduledJob, should
-		// all start running with no further intervention (if the scheduledJob
-		// allows concurrency and late starts).
+		// jobs, more than 80 of them for one hourly scheduledJob, are
+		// due and handled by the catch-up policy.
 		//
 		// However, if there is a bug somewhere, or incorrect clock
 		// on controller's server or apiservers (for setting creationTimestamp)
 		// then there could be so many missed start times (it could be off
 		// by decades or more), that it would eat up all the CPU and memory
-		// of this controller. In that case, we want to not try to list
-		// all the missed start times.
-		if missingJobs++; missingJobs > 100 {
-			// We can't get the most recent times so just return an empty slice
-			return time.Time{}, time.Time{}, errors.New("too many missed jobs")
+		// of this controller. In that case, we only list a bounded number
+		// of them at a time.
+		if len(due) == maxScheduledTimes {
+			return due, schedule.Next(now), true, nil
+		}
+		due = append(due, t)
+	}
+
+	return due, schedule.Next(now), false, nil
+}
+
+// catchUp applies the catch-up policy of the CronJob to the due runs. It returns
+// the runs to start in order, and the runs which are missed.
+func catchUp(cronJob *batchv1.CronJob, due []time.Time, more bool, now time.Time) (pending, missed []time.Time) {
+	// there are more due runs to evaluate, only the latest runs may be started
+	if more {
+		return nil, due[:len(due)-maxCatchUpRuns]
+	}
+
+	// the runs past the starting deadline are missed regardless of the policy
+	if cronJob.Spec.StartingDeadlineSeconds != nil {
+		schedulingDeadline := now.Add(-time.Second * time.Duration(*cronJob.Spec.StartingDeadlineSeconds))
+		for len(due) != 0 && due[0].Before(schedulingDeadline) {
+			missed, due = append(missed, due[0]), due[1:]
 		}
 	}
+	if len(due) == 0 {
+		return nil, missed
+	}
 
-	return lastMissed, schedule.Next(now), nil
+	switch cronJob.Spec.CatchUpPolicy {
+	case batchv1.RunAllCatchUp:
+		if len(due) > maxCatchUpRuns {
+			missed, due = append(missed, due[:len(due)-maxCatchUpRuns]...), due[len(due)-maxCatchUpRuns:]
+		}
+		return due, missed
+	case batchv1.SkipCatchUp:
+		// only the run on schedule is started, i.e. the latest due run if it's
+		// within the starting deadline or the grace period. all the others are
+		// missed, however late the latest one is.
+		latest := due[len(due)-1]
+		if cronJob.Spec.StartingDeadlineSeconds == nil && latest.Before(now.Add(-skipGracePeriod)) {
+			return nil, append(missed, due...)
+		}
+		return due[len(due)-1:], append(missed, due[:len(due)-1]...)
+	default:
+		return due[len(due)-1:], append(missed, due[:len(due)-1]...)
+	}
 }
 
 // Clock knows how to get the current time.
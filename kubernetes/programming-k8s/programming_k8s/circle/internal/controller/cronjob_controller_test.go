@@ -0,0 +1,805 @@
+/*
+Copyright 2023 Jayson Wang.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	kbatch "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	batchv1 "github.com/wjiec/programming_k8s/circle/api/v1"
+)
+
+// catchUpStart is the creation time of the CronJobs in the tests.
+var catchUpStart = time.Date(2023, time.October, 1, 12, 0, 0, 0, time.UTC)
+
+// fakeClock is a Clock which is moved by the tests.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+// minutes returns the times of the given minutes after catchUpStart.
+func minutes(from, to int) []time.Time {
+	var times []time.Time
+	for i := from; i <= to; i++ {
+		times = append(times, catchUpStart.Add(time.Duration(i)*time.Minute))
+	}
+	return times
+}
+
+func newCatchUpCronJob(policy batchv1.CatchUpPolicy, startingDeadlineSeconds *int64) *batchv1.CronJob {
+	return &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "hello",
+			Namespace:         "default",
+			UID:               "uid-hello",
+			CreationTimestamp: metav1.NewTime(catchUpStart),
+		},
+		Spec: batchv1.CronJobSpec{
+			Schedule:                "*/1 * * * *",
+			StartingDeadlineSeconds: startingDeadlineSeconds,
+			ConcurrencyPolicy:       batchv1.AllowConcurrent,
+			CatchUpPolicy:           policy,
+			JobTemplate: &corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Containers:    []corev1.Container{{Name: "hello", Image: "busybox"}},
+				},
+			},
+		},
+	}
+}
+
+func TestGetDueRuns(t *testing.T) {
+	lastScheduleTime := metav1.NewTime(catchUpStart.Add(3 * time.Minute))
+	lastMissedTime := metav1.NewTime(catchUpStart.Add(4 * time.Minute))
+
+	tests := []struct {
+		name   string
+		status batchv1.CronJobStatus
+		now    time.Time
+		due    []time.Time
+		more   bool
+	}{
+		{name: "none due", now: catchUpStart.Add(30 * time.Second)},
+		{name: "since creation", now: catchUpStart.Add(5*time.Minute + 30*time.Second), due: minutes(1, 5)},
+		{name: "exactly on schedule", now: catchUpStart.Add(5 * time.Minute), due: minutes(1, 5)},
+		{
+			name:   "since last schedule time",
+			status: batchv1.CronJobStatus{LastScheduleTime: &lastScheduleTime},
+			now:    catchUpStart.Add(5*time.Minute + 30*time.Second),
+			due:    minutes(4, 5),
+		},
+		{
+			name:   "since last missed time",
+			status: batchv1.CronJobStatus{LastScheduleTime: &lastScheduleTime, LastMissedTime: &lastMissedTime},
+			now:    catchUpStart.Add(5*time.Minute + 30*time.Second),
+			due:    minutes(5, 5),
+		},
+		{
+			name: "bounded",
+			now:  catchUpStart.Add(time.Duration(maxScheduledTimes+10) * time.Minute),
+			due:  minutes(1, maxScheduledTimes),
+			more: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cronJob := newCatchUpCronJob(batchv1.RunAllCatchUp, nil)
+			cronJob.Status = tt.status
+
+			due, next, more, err := getDueRuns(cronJob, tt.now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(due, tt.due) || more != tt.more {
+				t.Fatalf("expected due runs %v (more: %v), got %v (more: %v)", tt.due, tt.more, due, more)
+			}
+			if expected := tt.now.Truncate(time.Minute).Add(time.Minute); !next.Equal(expected) {
+				t.Fatalf("expected the next run at %s, got %s", expected, next)
+			}
+		})
+	}
+}
+
+func TestCatchUp(t *testing.T) {
+	deadline := int64(90)
+	now := catchUpStart.Add(5*time.Minute + 30*time.Second)
+
+	tests := []struct {
+		name     string
+		policy   batchv1.CatchUpPolicy
+		deadline *int64
+		// now is catchUpStart+5m30s if it's not specified
+		now     time.Time
+		due     []time.Time
+		more    bool
+		pending []time.Time
+		missed  []time.Time
+	}{
+		{name: "nothing due", policy: batchv1.RunLatestCatchUp},
+		{name: "run latest on schedule", policy: batchv1.RunLatestCatchUp, due: minutes(5, 5), pending: minutes(5, 5)},
+		{name: "run latest", policy: batchv1.RunLatestCatchUp, due: minutes(1, 5), pending: minutes(5, 5), missed: minutes(1, 4)},
+		{name: "run all", policy: batchv1.RunAllCatchUp, due: minutes(1, 5), pending: minutes(1, 5)},
+		{name: "skip on schedule", policy: batchv1.SkipCatchUp, now: catchUpStart.Add(5*time.Minute + 5*time.Second), due: minutes(5, 5), pending: minutes(5, 5)},
+		{name: "skip within the grace period", policy: batchv1.SkipCatchUp, now: catchUpStart.Add(5*time.Minute + skipGracePeriod), due: minutes(1, 5), pending: minutes(5, 5), missed: minutes(1, 4)},
+		{
+			// RunLatest starts the latest run however late it is, Skip doesn't
+			name:   "skip late",
+			policy: batchv1.SkipCatchUp,
+			due:    minutes(1, 5),
+			missed: minutes(1, 5),
+		},
+		{name: "skip late on schedule", policy: batchv1.SkipCatchUp, due: minutes(5, 5), missed: minutes(5, 5)},
+		{
+			name:     "run latest past the deadline",
+			policy:   batchv1.RunLatestCatchUp,
+			deadline: &deadline,
+			due:      minutes(1, 5),
+			pending:  minutes(5, 5),
+			missed:   minutes(1, 4),
+		},
+		{
+			name:     "run all past the deadline",
+			policy:   batchv1.RunAllCatchUp,
+			deadline: &deadline,
+			due:      minutes(1, 5),
+			pending:  minutes(4, 5),
+			missed:   minutes(1, 3),
+		},
+		{
+			name:     "skip past the deadline",
+			policy:   batchv1.SkipCatchUp,
+			deadline: &deadline,
+			due:      minutes(1, 5),
+			pending:  minutes(5, 5),
+			missed:   minutes(1, 4),
+		},
+		{
+			// The latest run is 150s late
+			name:     "all past the deadline",
+			policy:   batchv1.SkipCatchUp,
+			deadline: &deadline,
+			due:      minutes(1, 3),
+			missed:   minutes(1, 3),
+		},
+		{
+			name:    "run all bounded",
+			policy:  batchv1.RunAllCatchUp,
+			due:     minutes(-maxCatchUpRuns-4, 5),
+			pending: minutes(-maxCatchUpRuns+6, 5),
+			missed:  minutes(-maxCatchUpRuns-4, -maxCatchUpRuns+5),
+		},
+		{
+			// Only the latest runs of the rest may be started
+			name:   "more due runs",
+			policy: batchv1.RunAllCatchUp,
+			due:    minutes(-maxScheduledTimes+6, 5),
+			more:   true,
+			missed: minutes(-maxScheduledTimes+6, -maxCatchUpRuns+5),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.now.IsZero() {
+				tt.now = now
+			}
+			cronJob := newCatchUpCronJob(tt.policy, tt.deadline)
+			pending, missed := catchUp(cronJob, tt.due, tt.more, tt.now)
+			if !reflect.DeepEqual(pending, tt.pending) {
+				t.Fatalf("expected pending runs %v, got %v", tt.pending, pending)
+			}
+			if !reflect.DeepEqual(missed, tt.missed) {
+				t.Fatalf("expected missed runs %v, got %v", tt.missed, missed)
+			}
+		})
+	}
+}
+
+// newTestReconciler returns a reconciler of the CronJob backed by the fake
+// client, with the clock at the time.
+func newTestReconciler(t *testing.T, cronJob *batchv1.CronJob, now time.Time) (*CronJobReconciler, *fakeClock) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(batchv1.AddToScheme(scheme))
+
+	indexByOwner := func(object client.Object) []string {
+		if ownerRef := metav1.GetControllerOf(object); ownerRef != nil && ownerRef.Kind == "CronJob" {
+			return []string{ownerRef.Name}
+		}
+		return nil
+	}
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(cronJob).
+		WithStatusSubresource(cronJob).
+		WithIndex(&corev1.Pod{}, jobOwnerKey, indexByOwner).
+		WithIndex(&kbatch.Job{}, jobOwnerKey, indexByOwner).
+		Build()
+
+	clock := &fakeClock{now: now}
+	return &CronJobReconciler{Client: fakeClient, Scheme: scheme, Clock: clock}, clock
+}
+
+// reconcile reconciles the CronJob and returns it with the scheduled times of
+// its runs in order.
+func reconcile(t *testing.T, r *CronJobReconciler, cronJob *batchv1.CronJob) (*batchv1.CronJob, []time.Time) {
+	t.Helper()
+	ctx := context.Background()
+	key := types.NamespacedName{Namespace: cronJob.Namespace, Name: cronJob.Name}
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatalf("reconcile at %s: %v", r.Now().Format(time.RFC3339), err)
+	}
+
+	var reconciled batchv1.CronJob
+	if err := r.Get(ctx, key, &reconciled); err != nil {
+		t.Fatal(err)
+	}
+	var pods corev1.PodList
+	if err := r.List(ctx, &pods, client.InNamespace(cronJob.Namespace)); err != nil {
+		t.Fatal(err)
+	}
+
+	var runs []time.Time
+	for i := range pods.Items {
+		scheduledTime, err := getScheduleTimeForRun(&pods.Items[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		runs = append(runs, scheduledTime)
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].Before(runs[j]) })
+	return &reconciled, runs
+}
+
+// finishRuns marks all the runs of the CronJob succeeded.
+func finishRuns(t *testing.T, r *CronJobReconciler, cronJob *batchv1.CronJob) {
+	t.Helper()
+	ctx := context.Background()
+	var pods corev1.PodList
+	if err := r.List(ctx, &pods, client.InNamespace(cronJob.Namespace)); err != nil {
+		t.Fatal(err)
+	}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		pod.Status.Phase = corev1.PodSucceeded
+		if err := r.Status().Update(ctx, pod); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func expectMissedRuns(t *testing.T, cronJob *batchv1.CronJob, count int64, last time.Time) {
+	t.Helper()
+	if cronJob.Status.MissedRuns != count {
+		t.Fatalf("expected %d missed runs, got %d", count, cronJob.Status.MissedRuns)
+	}
+	if count == 0 {
+		if cronJob.Status.LastMissedTime != nil {
+			t.Fatalf("expected no last missed time, got %s", cronJob.Status.LastMissedTime)
+		}
+		return
+	}
+	if cronJob.Status.LastMissedTime == nil || !cronJob.Status.LastMissedTime.Time.Equal(last) {
+		t.Fatalf("expected the last missed time %s, got %v", last, cronJob.Status.LastMissedTime)
+	}
+	if !meta.IsStatusConditionTrue(cronJob.Status.Conditions, batchv1.CronJobMissedRuns) {
+		t.Fatalf("expected the missed runs condition, got %v", cronJob.Status.Conditions)
+	}
+}
+
+func expectRuns(t *testing.T, runs []time.Time, expected []time.Time) {
+	t.Helper()
+	if len(runs) != len(expected) {
+		t.Fatalf("expected runs %v, got %v", expected, runs)
+	}
+	for i := range runs {
+		if !runs[i].Equal(expected[i]) {
+			t.Fatalf("expected runs %v, got %v", expected, runs)
+		}
+	}
+}
+
+func TestReconcileCatchUp(t *testing.T) {
+	deadline := int64(20)
+	now := catchUpStart.Add(5*time.Minute + 30*time.Second)
+
+	tests := []struct {
+		name     string
+		policy   batchv1.CatchUpPolicy
+		deadline *int64
+		// now is catchUpStart+5m30s if it's not specified
+		now        time.Time
+		runs       []time.Time
+		missedRuns int64
+		lastMissed time.Time
+	}{
+		{name: "run latest", policy: batchv1.RunLatestCatchUp, runs: minutes(5, 5), missedRuns: 4, lastMissed: minutes(4, 4)[0]},
+		{name: "run all", policy: batchv1.RunAllCatchUp, runs: minutes(1, 1)},
+		{name: "skip", policy: batchv1.SkipCatchUp, missedRuns: 5, lastMissed: minutes(5, 5)[0]},
+		{name: "skip on schedule", policy: batchv1.SkipCatchUp, now: catchUpStart.Add(5*time.Minute + 5*time.Second), runs: minutes(5, 5), missedRuns: 4, lastMissed: minutes(4, 4)[0]},
+		{name: "run latest past the deadline", policy: batchv1.RunLatestCatchUp, deadline: &deadline, missedRuns: 5, lastMissed: minutes(5, 5)[0]},
+		{name: "run all past the deadline", policy: batchv1.RunAllCatchUp, deadline: &deadline, missedRuns: 5, lastMissed: minutes(5, 5)[0]},
+		{name: "skip past the deadline", policy: batchv1.SkipCatchUp, deadline: &deadline, missedRuns: 5, lastMissed: minutes(5, 5)[0]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.now.IsZero() {
+				tt.now = now
+			}
+			r, _ := newTestReconciler(t, newCatchUpCronJob(tt.policy, tt.deadline), tt.now)
+			cronJob, runs := reconcile(t, r, newCatchUpCronJob(tt.policy, tt.deadline))
+			expectRuns(t, runs, tt.runs)
+			expectMissedRuns(t, cronJob, tt.missedRuns, tt.lastMissed)
+
+			// Nothing more is started or missed until the next run is due
+			cronJob, runs = reconcile(t, r, cronJob)
+			expectRuns(t, runs, tt.runs)
+			expectMissedRuns(t, cronJob, tt.missedRuns, tt.lastMissed)
+		})
+	}
+}
+
+func TestReconcileRunAllSequentially(t *testing.T) {
+	r, clock := newTestReconciler(t, newCatchUpCronJob(batchv1.RunAllCatchUp, nil), catchUpStart.Add(3*time.Minute+30*time.Second))
+
+	// The backlog is run one after another
+	cronJob, runs := reconcile(t, r, newCatchUpCronJob(batchv1.RunAllCatchUp, nil))
+	expectRuns(t, runs, minutes(1, 1))
+	cronJob, runs = reconcile(t, r, cronJob)
+	expectRuns(t, runs, minutes(1, 1))
+
+	for i := 2; i <= 3; i++ {
+		finishRuns(t, r, cronJob)
+		cronJob, runs = reconcile(t, r, cronJob)
+		expectRuns(t, runs, minutes(1, i))
+	}
+
+	// The backlog is drained, the run on schedule is started right away
+	finishRuns(t, r, cronJob)
+	clock.now = catchUpStart.Add(4*time.Minute + 10*time.Second)
+	cronJob, runs = reconcile(t, r, cronJob)
+	expectRuns(t, runs, minutes(1, 4))
+	expectMissedRuns(t, cronJob, 0, time.Time{})
+}
+
+func TestReconcileRunAllBounded(t *testing.T) {
+	now := catchUpStart.Add(time.Duration(maxCatchUpRuns+20)*time.Minute + 30*time.Second)
+	r, _ := newTestReconciler(t, newCatchUpCronJob(batchv1.RunAllCatchUp, nil), now)
+
+	// Only the latest runs are kept in the backlog
+	cronJob, runs := reconcile(t, r, newCatchUpCronJob(batchv1.RunAllCatchUp, nil))
+	expectRuns(t, runs, minutes(21, 21))
+	expectMissedRuns(t, cronJob, 20, minutes(20, 20)[0])
+}
+
+func TestReconcileTooManyDueRuns(t *testing.T) {
+	now := catchUpStart.Add(time.Duration(maxScheduledTimes+50)*time.Minute + 30*time.Second)
+	r, _ := newTestReconciler(t, newCatchUpCronJob(batchv1.RunLatestCatchUp, nil), now)
+
+	// The due runs are evaluated over several reconciles, only the latest one is started
+	cronJob, runs := reconcile(t, r, newCatchUpCronJob(batchv1.RunLatestCatchUp, nil))
+	expectRuns(t, runs, nil)
+	expectMissedRuns(t, cronJob, maxScheduledTimes-maxCatchUpRuns, minutes(maxScheduledTimes-maxCatchUpRuns, maxScheduledTimes-maxCatchUpRuns)[0])
+
+	cronJob, runs = reconcile(t, r, cronJob)
+	expectRuns(t, runs, minutes(maxScheduledTimes+50, maxScheduledTimes+50))
+	expectMissedRuns(t, cronJob, maxScheduledTimes+49, minutes(maxScheduledTimes+49, maxScheduledTimes+49)[0])
+}
+
+// newRunPod returns a pod of the run scheduled at the minute after
+// catchUpStart in the phase.
+func newRunPod(minute int, phase corev1.PodPhase) *corev1.Pod {
+	scheduledTime := minutes(minute, minute)[0]
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        fmt.Sprintf("hello-%d", scheduledTime.Unix()),
+			Namespace:   "default",
+			Annotations: map[string]string{scheduledTimeAnnotation: scheduledTime.Format(time.RFC3339)},
+		},
+		Status: corev1.PodStatus{Phase: phase},
+	}
+}
+
+// setRunPhase sets the phase of the pod of the run scheduled at the minute
+// after catchUpStart, the pod is started at its scheduled time.
+func setRunPhase(t *testing.T, r *CronJobReconciler, minute int, phase corev1.PodPhase) {
+	t.Helper()
+	ctx := context.Background()
+	var pods corev1.PodList
+	if err := r.List(ctx, &pods, client.InNamespace("default")); err != nil {
+		t.Fatal(err)
+	}
+
+	scheduledTime := minutes(minute, minute)[0]
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if runScheduledTime, err := getScheduleTimeForRun(pod); err == nil && runScheduledTime.Equal(scheduledTime) {
+			pod.Status.Phase = phase
+			pod.Status.StartTime = &metav1.Time{Time: scheduledTime}
+			if err = r.Status().Update(ctx, pod); err != nil {
+				t.Fatal(err)
+			}
+			return
+		}
+	}
+	t.Fatalf("run scheduled at %s not found", scheduledTime)
+}
+
+func expectFinishedRuns(t *testing.T, cronJob *batchv1.CronJob, succeeded, failed int64) {
+	t.Helper()
+	if cronJob.Status.Succeeded != succeeded || cronJob.Status.Failed != failed {
+		t.Fatalf("expected %d succeeded and %d failed runs, got %d and %d",
+			succeeded, failed, cronJob.Status.Succeeded, cronJob.Status.Failed)
+	}
+}
+
+func TestReconcileCountFinishedRuns(t *testing.T) {
+	historyLimit := int32(1)
+	newCronJob := func() *batchv1.CronJob {
+		cronJob := newCatchUpCronJob(batchv1.RunAllCatchUp, nil)
+		cronJob.Spec.SuccessfulJobsHistoryLimit = &historyLimit
+		cronJob.Spec.FailedJobsHistoryLimit = &historyLimit
+		return cronJob
+	}
+	r, clock := newTestReconciler(t, newCronJob(), catchUpStart.Add(time.Minute+30*time.Second))
+
+	// The run is counted once it finishes, only once
+	cronJob, _ := reconcile(t, r, newCronJob())
+	cronJob, _ = reconcile(t, r, cronJob)
+	expectFinishedRuns(t, cronJob, 0, 0)
+	setRunPhase(t, r, 1, corev1.PodSucceeded)
+	cronJob, _ = reconcile(t, r, cronJob)
+	expectFinishedRuns(t, cronJob, 1, 0)
+	cronJob, _ = reconcile(t, r, cronJob)
+	expectFinishedRuns(t, cronJob, 1, 0)
+
+	// The run finished before it's seen active is counted as well
+	clock.now = catchUpStart.Add(2*time.Minute + 10*time.Second)
+	cronJob, _ = reconcile(t, r, cronJob)
+	setRunPhase(t, r, 2, corev1.PodFailed)
+	cronJob, _ = reconcile(t, r, cronJob)
+	expectFinishedRuns(t, cronJob, 1, 1)
+
+	// The runs deleted by the history limit are still counted
+	clock.now = catchUpStart.Add(3*time.Minute + 10*time.Second)
+	cronJob, _ = reconcile(t, r, cronJob)
+	setRunPhase(t, r, 3, corev1.PodSucceeded)
+	cronJob, runs := reconcile(t, r, cronJob)
+	expectRuns(t, runs, []time.Time{minutes(2, 2)[0], minutes(3, 3)[0]})
+	expectFinishedRuns(t, cronJob, 2, 1)
+	if cronJob.Status.RetainedSucceeded != 1 || cronJob.Status.RetainedFailed != 1 {
+		t.Fatalf("expected 1 retained succeeded and failed run, got %d and %d",
+			cronJob.Status.RetainedSucceeded, cronJob.Status.RetainedFailed)
+	}
+	cronJob, _ = reconcile(t, r, cronJob)
+	expectFinishedRuns(t, cronJob, 2, 1)
+}
+
+func TestRunPhaseChanged(t *testing.T) {
+	newJob := func(conditionType kbatch.JobConditionType, status corev1.ConditionStatus) *kbatch.Job {
+		job := &kbatch.Job{}
+		if len(conditionType) != 0 {
+			job.Status.Conditions = []kbatch.JobCondition{{Type: conditionType, Status: status}}
+		}
+		return job
+	}
+	runningPod := newRunPod(1, corev1.PodRunning)
+	runningPodWithStatus := runningPod.DeepCopy()
+	runningPodWithStatus.Status.ContainerStatuses = []corev1.ContainerStatus{{Name: "hello", Ready: true}}
+
+	tests := []struct {
+		name     string
+		old, new client.Object
+		expected bool
+	}{
+		{name: "pod started", old: newRunPod(1, corev1.PodPending), new: runningPod, expected: true},
+		{name: "pod succeeded", old: runningPod, new: newRunPod(1, corev1.PodSucceeded), expected: true},
+		{name: "pod failed", old: runningPod, new: newRunPod(1, corev1.PodFailed), expected: true},
+		{name: "pod status updated", old: runningPod, new: runningPodWithStatus},
+		{name: "job completed", old: newJob("", ""), new: newJob(kbatch.JobComplete, corev1.ConditionTrue), expected: true},
+		{name: "job failed", old: newJob("", ""), new: newJob(kbatch.JobFailed, corev1.ConditionTrue), expected: true},
+		{name: "job suspended", old: newJob("", ""), new: newJob(kbatch.JobSuspended, corev1.ConditionTrue)},
+		{name: "job not completed", old: newJob("", ""), new: newJob(kbatch.JobComplete, corev1.ConditionFalse)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := runPhaseChanged.Update(event.UpdateEvent{ObjectOld: tt.old, ObjectNew: tt.new}); got != tt.expected {
+				t.Fatalf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+
+	// The runs created and deleted always pass
+	if !runPhaseChanged.Create(event.CreateEvent{Object: runningPod}) || !runPhaseChanged.Delete(event.DeleteEvent{Object: runningPod}) {
+		t.Fatalf("expected the created and deleted runs to pass")
+	}
+}
+
+func TestSetStatusConditions(t *testing.T) {
+	suspend := true
+
+	tests := []struct {
+		name        string
+		schedule    string
+		suspend     *bool
+		timeZoneErr error
+		active      []client.Object
+		succeeded   []client.Object
+		failed      []client.Object
+		// expected are the reasons of the conditions by their types, the
+		// conditions not listed must not be set.
+		expected map[string]string
+	}{
+		{
+			name: "scheduling",
+			expected: map[string]string{
+				batchv1.CronJobReady:  batchv1.ReasonScheduling,
+				batchv1.CronJobActive: batchv1.ReasonNoActiveRuns,
+			},
+		},
+		{
+			name:        "invalid time zone",
+			schedule:    "every minute",
+			timeZoneErr: errors.New("unknown time zone Mars/Olympus"),
+			expected: map[string]string{
+				batchv1.CronJobReady:  batchv1.ReasonInvalidTimeZone,
+				batchv1.CronJobActive: batchv1.ReasonNoActiveRuns,
+			},
+		},
+		{
+			name:     "invalid schedule",
+			schedule: "every minute",
+			suspend:  &suspend,
+			expected: map[string]string{
+				batchv1.CronJobReady:  batchv1.ReasonInvalidSchedule,
+				batchv1.CronJobActive: batchv1.ReasonNoActiveRuns,
+			},
+		},
+		{
+			name:    "suspended",
+			suspend: &suspend,
+			active:  []client.Object{newRunPod(3, corev1.PodRunning)},
+			expected: map[string]string{
+				batchv1.CronJobReady:  batchv1.ReasonSuspended,
+				batchv1.CronJobActive: batchv1.ReasonRunsActive,
+			},
+		},
+		{
+			name:      "last run succeeded",
+			succeeded: []client.Object{newRunPod(2, corev1.PodSucceeded)},
+			failed:    []client.Object{newRunPod(1, corev1.PodFailed)},
+			expected: map[string]string{
+				batchv1.CronJobReady:  batchv1.ReasonScheduling,
+				batchv1.CronJobActive: batchv1.ReasonNoActiveRuns,
+				batchv1.CronJobFailed: batchv1.ReasonRunSucceeded,
+			},
+		},
+		{
+			name:      "last run failed",
+			active:    []client.Object{newRunPod(3, corev1.PodRunning)},
+			succeeded: []client.Object{newRunPod(1, corev1.PodSucceeded)},
+			failed:    []client.Object{newRunPod(2, corev1.PodFailed)},
+			expected: map[string]string{
+				batchv1.CronJobReady:  batchv1.ReasonScheduling,
+				batchv1.CronJobActive: batchv1.ReasonRunsActive,
+				batchv1.CronJobFailed: batchv1.ReasonRunFailed,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cronJob := newCatchUpCronJob(batchv1.RunAllCatchUp, nil)
+			cronJob.Spec.Suspend = tt.suspend
+			if len(tt.schedule) != 0 {
+				cronJob.Spec.Schedule = tt.schedule
+			}
+
+			setStatusConditions(cronJob, tt.active, tt.succeeded, tt.failed, tt.timeZoneErr)
+			if len(cronJob.Status.Conditions) != len(tt.expected) {
+				t.Fatalf("expected conditions %v, got %v", tt.expected, cronJob.Status.Conditions)
+			}
+			for conditionType, reason := range tt.expected {
+				condition := meta.FindStatusCondition(cronJob.Status.Conditions, conditionType)
+				if condition == nil || condition.Reason != reason {
+					t.Fatalf("expected condition %s with reason %s, got %v", conditionType, reason, condition)
+				}
+			}
+		})
+	}
+}
+
+// newBatchJobCronJob returns the CronJob whose runs are Jobs.
+func newBatchJobCronJob() *batchv1.CronJob {
+	cronJob := newCatchUpCronJob(batchv1.RunAllCatchUp, nil)
+	cronJob.Spec.BatchJobTemplate = &kbatch.JobTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels:      map[string]string{"app": "hello"},
+			Annotations: map[string]string{"example.org/team": "greeters"},
+		},
+		Spec: kbatch.JobSpec{Template: *cronJob.Spec.JobTemplate},
+	}
+	cronJob.Spec.JobTemplate = nil
+	return cronJob
+}
+
+// listJobs returns the Jobs of the CronJob by their names.
+func listJobs(t *testing.T, r *CronJobReconciler) map[string]*kbatch.Job {
+	t.Helper()
+	var jobs kbatch.JobList
+	if err := r.List(context.Background(), &jobs, client.InNamespace("default")); err != nil {
+		t.Fatal(err)
+	}
+
+	byName := make(map[string]*kbatch.Job)
+	for i := range jobs.Items {
+		byName[jobs.Items[i].Name] = &jobs.Items[i]
+	}
+	return byName
+}
+
+func TestReconcileBatchJobTemplate(t *testing.T) {
+	r, _ := newTestReconciler(t, newBatchJobCronJob(), catchUpStart.Add(time.Minute+30*time.Second))
+
+	cronJob, runs := reconcile(t, r, newBatchJobCronJob())
+	expectRuns(t, runs, nil)
+	jobs := listJobs(t, r)
+	scheduledTime := minutes(1, 1)[0]
+	job, found := jobs[fmt.Sprintf("hello-%d", scheduledTime.Unix())]
+	if len(jobs) != 1 || !found {
+		t.Fatalf("expected the job of the run at %s, got %v", scheduledTime, jobs)
+	}
+
+	if job.Labels["app"] != "hello" || job.Annotations["example.org/team"] != "greeters" {
+		t.Fatalf("expected the labels and annotations of the template, got %v and %v", job.Labels, job.Annotations)
+	}
+	if runScheduledTime, err := getScheduleTimeForRun(job); err != nil || !runScheduledTime.Equal(scheduledTime) {
+		t.Fatalf("expected the job scheduled at %s, got %s (%v)", scheduledTime, runScheduledTime, err)
+	}
+	if ownerRef := metav1.GetControllerOf(job); ownerRef == nil || ownerRef.Kind != "CronJob" || ownerRef.UID != cronJob.UID {
+		t.Fatalf("expected the job controlled by the CronJob, got %v", ownerRef)
+	}
+	if !reflect.DeepEqual(job.Spec.Template, cronJob.Spec.BatchJobTemplate.Spec.Template) {
+		t.Fatalf("expected the pod template of the job template, got %v", job.Spec.Template)
+	}
+
+	// The job is active until it's complete, the run isn't created twice
+	cronJob, _ = reconcile(t, r, cronJob)
+	if len(cronJob.Status.Active) != 1 || cronJob.Status.Active[0].Kind != "Job" || cronJob.Status.Active[0].Name != job.Name {
+		t.Fatalf("expected the job active, got %v", cronJob.Status.Active)
+	}
+	if jobs = listJobs(t, r); len(jobs) != 1 {
+		t.Fatalf("expected only one job, got %v", jobs)
+	}
+}
+
+func TestGetRunResult(t *testing.T) {
+	newJob := func(conditions ...kbatch.JobCondition) *kbatch.Job {
+		return &kbatch.Job{Status: kbatch.JobStatus{Conditions: conditions}}
+	}
+
+	tests := []struct {
+		name      string
+		run       client.Object
+		finished  bool
+		succeeded bool
+	}{
+		{name: "pod pending", run: newRunPod(1, corev1.PodPending)},
+		{name: "pod running", run: newRunPod(1, corev1.PodRunning)},
+		{name: "pod succeeded", run: newRunPod(1, corev1.PodSucceeded), finished: true, succeeded: true},
+		{name: "pod failed", run: newRunPod(1, corev1.PodFailed), finished: true},
+		{name: "job active", run: newJob()},
+		{name: "job complete", run: newJob(kbatch.JobCondition{Type: kbatch.JobComplete, Status: corev1.ConditionTrue}), finished: true, succeeded: true},
+		{name: "job failed", run: newJob(kbatch.JobCondition{Type: kbatch.JobFailed, Status: corev1.ConditionTrue}), finished: true},
+		{name: "job not complete yet", run: newJob(kbatch.JobCondition{Type: kbatch.JobComplete, Status: corev1.ConditionFalse})},
+		{name: "job suspended", run: newJob(kbatch.JobCondition{Type: kbatch.JobSuspended, Status: corev1.ConditionTrue})},
+		{
+			name: "job failed after suspended",
+			run: newJob(
+				kbatch.JobCondition{Type: kbatch.JobSuspended, Status: corev1.ConditionFalse},
+				kbatch.JobCondition{Type: kbatch.JobFailed, Status: corev1.ConditionTrue},
+			),
+			finished: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if finished, succeeded := getRunResult(tt.run); finished != tt.finished || succeeded != tt.succeeded {
+				t.Fatalf("expected finished %v and succeeded %v, got %v and %v", tt.finished, tt.succeeded, finished, succeeded)
+			}
+		})
+	}
+}
+
+func TestReconcileJobHistoryLimits(t *testing.T) {
+	historyLimit := int32(1)
+	newCronJob := func() *batchv1.CronJob {
+		cronJob := newBatchJobCronJob()
+		cronJob.Spec.SuccessfulJobsHistoryLimit = &historyLimit
+		cronJob.Spec.FailedJobsHistoryLimit = &historyLimit
+		return cronJob
+	}
+	r, _ := newTestReconciler(t, newCronJob(), catchUpStart.Add(5*time.Minute+30*time.Second))
+
+	// The runs are complete, failed or active in turn
+	ctx := context.Background()
+	var names []string
+	for i, scheduledTime := range minutes(1, 5) {
+		job, err := r.newJobForCronJob(newCronJob(), scheduledTime)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = r.Create(ctx, job); err != nil {
+			t.Fatal(err)
+		}
+
+		job.Status.StartTime = &metav1.Time{Time: scheduledTime}
+		switch i % 3 {
+		case 0:
+			job.Status.Conditions = []kbatch.JobCondition{{Type: kbatch.JobComplete, Status: corev1.ConditionTrue}}
+			job.Status.CompletionTime = &metav1.Time{Time: scheduledTime.Add(10 * time.Second)}
+		case 1:
+			job.Status.Conditions = []kbatch.JobCondition{{Type: kbatch.JobFailed, Status: corev1.ConditionTrue}}
+		}
+		if err = r.Status().Update(ctx, job); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, job.Name)
+	}
+
+	// Only the latest complete and failed jobs are kept, the active ones are never deleted
+	cronJob, _ := reconcile(t, r, newCronJob())
+	jobs := listJobs(t, r)
+	for i, name := range names {
+		_, found := jobs[name]
+		if expected := i != 0 && i != 1; found != expected {
+			t.Fatalf("expected job %d to be kept %v, got %v", i, expected, found)
+		}
+	}
+	if cronJob.Status.RetainedSucceeded != 1 || cronJob.Status.RetainedFailed != 1 || len(cronJob.Status.Active) != 1 {
+		t.Fatalf("expected 1 retained succeeded, failed and active run, got %d, %d and %v",
+			cronJob.Status.RetainedSucceeded, cronJob.Status.RetainedFailed, cronJob.Status.Active)
+	}
+	if expected := minutes(4, 4)[0].Add(10 * time.Second); cronJob.Status.LastSuccessfulTime == nil || !cronJob.Status.LastSuccessfulTime.Time.Equal(expected) {
+		t.Fatalf("expected the last successful time %s, got %v", expected, cronJob.Status.LastSuccessfulTime)
+	}
+}
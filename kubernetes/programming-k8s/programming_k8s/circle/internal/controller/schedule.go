@@ -0,0 +1,141 @@
+/*
+Copyright 2023 Jayson Wang.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron"
+
+	batchv1 "github.com/wjiec/programming_k8s/circle/api/v1"
+)
+
+const (
+	// everyHour is the bits of the hours of a schedule which fires every hour.
+	everyHour = 1<<24 - 1
+
+	// maxSkippedRuns bounds the runs skipped while looking for the next run,
+	// the runs at the wall times repeated by a transition are skipped.
+	maxSkippedRuns = 1000
+)
+
+// zonedSchedule is a cron schedule evaluated in a time zone.
+//
+// The cron library evaluates the schedule by walking the wall clock, so a run
+// whose wall time is skipped by a daylight saving transition is dropped, and a
+// run whose wall time is repeated fires twice. A schedule which fires at the
+// specific hours is evaluated on the wall clock of the time zone instead, and
+// then resolved to the instant explicitly:
+//   - a run whose wall time is skipped fires once at the instant of the transition;
+//   - a run whose wall time is repeated fires only once at its first occurrence.
+//
+// A schedule which fires every hour is evaluated in the elapsed time, it's not
+// shifted by the transitions.
+type zonedSchedule struct {
+	schedule cron.Schedule
+	location *time.Location
+	// wallClock is true if the schedule fires at specific hours of the day.
+	wallClock bool
+}
+
+// newSchedule parses the standard cron spec, which is evaluated in the location.
+func newSchedule(spec string, location *time.Location) (*zonedSchedule, error) {
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &zonedSchedule{schedule: schedule, location: location}
+	if specSchedule, ok := schedule.(*cron.SpecSchedule); ok {
+		s.wallClock = specSchedule.Hour&everyHour != everyHour
+	}
+	return s, nil
+}
+
+// Next returns the next time of the schedule strictly after the time, or the
+// zero time if there is no such time.
+func (s *zonedSchedule) Next(t time.Time) time.Time {
+	if !s.wallClock {
+		return s.schedule.Next(t.In(s.location))
+	}
+
+	// The wall clock is evaluated in UTC, where there are no transitions
+	local := t.In(s.location)
+	wall := time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), local.Minute(), local.Second(), 0, time.UTC)
+	for i := 0; i < maxSkippedRuns; i++ {
+		if wall = s.schedule.Next(wall); wall.IsZero() {
+			break
+		}
+
+		// The wall time repeated by a transition has fired at its first occurrence
+		if next := resolveWallClock(wall, s.location); next.After(t) {
+			return next
+		}
+	}
+	return time.Time{}
+}
+
+// resolveWallClock returns the first instant the wall clock of the location
+// reads the wall time, which is given in UTC. If the wall time is skipped by
+// a transition, it returns the instant of the transition.
+func resolveWallClock(wall time.Time, location *time.Location) time.Time {
+	// A day before and after the wall time are both out of a transition near
+	// the wall time, so their offsets are the offsets around the transition.
+	before, after := wall.Add(-24*time.Hour), wall.Add(24*time.Hour)
+
+	var first time.Time
+	for _, probe := range []time.Time{before, after} {
+		_, offset := probe.In(location).Zone()
+		candidate := wall.Add(-time.Duration(offset) * time.Second).In(location)
+		if isSameWallClock(candidate, wall) && (first.IsZero() || candidate.Before(first)) {
+			first = candidate
+		}
+	}
+	if !first.IsZero() {
+		return first
+	}
+
+	// The wall time is in a gap, it's after the transition by the offset before
+	_, offset := before.In(location).Zone()
+	transition, _ := wall.Add(-time.Duration(offset) * time.Second).In(location).ZoneBounds()
+	return transition
+}
+
+// isSameWallClock returns true if the wall clocks of the times are the same.
+func isSameWallClock(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd &&
+		a.Hour() == b.Hour() && a.Minute() == b.Minute() && a.Second() == b.Second()
+}
+
+// getTimeZone returns the location the schedule of the CronJob is evaluated
+// in, which defaults to the location of the clock.
+func (r *CronJobReconciler) getTimeZone(cronJob *batchv1.CronJob) (*time.Location, error) {
+	if cronJob.Spec.TimeZone == nil {
+		return r.Now().Location(), nil
+	}
+
+	// The host dependent "Local" is not an IANA time zone name
+	name := *cronJob.Spec.TimeZone
+	if len(name) == 0 || strings.EqualFold(name, "Local") {
+		return nil, fmt.Errorf("unknown time zone %q", name)
+	}
+	return time.LoadLocation(name)
+}
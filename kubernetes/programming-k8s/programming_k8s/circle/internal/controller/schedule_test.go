@@ -0,0 +1,275 @@
+/*
+Copyright 2023 Jayson Wang.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+	"time"
+
+	_ "time/tzdata"
+)
+
+// The transitions of the time zones in the tests:
+//   - America/New_York skips 02:00-03:00 on 2023-03-12 (at 07:00 UTC), and
+//     repeats 01:00-02:00 on 2023-11-05 (from 05:00 to 07:00 UTC).
+//   - Australia/Lord_Howe shifts by 30 minutes, it skips 02:00-02:30 on
+//     2023-10-01 (at 15:30 UTC the day before), and repeats 01:30-02:00 on
+//     2023-04-02 (from 14:30 to 15:30 UTC the day before).
+const (
+	newYork  = "America/New_York"
+	lordHowe = "Australia/Lord_Howe"
+)
+
+func loadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return location
+}
+
+// utc returns the instant in UTC, the layout is "2006-01-02 15:04".
+func utc(t *testing.T, value string) time.Time {
+	t.Helper()
+	instant, err := time.ParseInLocation("2006-01-02 15:04", value, time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return instant
+}
+
+func TestZonedScheduleNext(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     string
+		location string
+		// from is the time the next runs are looked for, and expected are
+		// the next runs in order, all of them in UTC.
+		from     string
+		expected []string
+	}{
+		{
+			name:     "every minute across the gap",
+			spec:     "* * * * *",
+			location: newYork,
+			from:     "2023-03-12 06:58",
+			expected: []string{"2023-03-12 06:59", "2023-03-12 07:00", "2023-03-12 07:01"},
+		},
+		{
+			name:     "every minute across the overlap",
+			spec:     "* * * * *",
+			location: newYork,
+			from:     "2023-11-05 05:58",
+			expected: []string{"2023-11-05 05:59", "2023-11-05 06:00", "2023-11-05 06:01"},
+		},
+		{
+			name:     "hourly across the gap",
+			spec:     "0 * * * *",
+			location: newYork,
+			from:     "2023-03-12 05:30",
+			expected: []string{"2023-03-12 06:00", "2023-03-12 07:00", "2023-03-12 08:00"},
+		},
+		{
+			name:     "hourly across the overlap",
+			spec:     "0 * * * *",
+			location: newYork,
+			from:     "2023-11-05 04:30",
+			expected: []string{"2023-11-05 05:00", "2023-11-05 06:00", "2023-11-05 07:00"},
+		},
+		{
+			// 02:30 is skipped, the run fires at 03:00 EDT instead
+			name:     "daily in the gap",
+			spec:     "30 2 * * *",
+			location: newYork,
+			from:     "2023-03-11 07:30",
+			expected: []string{"2023-03-12 07:00", "2023-03-13 06:30", "2023-03-14 06:30"},
+		},
+		{
+			name:     "daily before the gap",
+			spec:     "30 1 * * *",
+			location: newYork,
+			from:     "2023-03-11 06:30",
+			expected: []string{"2023-03-12 06:30", "2023-03-13 05:30"},
+		},
+		{
+			name:     "daily after the gap",
+			spec:     "0 3 * * *",
+			location: newYork,
+			from:     "2023-03-11 08:00",
+			expected: []string{"2023-03-12 07:00", "2023-03-13 07:00"},
+		},
+		{
+			// 01:30 is repeated, the run only fires at 01:30 EDT
+			name:     "daily in the overlap",
+			spec:     "30 1 * * *",
+			location: newYork,
+			from:     "2023-11-04 05:30",
+			expected: []string{"2023-11-05 05:30", "2023-11-06 06:30"},
+		},
+		{
+			name:     "daily in the overlap from its repetition",
+			spec:     "30 1 * * *",
+			location: newYork,
+			from:     "2023-11-05 06:00",
+			expected: []string{"2023-11-06 06:30"},
+		},
+		{
+			name:     "daily after the overlap",
+			spec:     "0 2 * * *",
+			location: newYork,
+			from:     "2023-11-04 06:00",
+			expected: []string{"2023-11-05 07:00", "2023-11-06 07:00"},
+		},
+		{
+			name:     "every minute across the half hour gap",
+			spec:     "* * * * *",
+			location: lordHowe,
+			from:     "2023-09-30 15:28",
+			expected: []string{"2023-09-30 15:29", "2023-09-30 15:30", "2023-09-30 15:31"},
+		},
+		{
+			// 02:15 is skipped, the run fires at 02:30 LHDT instead
+			name:     "daily in the half hour gap",
+			spec:     "15 2 * * *",
+			location: lordHowe,
+			from:     "2023-09-29 15:45",
+			expected: []string{"2023-09-30 15:30", "2023-10-01 15:15"},
+		},
+		{
+			// 01:45 is repeated, the run only fires at 01:45 LHDT
+			name:     "daily in the half hour overlap",
+			spec:     "45 1 * * *",
+			location: lordHowe,
+			from:     "2023-03-31 14:45",
+			expected: []string{"2023-04-01 14:45", "2023-04-02 15:15"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := newSchedule(tt.spec, loadLocation(t, tt.location))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			next := utc(t, tt.from)
+			for i, expected := range tt.expected {
+				next = schedule.Next(next)
+				if !next.Equal(utc(t, expected)) {
+					t.Fatalf("expected run %d at %s, got %s", i, expected, next.UTC().Format("2006-01-02 15:04"))
+				}
+			}
+		})
+	}
+}
+
+func TestResolveWallClock(t *testing.T) {
+	tests := []struct {
+		name     string
+		location string
+		// wall is the wall time given in UTC, expected is the instant in UTC.
+		wall     string
+		expected string
+	}{
+		{name: "standard time", location: newYork, wall: "2023-01-15 09:00", expected: "2023-01-15 14:00"},
+		{name: "daylight saving time", location: newYork, wall: "2023-07-15 09:00", expected: "2023-07-15 13:00"},
+		{name: "start of the gap", location: newYork, wall: "2023-03-12 02:00", expected: "2023-03-12 07:00"},
+		{name: "in the gap", location: newYork, wall: "2023-03-12 02:59", expected: "2023-03-12 07:00"},
+		{name: "end of the gap", location: newYork, wall: "2023-03-12 03:00", expected: "2023-03-12 07:00"},
+		{name: "start of the overlap", location: newYork, wall: "2023-11-05 01:00", expected: "2023-11-05 05:00"},
+		{name: "in the overlap", location: newYork, wall: "2023-11-05 01:59", expected: "2023-11-05 05:59"},
+		{name: "end of the overlap", location: newYork, wall: "2023-11-05 02:00", expected: "2023-11-05 07:00"},
+		{name: "half hour offset", location: lordHowe, wall: "2023-06-15 09:00", expected: "2023-06-14 22:30"},
+		{name: "in the half hour gap", location: lordHowe, wall: "2023-10-01 02:15", expected: "2023-09-30 15:30"},
+		{name: "in the half hour overlap", location: lordHowe, wall: "2023-04-02 01:45", expected: "2023-04-01 14:45"},
+		{name: "utc", location: "UTC", wall: "2023-03-12 02:30", expected: "2023-03-12 02:30"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			location := loadLocation(t, tt.location)
+			actual := resolveWallClock(utc(t, tt.wall), location)
+			if !actual.Equal(utc(t, tt.expected)) {
+				t.Fatalf("expected %s, got %s", tt.expected, actual.UTC().Format("2006-01-02 15:04"))
+			}
+			if actual.Location() != location {
+				t.Fatalf("expected the instant in %s, got %s", location, actual.Location())
+			}
+		})
+	}
+}
+
+func TestIsSameWallClock(t *testing.T) {
+	newYorkLocation := loadLocation(t, newYork)
+	lordHoweLocation := loadLocation(t, lordHowe)
+
+	tests := []struct {
+		name     string
+		a, b     time.Time
+		expected bool
+	}{
+		{
+			name:     "same wall clock in other zones",
+			a:        time.Date(2023, time.November, 5, 1, 30, 0, 0, newYorkLocation),
+			b:        time.Date(2023, time.November, 5, 1, 30, 0, 0, time.UTC),
+			expected: true,
+		},
+		{
+			name:     "same wall clock at a half hour offset",
+			a:        time.Date(2023, time.April, 2, 1, 45, 0, 0, lordHoweLocation),
+			b:        time.Date(2023, time.April, 2, 1, 45, 0, 0, time.UTC),
+			expected: true,
+		},
+		{
+			// The repeated 01:30 EST reads the same as 01:30 EDT
+			name:     "both occurrences in the overlap",
+			a:        time.Date(2023, time.November, 5, 5, 30, 0, 0, time.UTC).In(newYorkLocation),
+			b:        time.Date(2023, time.November, 5, 6, 30, 0, 0, time.UTC).In(newYorkLocation),
+			expected: true,
+		},
+		{
+			name: "same instant",
+			a:    time.Date(2023, time.November, 5, 5, 30, 0, 0, time.UTC).In(newYorkLocation),
+			b:    time.Date(2023, time.November, 5, 5, 30, 0, 0, time.UTC),
+		},
+		{
+			// 02:30 doesn't exist in New York, it's normalized to 03:30 EDT
+			name: "wall time in the gap",
+			a:    time.Date(2023, time.March, 12, 2, 30, 0, 0, newYorkLocation),
+			b:    time.Date(2023, time.March, 12, 2, 30, 0, 0, time.UTC),
+		},
+		{
+			name: "other day",
+			a:    time.Date(2023, time.November, 5, 1, 30, 0, 0, time.UTC),
+			b:    time.Date(2023, time.November, 6, 1, 30, 0, 0, time.UTC),
+		},
+		{
+			name: "other second",
+			a:    time.Date(2023, time.November, 5, 1, 30, 0, 0, time.UTC),
+			b:    time.Date(2023, time.November, 5, 1, 30, 1, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := isSameWallClock(tt.a, tt.b); actual != tt.expected {
+				t.Fatalf("expected %v, got %v", tt.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"flag"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+	// Embed the IANA time zone database, so that the time zone of greeters
+	// doesn't depend on the zoneinfo files installed on the host.
+	_ "time/tzdata"
+
+	apiextensionsclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/util/homedir"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	"sigs.k8s.io/controller-runtime/pkg/manager/signals"
+
+	"github.com/wjiec/programming_k8s/greeter/internal/greeter"
+	"github.com/wjiec/programming_k8s/greeter/internal/webhook"
+)
+
+var (
+	masterURL         string
+	kubeconfig        string
+	bindAddr          string
+	namespace         string
+	serviceName       string
+	secretName        string
+	webhookConfigName string
+	crdName           string
+	defaultTimeZone   string
+	maxMessageSize    int
+	clusterDomain     string
+)
+
+func init() {
+	var defaultKubeConfig string
+	if home := homedir.HomeDir(); home != "" {
+		defaultKubeConfig = filepath.Join(home, ".kube", "config")
+	}
+
+	flag.StringVar(&kubeconfig, "kubeconfig", defaultKubeConfig, "Path to a kubeconfig. Only required if out-of-cluster.")
+	flag.StringVar(&masterURL, "master", "", "The address of the Kubernetes API server. Overrides any value in kubeconfig. Only required if out-of-cluster.")
+	flag.StringVar(&bindAddr, "bind-address", ":9443", "The address the webhook server binds to.")
+	flag.StringVar(&namespace, "namespace", os.Getenv("POD_NAMESPACE"), "The namespace of the webhook Service and the Secret of its certificate. Defaults to the namespace of the pod (POD_NAMESPACE).")
+	flag.StringVar(&serviceName, "service-name", "greeter-webhook", "The name of the Service in front of the webhook server.")
+	flag.StringVar(&secretName, "tls-secret-name", "greeter-webhook-tls", "The name of the Secret holding the serving certificate, it's generated if missing.")
+	flag.StringVar(&webhookConfigName, "webhook-config-name", "greeter-validating-webhook", "The name of the ValidatingWebhookConfiguration whose CA bundle is managed by the server.")
+	flag.StringVar(&crdName, "crd-name", "greeters.example.org", "The name of the CustomResourceDefinition whose conversion webhook CA bundle is managed by the server. Empty disables the management.")
+	flag.StringVar(&defaultTimeZone, "default-timezone", "UTC", "The IANA time zone name used for greeters which don't specify one, it must match the greeter-controller.")
+	flag.IntVar(&maxMessageSize, "max-message-size", 0, "The maximum size in bytes of the message of greeters. Zero means unlimited.")
+	flag.StringVar(&clusterDomain, "cluster-domain", greeter.DefaultClusterDomain, "The DNS domain of the cluster, the host of an HTTP sink must be a Service optionally qualified by it. It must match the greeter-controller.")
+}
+
+func main() {
+	klog.InitFlags(nil)
+	flag.Parse()
+
+	// set up signals so we handle the shutdown signal gracefully
+	ctx := signals.SetupSignalHandler()
+	logger := klog.FromContext(ctx)
+
+	if len(namespace) == 0 {
+		logger.Error(nil, "The namespace of the webhook is required, set either --namespace or POD_NAMESPACE")
+		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+	}
+
+	cfg, err := clientcmd.BuildConfigFromFlags(masterURL, kubeconfig)
+	if err != nil {
+		logger.Error(err, "Error building kubeconfig")
+		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+	}
+
+	timeZone, err := greeter.LoadTimeZone(defaultTimeZone)
+	if err != nil {
+		logger.Error(err, "Error loading default time zone")
+		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+	}
+
+	kubeClientset, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		logger.Error(err, "Error building kubernetes clientset")
+		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+	}
+
+	crdClientset, err := apiextensionsclientset.NewForConfig(cfg)
+	if err != nil {
+		logger.Error(err, "Error building apiextensions clientset")
+		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+	}
+
+	bootstrap := &webhook.CertBootstrap{
+		Client:            kubeClientset,
+		Namespace:         namespace,
+		SecretName:        secretName,
+		ServiceName:       serviceName,
+		WebhookConfigName: webhookConfigName,
+		CRDClient:         crdClientset,
+		CRDName:           crdName,
+	}
+	if err = bootstrap.Bootstrap(ctx); err != nil {
+		logger.Error(err, "Error bootstrapping serving certificate")
+		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+	}
+	// The certificate may be regenerated by another replica
+	go bootstrap.Reload(ctx)
+
+	mux := http.NewServeMux()
+	mux.Handle(webhook.ValidatePath, &webhook.ValidatingHandler{
+		Validator: &greeter.Validator{
+			DefaultTimeZone: timeZone,
+			MaxMessageSize:  maxMessageSize,
+			ClusterDomain:   clusterDomain,
+		},
+	})
+	mux.Handle(webhook.ConvertPath, &webhook.ConversionHandler{})
+	mux.Handle("/healthz", http.StripPrefix("/healthz", &healthz.Handler{Checks: map[string]healthz.Checker{
+		"ping": healthz.Ping,
+	}}))
+
+	server := &http.Server{
+		Addr:              bindAddr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+		TLSConfig: &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: bootstrap.GetCertificate,
+		},
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			logger.Error(err, "Error shutting down webhook server")
+		}
+	}()
+
+	logger.Info("Starting webhook server", "addr", bindAddr)
+	if err := server.ListenAndServeTLS("", ""); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logger.Error(err, "Error running webhook server")
+		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+	// Embed the IANA time zone database, so that the --time-zone doesn't
+	// depend on the zoneinfo files installed on the host.
+	_ "time/tzdata"
+
+	"github.com/spf13/pflag"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/wjiec/programming_k8s/greeter/internal/greeter"
+	"github.com/wjiec/programming_k8s/greeter/pkg/apis/greeter/v1alpha1"
+)
+
+// createOptions are the flags of the create command.
+type createOptions struct {
+	message  string
+	in       time.Duration
+	at       string
+	cron     string
+	timeZone string
+	suspend  bool
+}
+
+func newCreateCommand() *command {
+	o := &createOptions{}
+	return &command{
+		name:  "create",
+		usage: "NAME --message MESSAGE (--in DURATION | --at TIME | --cron SCHEDULE) [options]",
+		short: "Create a Greeter firing once or recurring",
+		addFlags: func(flags *pflag.FlagSet) {
+			flags.StringVar(&o.message, "message", "", "The message of the greeting.")
+			flags.DurationVar(&o.in, "in", 0, "Fire once after the duration from now, e.g. 10m or 1h30m.")
+			flags.StringVar(&o.at, "at", "", fmt.Sprintf("Fire once at the time in %q format.", greeter.ScheduleLayout))
+			flags.StringVar(&o.cron, "cron", "", "Fire repeatedly on the schedule in Cron format, e.g. \"*/5 * * * *\".")
+			flags.StringVar(&o.timeZone, "time-zone", "", "The IANA time zone of the schedule. Defaults to UTC for --in, and the time zone of the controller otherwise.")
+			flags.BoolVar(&o.suspend, "suspend", false, "Create the Greeter suspended.")
+		},
+		run: o.run,
+	}
+}
+
+func (o *createOptions) run(ctx context.Context, env *environment, args []string) error {
+	name, err := requireName(args)
+	if err != nil {
+		return err
+	}
+	if len(o.message) == 0 {
+		return fmt.Errorf("--message is required")
+	}
+
+	instance := &v1alpha1.Greeter{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: env.namespace},
+		Spec:       v1alpha1.GreeterSpec{Message: o.message},
+	}
+	if len(o.timeZone) != 0 {
+		if _, err := greeter.LoadTimeZone(o.timeZone); err != nil {
+			return fmt.Errorf("invalid --time-zone: %w", err)
+		}
+		instance.Spec.TimeZone = &o.timeZone
+	}
+	if o.suspend {
+		instance.Spec.Suspend = &o.suspend
+	}
+
+	switch {
+	case o.in != 0 && len(o.at) == 0 && len(o.cron) == 0:
+		if instance.Spec.Schedule, err = o.resolveIn(time.Now()); err != nil {
+			return err
+		}
+		// The schedule is resolved in the explicit time zone, so that it
+		// doesn't depend on the default time zone of the controller.
+		if instance.Spec.TimeZone == nil {
+			utc := time.UTC.String()
+			instance.Spec.TimeZone = &utc
+		}
+	case o.in == 0 && len(o.at) != 0 && len(o.cron) == 0:
+		if _, err := time.Parse(greeter.ScheduleLayout, o.at); err != nil {
+			return fmt.Errorf("--at must be in %q format", greeter.ScheduleLayout)
+		}
+		instance.Spec.Schedule = o.at
+	case o.in == 0 && len(o.at) == 0 && len(o.cron) != 0:
+		instance.Spec.Cron = o.cron
+	default:
+		return fmt.Errorf("exactly one of --in, --at and --cron is required")
+	}
+
+	created, err := env.greeterClientset.GreeterV1alpha1().Greeters(env.namespace).Create(ctx, instance, metav1.CreateOptions{})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(env.out, "greeter.example.org/%s created\n", created.Name)
+	return nil
+}
+
+// resolveIn returns the schedule after the duration from now in the time zone
+// of the schedule, the schedule is rounded up to the next second so that the
+// Greeter is never fired early.
+func (o *createOptions) resolveIn(now time.Time) (string, error) {
+	if o.in < 0 {
+		return "", fmt.Errorf("--in must be positive, got %s", o.in)
+	}
+
+	location := time.UTC
+	if len(o.timeZone) != 0 {
+		var err error
+		if location, err = greeter.LoadTimeZone(o.timeZone); err != nil {
+			return "", err
+		}
+	}
+
+	scheduledTime := now.Add(o.in)
+	if truncated := scheduledTime.Truncate(time.Second); !truncated.Equal(scheduledTime) {
+		scheduledTime = truncated.Add(time.Second)
+	}
+	return scheduledTime.In(location).Format(greeter.ScheduleLayout), nil
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/wjiec/programming_k8s/greeter/internal/greeter"
+	"github.com/wjiec/programming_k8s/greeter/pkg/apis/greeter/v1alpha1"
+)
+
+func TestResolveIn(t *testing.T) {
+	now := time.Date(2023, time.October, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		in       time.Duration
+		timeZone string
+		now      time.Time
+		expected string
+		err      string
+	}{
+		{name: "minutes", in: 10 * time.Minute, expected: "2023-10-01 12:10:00"},
+		{name: "hours and minutes", in: time.Hour + 30*time.Minute, expected: "2023-10-01 13:30:00"},
+		{name: "next day", in: 12 * time.Hour, expected: "2023-10-02 00:00:00"},
+		{name: "rounded up", in: 10 * time.Minute, now: now.Add(100 * time.Millisecond), expected: "2023-10-01 12:10:01"},
+		{name: "in time zone", in: 10 * time.Minute, timeZone: "Asia/Shanghai", expected: "2023-10-01 20:10:00"},
+		{name: "negative", in: -time.Minute, err: "must be positive"},
+		{name: "unknown time zone", in: time.Minute, timeZone: "Mars/Olympus", err: "Mars/Olympus"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.now.IsZero() {
+				tt.now = now
+			}
+
+			o := &createOptions{in: tt.in, timeZone: tt.timeZone}
+			schedule, err := o.resolveIn(tt.now)
+			if len(tt.err) != 0 {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected error containing %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if schedule != tt.expected {
+				t.Fatalf("expected schedule %q, got %q", tt.expected, schedule)
+			}
+		})
+	}
+}
+
+func TestCreate(t *testing.T) {
+	tests := []struct {
+		name      string
+		arguments []string
+		// expected checks the spec of the created Greeter
+		expected func(t *testing.T, spec *v1alpha1.GreeterSpec)
+		err      string
+	}{
+		{
+			name:      "in",
+			arguments: []string{"hello", "--message", "hello world", "--in", "10m"},
+			expected: func(t *testing.T, spec *v1alpha1.GreeterSpec) {
+				if _, err := time.Parse(greeter.ScheduleLayout, spec.Schedule); err != nil || len(spec.Cron) != 0 {
+					t.Fatalf("expected the schedule, got %q and cron %q", spec.Schedule, spec.Cron)
+				}
+				// The schedule doesn't depend on the time zone of the controller
+				if spec.TimeZone == nil || *spec.TimeZone != "UTC" {
+					t.Fatalf("expected the UTC time zone, got %v", spec.TimeZone)
+				}
+			},
+		},
+		{
+			name:      "at in time zone",
+			arguments: []string{"hello", "--message", "hello world", "--at", "2999-01-01 08:00:00", "--time-zone", "Asia/Shanghai"},
+			expected: func(t *testing.T, spec *v1alpha1.GreeterSpec) {
+				if spec.Schedule != "2999-01-01 08:00:00" || spec.TimeZone == nil || *spec.TimeZone != "Asia/Shanghai" {
+					t.Fatalf("expected the schedule in Asia/Shanghai, got %q in %v", spec.Schedule, spec.TimeZone)
+				}
+			},
+		},
+		{
+			name:      "cron suspended",
+			arguments: []string{"hello", "--message", "hello world", "--cron", "*/5 * * * *", "--suspend"},
+			expected: func(t *testing.T, spec *v1alpha1.GreeterSpec) {
+				if spec.Cron != "*/5 * * * *" || len(spec.Schedule) != 0 || spec.TimeZone != nil {
+					t.Fatalf("expected the cron schedule, got %q, %q in %v", spec.Cron, spec.Schedule, spec.TimeZone)
+				}
+				if spec.Suspend == nil || !*spec.Suspend {
+					t.Fatalf("expected the greeter suspended")
+				}
+			},
+		},
+		{name: "no name", arguments: []string{"--message", "hello world", "--in", "10m"}, err: "exactly one greeter name"},
+		{name: "no message", arguments: []string{"hello", "--in", "10m"}, err: "--message is required"},
+		{name: "no schedule", arguments: []string{"hello", "--message", "hello world"}, err: "exactly one of --in, --at and --cron"},
+		{
+			name:      "in and cron",
+			arguments: []string{"hello", "--message", "hello world", "--in", "10m", "--cron", "*/5 * * * *"},
+			err:       "exactly one of --in, --at and --cron",
+		},
+		{name: "malformed at", arguments: []string{"hello", "--message", "hello world", "--at", "2999-01-01T08:00:00Z"}, err: "--at must be in"},
+		{name: "negative in", arguments: []string{"hello", "--message", "hello world", "--in", "-10m"}, err: "must be positive"},
+		{
+			name:      "unknown time zone",
+			arguments: []string{"hello", "--message", "hello world", "--cron", "*/5 * * * *", "--time-zone", "Mars/Olympus"},
+			err:       "invalid --time-zone",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, greeterClientset, out := newTestEnvironment()
+
+			err := runCommand(t, newCreateCommand(), env, tt.arguments...)
+			greeters, listErr := greeterClientset.GreeterV1alpha1().Greeters("default").List(context.Background(), metav1.ListOptions{})
+			if listErr != nil {
+				t.Fatal(listErr)
+			}
+
+			if len(tt.err) != 0 {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected error containing %q, got %v", tt.err, err)
+				}
+				if len(greeters.Items) != 0 {
+					t.Fatalf("expected no greeter created, got %d", len(greeters.Items))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if len(greeters.Items) != 1 || greeters.Items[0].Name != "hello" || greeters.Items[0].Spec.Message != "hello world" {
+				t.Fatalf("expected the greeter hello created, got %+v", greeters.Items)
+			}
+			tt.expected(t, &greeters.Items[0].Spec)
+			if expected := "greeter.example.org/hello created\n"; out.String() != expected {
+				t.Fatalf("expected output %q, got %q", expected, out.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+
+	"github.com/wjiec/programming_k8s/greeter/pkg/apis/greeter/v1alpha1"
+)
+
+func newDescribeCommand() *command {
+	return &command{
+		name:  "describe",
+		usage: "NAME [options]",
+		short: "Show the details of a Greeter with its runner pod and events",
+		run:   runDescribe,
+	}
+}
+
+func runDescribe(ctx context.Context, env *environment, args []string) error {
+	name, err := requireName(args)
+	if err != nil {
+		return err
+	}
+
+	greeter, err := env.greeterClientset.GreeterV1alpha1().Greeters(env.namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	// The runner pod may be gone, e.g. cleaned up after its time to live
+	var pod *corev1.Pod
+	if podName := greeter.Status.PodName; len(podName) != 0 && isPodDelivery(greeter) {
+		pod, err = env.kubeClientset.CoreV1().Pods(greeter.Namespace).Get(ctx, podName, metav1.GetOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	events, err := env.kubeClientset.CoreV1().Events(greeter.Namespace).List(ctx, metav1.ListOptions{
+		FieldSelector: fields.Set{
+			"involvedObject.kind": "Greeter",
+			"involvedObject.name": greeter.Name,
+			"involvedObject.uid":  string(greeter.UID),
+		}.String(),
+	})
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(env.out, 0, 8, 2, ' ', 0)
+	describeGreeter(w, greeter, pod, events.Items, time.Now())
+	return w.Flush()
+}
+
+// describeGreeter writes the details of the Greeter in the layout of kubectl describe.
+func describeGreeter(w io.Writer, greeter *v1alpha1.Greeter, pod *corev1.Pod, events []corev1.Event, now time.Time) {
+	fmt.Fprintf(w, "Name:\t%s\n", greeter.Name)
+	fmt.Fprintf(w, "Namespace:\t%s\n", greeter.Namespace)
+	if len(greeter.Spec.Cron) != 0 {
+		fmt.Fprintf(w, "Cron:\t%s\n", greeter.Spec.Cron)
+	} else {
+		fmt.Fprintf(w, "Schedule:\t%s\n", greeter.Spec.Schedule)
+	}
+	fmt.Fprintf(w, "Time Zone:\t%s\n", stringOrDefault(greeter.Spec.TimeZone, "<controller default>"))
+	fmt.Fprintf(w, "Message:\t%s\n", greeter.Spec.Message)
+	fmt.Fprintf(w, "Delivery:\t%s\n", getDelivery(greeter))
+	fmt.Fprintf(w, "Suspend:\t%t\n", greeter.Spec.Suspend != nil && *greeter.Spec.Suspend)
+
+	status := &greeter.Status
+	fmt.Fprintf(w, "Status:\n")
+	fmt.Fprintf(w, "  Phase:\t%s\n", getPhase(greeter))
+	fmt.Fprintf(w, "  Last Schedule Time:\t%s\n", formatTime(status.LastScheduleTime))
+	fmt.Fprintf(w, "  Next Schedule Time:\t%s\n", formatTime(status.NextScheduleTime))
+	fmt.Fprintf(w, "  Start Time:\t%s\n", formatTime(status.StartTime))
+	fmt.Fprintf(w, "  Completion Time:\t%s\n", formatTime(status.CompletionTime))
+	fmt.Fprintf(w, "  Attempts:\t%d\n", status.Attempts)
+	if status.NextRetryTime != nil {
+		fmt.Fprintf(w, "  Next Retry Time:\t%s\n", formatTime(status.NextRetryTime))
+	}
+	if status.ExitCode != nil {
+		fmt.Fprintf(w, "  Exit Code:\t%d\n", *status.ExitCode)
+	}
+	if len(status.TerminationMessage) != 0 {
+		fmt.Fprintf(w, "  Termination Message:\t%s\n", status.TerminationMessage)
+	}
+	if len(status.Output) != 0 {
+		fmt.Fprintf(w, "  Output:\n")
+		for _, line := range strings.Split(strings.TrimRight(status.Output, "\n"), "\n") {
+			fmt.Fprintf(w, "    %s\n", line)
+		}
+	}
+
+	if len(status.Conditions) == 0 {
+		fmt.Fprintf(w, "Conditions:\t<none>\n")
+	} else {
+		fmt.Fprintf(w, "Conditions:\n  Type\tStatus\tReason\tMessage\n  ----\t------\t------\t-------\n")
+		for _, condition := range status.Conditions {
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n", condition.Type, condition.Status, condition.Reason, condition.Message)
+		}
+	}
+
+	switch {
+	case len(status.PodName) == 0 || !isPodDelivery(greeter):
+		fmt.Fprintf(w, "Pod:\t<none>\n")
+	case pod == nil:
+		fmt.Fprintf(w, "Pod:\t%s (not found)\n", status.PodName)
+	default:
+		fmt.Fprintf(w, "Pod:\n")
+		fmt.Fprintf(w, "  Name:\t%s\n", pod.Name)
+		fmt.Fprintf(w, "  Phase:\t%s\n", pod.Status.Phase)
+		fmt.Fprintf(w, "  Node:\t%s\n", stringOrDefault(&pod.Spec.NodeName, "<none>"))
+		fmt.Fprintf(w, "  Age:\t%s\n", sinceTime(&pod.CreationTimestamp, now))
+	}
+
+	if len(events) == 0 {
+		fmt.Fprintf(w, "Events:\t<none>\n")
+		return
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return getEventTime(&events[i]).Before(getEventTime(&events[j]))
+	})
+	fmt.Fprintf(w, "Events:\n  Type\tReason\tAge\tFrom\tMessage\n  ----\t------\t----\t----\t-------\n")
+	for i := range events {
+		event := &events[i]
+		eventTime := metav1.NewTime(getEventTime(event))
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\n", event.Type, event.Reason, sinceTime(&eventTime, now),
+			event.Source.Component, strings.TrimSpace(event.Message))
+	}
+}
+
+// getDelivery returns the description of the sink the Greeter is delivered to.
+func getDelivery(greeter *v1alpha1.Greeter) string {
+	switch delivery := greeter.Spec.Delivery; {
+	case delivery.Event != nil:
+		return fmt.Sprintf("Event on %s %q", delivery.Event.Kind, delivery.Event.Name)
+	case delivery.ConfigMap != nil:
+		return fmt.Sprintf("ConfigMap %q key %q", delivery.ConfigMap.Name, delivery.ConfigMap.Key)
+	case delivery.HTTP != nil:
+		return fmt.Sprintf("HTTP %s", delivery.HTTP.URL)
+	default:
+		return "Pod"
+	}
+}
+
+// isPodDelivery returns true if the Greeter is delivered by a runner pod.
+func isPodDelivery(greeter *v1alpha1.Greeter) bool {
+	delivery := &greeter.Spec.Delivery
+	return delivery.Event == nil && delivery.ConfigMap == nil && delivery.HTTP == nil
+}
+
+// getEventTime returns the time of the last occurrence of the Event.
+func getEventTime(event *corev1.Event) time.Time {
+	switch {
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	default:
+		return event.CreationTimestamp.Time
+	}
+}
+
+// formatTime returns the time in RFC 3339 format, or "<none>" if not set.
+func formatTime(t *metav1.Time) string {
+	if t == nil || t.IsZero() {
+		return "<none>"
+	}
+	return t.Format(time.RFC3339)
+}
+
+// stringOrDefault returns the string, or the default value if it's not set.
+func stringOrDefault(s *string, defaultValue string) string {
+	if s == nil || len(*s) == 0 {
+		return defaultValue
+	}
+	return *s
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/pflag"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+
+	"github.com/wjiec/programming_k8s/greeter/pkg/apis/greeter/v1alpha1"
+)
+
+// listOptions are the flags of the list command.
+type listOptions struct {
+	allNamespaces bool
+	selector      string
+}
+
+func newListCommand() *command {
+	o := &listOptions{}
+	return &command{
+		name:  "list",
+		usage: "[options]",
+		short: "List Greeters with their phase and next fire time",
+		addFlags: func(flags *pflag.FlagSet) {
+			flags.BoolVarP(&o.allNamespaces, "all-namespaces", "A", false, "List the Greeters across all namespaces.")
+			flags.StringVarP(&o.selector, "selector", "l", "", "Selector (label query) to filter on.")
+		},
+		run: o.run,
+	}
+}
+
+func (o *listOptions) run(ctx context.Context, env *environment, args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("unexpected arguments %q", args)
+	}
+
+	namespace := env.namespace
+	if o.allNamespaces {
+		namespace = metav1.NamespaceAll
+	}
+
+	greeters, err := env.greeterClientset.GreeterV1alpha1().Greeters(namespace).List(ctx, metav1.ListOptions{LabelSelector: o.selector})
+	if err != nil {
+		return err
+	}
+	if len(greeters.Items) == 0 {
+		if o.allNamespaces {
+			fmt.Fprintf(env.out, "No greeters found.\n")
+		} else {
+			fmt.Fprintf(env.out, "No greeters found in %s namespace.\n", namespace)
+		}
+		return nil
+	}
+
+	w := tabwriter.NewWriter(env.out, 0, 8, 3, ' ', 0)
+	if o.allNamespaces {
+		fmt.Fprint(w, "NAMESPACE\t")
+	}
+	fmt.Fprintln(w, "NAME\tPHASE\tSCHEDULE\tNEXT FIRE\tLAST FIRE\tSUSPEND\tAGE")
+
+	now := time.Now()
+	for i := range greeters.Items {
+		greeter := &greeters.Items[i]
+		if o.allNamespaces {
+			fmt.Fprintf(w, "%s\t", greeter.Namespace)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%t\t%s\n",
+			greeter.Name,
+			getPhase(greeter),
+			getSchedule(greeter),
+			untilTime(greeter.Status.NextScheduleTime, now),
+			sinceTime(greeter.Status.LastScheduleTime, now),
+			greeter.Spec.Suspend != nil && *greeter.Spec.Suspend,
+			sinceTime(&greeter.CreationTimestamp, now),
+		)
+	}
+	return w.Flush()
+}
+
+// getPhase returns the phase of the Greeter, a Greeter never synced yet is
+// waiting for its schedule.
+func getPhase(greeter *v1alpha1.Greeter) string {
+	if len(greeter.Status.Phase) == 0 {
+		return v1alpha1.PhasePending
+	}
+	return greeter.Status.Phase
+}
+
+// getSchedule returns the Cron schedule of the recurring Greeter, or the
+// one-shot time otherwise.
+func getSchedule(greeter *v1alpha1.Greeter) string {
+	if len(greeter.Spec.Cron) != 0 {
+		return greeter.Spec.Cron
+	}
+	return greeter.Spec.Schedule
+}
+
+// untilTime returns the human-readable duration until the time, e.g. "in 5m".
+func untilTime(t *metav1.Time, now time.Time) string {
+	if t == nil || t.IsZero() {
+		return "<none>"
+	}
+	if !t.After(now) {
+		return "now"
+	}
+	return "in " + duration.HumanDuration(t.Sub(now))
+}
+
+// sinceTime returns the human-readable duration since the time, e.g. "5m".
+func sinceTime(t *metav1.Time, now time.Time) string {
+	if t == nil || t.IsZero() {
+		return "<none>"
+	}
+	return duration.HumanDuration(now.Sub(t.Time))
+}
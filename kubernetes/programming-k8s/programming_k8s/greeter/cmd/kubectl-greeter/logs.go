@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/spf13/pflag"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// logsOptions are the flags of the logs command.
+type logsOptions struct {
+	container string
+	follow    bool
+	tail      int64
+}
+
+func newLogsCommand() *command {
+	o := &logsOptions{}
+	return &command{
+		name:  "logs",
+		usage: "NAME [options]",
+		short: "Print the logs of the runner pod of the current (or last) run",
+		addFlags: func(flags *pflag.FlagSet) {
+			flags.StringVarP(&o.container, "container", "c", "", "Print the logs of this container. Defaults to the only container of the pod.")
+			flags.BoolVarP(&o.follow, "follow", "f", false, "Specify if the logs should be streamed.")
+			flags.Int64Var(&o.tail, "tail", -1, "Lines of recent log file to display. Defaults to -1, showing all log lines.")
+		},
+		run: o.run,
+	}
+}
+
+func (o *logsOptions) run(ctx context.Context, env *environment, args []string) error {
+	name, err := requireName(args)
+	if err != nil {
+		return err
+	}
+
+	greeter, err := env.greeterClientset.GreeterV1alpha1().Greeters(env.namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if !isPodDelivery(greeter) {
+		return fmt.Errorf("greeter %q is delivered by %s, it has no runner pod", name, getDelivery(greeter))
+	}
+	if len(greeter.Status.PodName) == 0 {
+		return fmt.Errorf("greeter %q has not been fired yet", name)
+	}
+
+	logOptions := &corev1.PodLogOptions{Container: o.container, Follow: o.follow}
+	if o.tail >= 0 {
+		logOptions.TailLines = &o.tail
+	}
+
+	stream, err := env.kubeClientset.CoreV1().Pods(greeter.Namespace).GetLogs(greeter.Status.PodName, logOptions).Stream(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = stream.Close() }()
+
+	_, err = io.Copy(env.out, stream)
+	return err
+}
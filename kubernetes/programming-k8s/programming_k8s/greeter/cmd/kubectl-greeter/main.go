@@ -0,0 +1,153 @@
+// The kubectl-greeter is a kubectl plugin to operate Greeters, it's invoked as
+// "kubectl greeter" once the binary is installed into the PATH:
+//
+//	go install ./cmd/kubectl-greeter
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/pflag"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+
+	clientset "github.com/wjiec/programming_k8s/greeter/pkg/generated/clientset/versioned"
+)
+
+// command is a subcommand of the plugin.
+type command struct {
+	// name is the name of the command on the command line.
+	name string
+	// usage is the synopsis of the arguments and flags of the command.
+	usage string
+	// short is the one-line description of the command.
+	short string
+
+	// addFlags registers the flags of the command, it may be nil.
+	addFlags func(flags *pflag.FlagSet)
+	// run runs the command with the positional arguments.
+	run func(ctx context.Context, env *environment, args []string) error
+}
+
+// environment holds the clients and the settings shared by all the commands.
+type environment struct {
+	kubeClientset    kubernetes.Interface
+	greeterClientset clientset.Interface
+
+	// namespace is the namespace from the flags or the current context.
+	namespace string
+
+	// out is where the output of the commands is written to.
+	out io.Writer
+}
+
+var commands = []*command{
+	newCreateCommand(),
+	newListCommand(),
+	newDescribeCommand(),
+	newTriggerCommand(),
+	newSuspendCommand(true),
+	newSuspendCommand(false),
+	newLogsCommand(),
+}
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] == "help" || os.Args[1] == "-h" || os.Args[1] == "--help" {
+		printUsage(os.Stdout)
+		return
+	}
+
+	var cmd *command
+	for _, c := range commands {
+		if c.name == os.Args[1] {
+			cmd = c
+		}
+	}
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "error: unknown command %q\n\n", os.Args[1])
+		printUsage(os.Stderr)
+		os.Exit(1)
+	}
+
+	if err := execute(cmd, os.Args[2:]); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+// execute parses the flags of the command, and runs the command with the
+// clients configured the same way as kubectl.
+func execute(cmd *command, arguments []string) error {
+	flags := pflag.NewFlagSet("kubectl greeter "+cmd.name, pflag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "%s\n\nUsage:\n  kubectl greeter %s %s\n\nFlags:\n%s",
+			cmd.short, cmd.name, cmd.usage, flags.FlagUsages())
+	}
+
+	// The kubeconfig, context and namespace flags are the same as kubectl
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	flags.StringVar(&loadingRules.ExplicitPath, clientcmd.RecommendedConfigPathFlag, "", "Path to the kubeconfig file to use for CLI requests.")
+	overrides := &clientcmd.ConfigOverrides{}
+	clientcmd.BindOverrideFlags(overrides, flags, clientcmd.RecommendedConfigOverrideFlags(""))
+	if cmd.addFlags != nil {
+		cmd.addFlags(flags)
+	}
+
+	if err := flags.Parse(arguments); err != nil {
+		if err == pflag.ErrHelp {
+			return nil
+		}
+		return err
+	}
+
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, overrides)
+	namespace, _, err := clientConfig.Namespace()
+	if err != nil {
+		return err
+	}
+	cfg, err := clientConfig.ClientConfig()
+	if err != nil {
+		return err
+	}
+
+	kubeClientset, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return err
+	}
+	greeterClientset, err := clientset.NewForConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return cmd.run(ctx, &environment{
+		kubeClientset:    kubeClientset,
+		greeterClientset: greeterClientset,
+		namespace:        namespace,
+		out:              os.Stdout,
+	}, flags.Args())
+}
+
+// printUsage prints the usage of the plugin and all its commands.
+func printUsage(w io.Writer) {
+	fmt.Fprintf(w, "Operate the Greeters in the cluster.\n\nUsage:\n  kubectl greeter COMMAND [options]\n\nCommands:\n")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", c.name, c.short)
+	}
+	fmt.Fprintf(w, "\nUse \"kubectl greeter COMMAND --help\" for more information about a command.\n")
+}
+
+// requireName returns the only positional argument, which is the name of a Greeter.
+func requireName(args []string) (string, error) {
+	if len(args) != 1 {
+		return "", fmt.Errorf("exactly one greeter name is required, got %d arguments", len(args))
+	}
+	return args[0], nil
+}
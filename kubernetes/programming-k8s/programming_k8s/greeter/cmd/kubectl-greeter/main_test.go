@@ -0,0 +1,34 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	greeterfake "github.com/wjiec/programming_k8s/greeter/pkg/generated/clientset/versioned/fake"
+)
+
+// newTestEnvironment returns the environment of the commands in the default
+// namespace, with the fake clientset holding the objects.
+func newTestEnvironment(objects ...runtime.Object) (*environment, *greeterfake.Clientset, *bytes.Buffer) {
+	greeterClientset := greeterfake.NewSimpleClientset(objects...)
+	out := &bytes.Buffer{}
+	return &environment{greeterClientset: greeterClientset, namespace: "default", out: out}, greeterClientset, out
+}
+
+// runCommand parses the flags of the command from the arguments, and runs it
+// with the positional arguments.
+func runCommand(t *testing.T, cmd *command, env *environment, arguments ...string) error {
+	t.Helper()
+	flags := pflag.NewFlagSet(cmd.name, pflag.ContinueOnError)
+	if cmd.addFlags != nil {
+		cmd.addFlags(flags)
+	}
+	if err := flags.Parse(arguments); err != nil {
+		t.Fatal(err)
+	}
+	return cmd.run(context.Background(), env, flags.Args())
+}
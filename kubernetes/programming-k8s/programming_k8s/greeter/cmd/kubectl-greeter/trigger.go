@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/uuid"
+
+	"github.com/wjiec/programming_k8s/greeter/pkg/apis/greeter/v1alpha1"
+)
+
+func newTriggerCommand() *command {
+	return &command{
+		name:  "trigger",
+		usage: "NAME [options]",
+		short: "Fire a run of a Greeter immediately, even if it's suspended or finished",
+		run:   runTrigger,
+	}
+}
+
+// runTrigger sets a new token to the run-now annotation, the controller fires
+// exactly one run for each new token. The run of a running Greeter is fired
+// once the current run is finished.
+func runTrigger(ctx context.Context, env *environment, args []string) error {
+	name, err := requireName(args)
+	if err != nil {
+		return err
+	}
+
+	token := string(uuid.NewUUID())
+	patch := map[string]any{
+		"metadata": map[string]any{
+			"annotations": map[string]string{v1alpha1.AnnotationRunNow: token},
+		},
+	}
+	greeter, err := patchGreeter(ctx, env, name, patch)
+	if err != nil {
+		return err
+	}
+
+	if greeter.Status.Phase == v1alpha1.PhaseRunning {
+		fmt.Fprintf(env.out, "greeter.example.org/%s triggered (token %s), the run is fired once the current run is finished\n", name, token)
+		return nil
+	}
+	fmt.Fprintf(env.out, "greeter.example.org/%s triggered (token %s)\n", name, token)
+	return nil
+}
+
+func newSuspendCommand(suspend bool) *command {
+	c, done := &command{
+		name:  "suspend",
+		usage: "NAME [options]",
+		short: "Suspend the subsequent runs of a Greeter",
+	}, "suspended"
+	if !suspend {
+		c.name, done = "resume", "resumed"
+		c.short = "Resume the runs of a suspended Greeter"
+	}
+
+	c.run = func(ctx context.Context, env *environment, args []string) error {
+		name, err := requireName(args)
+		if err != nil {
+			return err
+		}
+
+		patch := map[string]any{"spec": map[string]any{"suspend": suspend}}
+		if _, err = patchGreeter(ctx, env, name, patch); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(env.out, "greeter.example.org/%s %s\n", name, done)
+		return nil
+	}
+	return c
+}
+
+// patchGreeter applies the merge patch to the Greeter, and returns the patched Greeter.
+func patchGreeter(ctx context.Context, env *environment, name string, patch map[string]any) (*v1alpha1.Greeter, error) {
+	data, err := json.Marshal(patch)
+	if err != nil {
+		return nil, err
+	}
+
+	return env.greeterClientset.GreeterV1alpha1().Greeters(env.namespace).Patch(ctx, name, types.MergePatchType, data, metav1.PatchOptions{})
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/wjiec/programming_k8s/greeter/pkg/apis/greeter/v1alpha1"
+)
+
+func newTestGreeter(phase string, suspend bool) *v1alpha1.Greeter {
+	return &v1alpha1.Greeter{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "hello",
+			Namespace:   "default",
+			Annotations: map[string]string{"example.org/team": "greeters"},
+		},
+		Spec:   v1alpha1.GreeterSpec{Cron: "*/5 * * * *", Message: "hello world", Suspend: &suspend},
+		Status: v1alpha1.GreeterStatus{Phase: phase},
+	}
+}
+
+func getTestGreeter(t *testing.T, env *environment) *v1alpha1.Greeter {
+	t.Helper()
+	greeter, err := env.greeterClientset.GreeterV1alpha1().Greeters("default").Get(context.Background(), "hello", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return greeter
+}
+
+func TestTrigger(t *testing.T) {
+	tests := []struct {
+		name      string
+		phase     string
+		suspend   bool
+		arguments []string
+		output    string
+		err       string
+	}{
+		{name: "pending", phase: v1alpha1.PhasePending, arguments: []string{"hello"}, output: "triggered (token "},
+		{name: "suspended", phase: v1alpha1.PhasePending, suspend: true, arguments: []string{"hello"}, output: "triggered (token "},
+		{name: "finished", phase: v1alpha1.PhaseSucceeded, arguments: []string{"hello"}, output: "triggered (token "},
+		{name: "running", phase: v1alpha1.PhaseRunning, arguments: []string{"hello"}, output: "once the current run is finished"},
+		{name: "not found", phase: v1alpha1.PhasePending, arguments: []string{"other"}, err: "not found"},
+		{name: "no name", phase: v1alpha1.PhasePending, err: "exactly one greeter name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, _, out := newTestEnvironment(newTestGreeter(tt.phase, tt.suspend))
+
+			err := runCommand(t, newTriggerCommand(), env, tt.arguments...)
+			greeter := getTestGreeter(t, env)
+			if len(tt.err) != 0 {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected error containing %q, got %v", tt.err, err)
+				}
+				if _, found := greeter.Annotations[v1alpha1.AnnotationRunNow]; found {
+					t.Fatalf("expected no run-now token, got %v", greeter.Annotations)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			// The token is new for each trigger, the other annotations are kept
+			token := greeter.Annotations[v1alpha1.AnnotationRunNow]
+			if len(token) == 0 || greeter.Annotations["example.org/team"] != "greeters" {
+				t.Fatalf("expected the run-now token added, got %v", greeter.Annotations)
+			}
+			if !strings.Contains(out.String(), tt.output) || !strings.Contains(out.String(), token) {
+				t.Fatalf("expected output containing %q and the token %q, got %q", tt.output, token, out.String())
+			}
+
+			out.Reset()
+			if err = runCommand(t, newTriggerCommand(), env, tt.arguments...); err != nil {
+				t.Fatal(err)
+			}
+			if next := getTestGreeter(t, env).Annotations[v1alpha1.AnnotationRunNow]; next == token {
+				t.Fatalf("expected a new token, got %q again", next)
+			}
+		})
+	}
+}
+
+func TestSuspend(t *testing.T) {
+	tests := []struct {
+		name      string
+		suspend   bool
+		suspended bool
+		arguments []string
+		expected  bool
+		output    string
+		err       string
+	}{
+		{name: "suspend", suspend: true, arguments: []string{"hello"}, expected: true, output: "greeter.example.org/hello suspended\n"},
+		{name: "suspend suspended", suspend: true, suspended: true, arguments: []string{"hello"}, expected: true, output: "greeter.example.org/hello suspended\n"},
+		{name: "resume", suspended: true, arguments: []string{"hello"}, output: "greeter.example.org/hello resumed\n"},
+		{name: "resume not found", suspended: true, arguments: []string{"other"}, expected: true, err: "not found"},
+		{name: "suspend no name", arguments: []string{}, suspend: true, err: "exactly one greeter name"},
+		{name: "resume more names", suspended: true, arguments: []string{"hello", "other"}, expected: true, err: "exactly one greeter name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, _, out := newTestEnvironment(newTestGreeter(v1alpha1.PhasePending, tt.suspended))
+
+			err := runCommand(t, newSuspendCommand(tt.suspend), env, tt.arguments...)
+			if len(tt.err) != 0 {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected error containing %q, got %v", tt.err, err)
+				}
+			} else if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			greeter := getTestGreeter(t, env)
+			if greeter.Spec.Suspend == nil || *greeter.Spec.Suspend != tt.expected {
+				t.Fatalf("expected suspend %v, got %v", tt.expected, greeter.Spec.Suspend)
+			}
+			if out.String() != tt.output {
+				t.Fatalf("expected output %q, got %q", tt.output, out.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package greeter
+
+import (
+	"encoding/json"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1apply "k8s.io/client-go/applyconfigurations/core/v1"
+
+	"github.com/wjiec/programming_k8s/greeter/pkg/apis/greeter/v1alpha1"
+	greeterapply "github.com/wjiec/programming_k8s/greeter/pkg/generated/applyconfiguration/greeter/v1alpha1"
+)
+
+// fieldManager is the manager of the fields applied by the controller. The
+// fields it doesn't apply anymore are removed, unless they are also owned by
+// other managers.
+const fieldManager = controllerAgentName
+
+// statusApplyOptions are the options of the apply of the Greeter status. The
+// fields are forced to be owned by the controller, as it's the only writer of
+// the status.
+var statusApplyOptions = metav1.ApplyOptions{FieldManager: fieldManager, Force: true}
+
+// podApplyOptions are the options of the apply of the runner pod. The apply is
+// not forced, an existing pod of the same name conflicts rather than being
+// taken over.
+var podApplyOptions = metav1.ApplyOptions{FieldManager: fieldManager}
+
+// newStatusApplyConfiguration returns the apply configuration of the status of
+// the Greeter. The status is applied as a whole, so the fields not set in the
+// status are removed from the object.
+func newStatusApplyConfiguration(greeter *v1alpha1.Greeter) *greeterapply.GreeterApplyConfiguration {
+	status := &greeter.Status
+	applyStatus := greeterapply.GreeterStatus().
+		WithPhase(status.Phase).
+		WithObservedGeneration(status.ObservedGeneration).
+		WithConditions(status.Conditions...)
+
+	if len(status.PodName) != 0 {
+		applyStatus.WithPodName(status.PodName)
+	}
+	if status.LastScheduleTime != nil {
+		applyStatus.WithLastScheduleTime(*status.LastScheduleTime)
+	}
+	if status.NextScheduleTime != nil {
+		applyStatus.WithNextScheduleTime(*status.NextScheduleTime)
+	}
+	if status.MissedRuns != 0 {
+		applyStatus.WithMissedRuns(status.MissedRuns)
+	}
+	if status.LastMissedTime != nil {
+		applyStatus.WithLastMissedTime(*status.LastMissedTime)
+	}
+	if status.StartTime != nil {
+		applyStatus.WithStartTime(*status.StartTime)
+	}
+	if status.CompletionTime != nil {
+		applyStatus.WithCompletionTime(*status.CompletionTime)
+	}
+	if status.ExitCode != nil {
+		applyStatus.WithExitCode(*status.ExitCode)
+	}
+	if len(status.TerminationMessage) != 0 {
+		applyStatus.WithTerminationMessage(status.TerminationMessage)
+	}
+	if status.Attempts != 0 {
+		applyStatus.WithAttempts(status.Attempts)
+	}
+	if status.NextRetryTime != nil {
+		applyStatus.WithNextRetryTime(*status.NextRetryTime)
+	}
+	if len(status.Output) != 0 {
+		applyStatus.WithOutput(status.Output)
+	}
+	if len(status.LastRunNowToken) != 0 {
+		applyStatus.WithLastRunNowToken(status.LastRunNowToken)
+	}
+
+	return greeterapply.Greeter(greeter.Name, greeter.Namespace).WithStatus(applyStatus)
+}
+
+// newPodApplyConfiguration returns the apply configuration of the runner pod,
+// it holds exactly the fields set in the pod. The pod is built from the pod
+// template of the Greeter, so it's converted through its JSON representation
+// rather than field by field.
+func newPodApplyConfiguration(pod *corev1.Pod) (*corev1apply.PodApplyConfiguration, error) {
+	data, err := json.Marshal(pod)
+	if err != nil {
+		return nil, err
+	}
+
+	applyPod := corev1apply.Pod(pod.Name, pod.Namespace)
+	if err = json.Unmarshal(data, applyPod); err != nil {
+		return nil, err
+	}
+
+	// The status is managed by the kubelet and can't be applied with the pod
+	applyPod.Status = nil
+	return applyPod, nil
+}
@@ -0,0 +1,237 @@
+package greeter
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/wjiec/programming_k8s/greeter/pkg/apis/greeter/v1alpha1"
+	greeterclientset "github.com/wjiec/programming_k8s/greeter/pkg/generated/clientset/versioned"
+)
+
+// getFields returns the sorted names of the fields of the JSON object at the
+// path of the applied object.
+func getFields(t *testing.T, applied any, path ...string) []string {
+	t.Helper()
+	data, err := json.Marshal(applied)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var object map[string]any
+	if err = json.Unmarshal(data, &object); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range path {
+		var ok bool
+		if object, ok = object[name].(map[string]any); !ok {
+			t.Fatalf("expected the object at %v, got %s", path, data)
+		}
+	}
+
+	var fields []string
+	for name := range object {
+		fields = append(fields, name)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+func expectFields(t *testing.T, fields []string, expected ...string) {
+	t.Helper()
+	sort.Strings(expected)
+	if !reflect.DeepEqual(fields, expected) {
+		t.Fatalf("expected fields %v, got %v", expected, fields)
+	}
+}
+
+func TestNewStatusApplyConfiguration(t *testing.T) {
+	now := metav1.NewTime(simulationStart)
+	exitCode := int32(1)
+
+	tests := []struct {
+		name     string
+		status   v1alpha1.GreeterStatus
+		expected []string
+	}{
+		{
+			// The fields cleared in the status are omitted, so they're removed
+			name:     "empty fields omitted",
+			status:   v1alpha1.GreeterStatus{Phase: v1alpha1.PhasePending, ObservedGeneration: 2},
+			expected: []string{"phase", "observedGeneration"},
+		},
+		{
+			name: "all fields",
+			status: v1alpha1.GreeterStatus{
+				Phase:              v1alpha1.PhaseFailed,
+				ObservedGeneration: 2,
+				Conditions: []metav1.Condition{{
+					Type:               v1alpha1.ConditionScheduled,
+					Status:             metav1.ConditionTrue,
+					LastTransitionTime: now,
+					Reason:             v1alpha1.ReasonScheduleReached,
+					Message:            "schedule reached",
+				}},
+				PodName:            "hello-28269840",
+				LastScheduleTime:   &now,
+				NextScheduleTime:   &now,
+				MissedRuns:         3,
+				LastMissedTime:     &now,
+				StartTime:          &now,
+				CompletionTime:     &now,
+				ExitCode:           &exitCode,
+				TerminationMessage: "exit 1",
+				Attempts:           2,
+				NextRetryTime:      &now,
+				Output:             "hello",
+				LastRunNowToken:    "token",
+			},
+			expected: []string{
+				"phase", "observedGeneration", "conditions", "podName", "lastScheduleTime", "nextScheduleTime",
+				"missedRuns", "lastMissedTime", "startTime", "completionTime", "exitCode", "terminationMessage",
+				"attempts", "nextRetryTime", "output", "lastRunNowToken",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			greeter := newRunningGreeter("hello", v1alpha1.GreeterSpec{Cron: "* * * * *"}, "")
+			greeter.Labels = map[string]string{"app": "hello"}
+			greeter.Status = tt.status
+
+			// Only the status is applied, the spec and metadata are left to their owners
+			applyStatus := newStatusApplyConfiguration(greeter)
+			expectFields(t, getFields(t, applyStatus), "kind", "apiVersion", "metadata", "status")
+			expectFields(t, getFields(t, applyStatus, "metadata"), "name", "namespace")
+			expectFields(t, getFields(t, applyStatus, "status"), tt.expected...)
+
+			data, err := json.Marshal(applyStatus)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var applied v1alpha1.Greeter
+			if err = json.Unmarshal(data, &applied); err != nil {
+				t.Fatal(err)
+			}
+			if !apiequality.Semantic.DeepEqual(applied.Status, tt.status) {
+				t.Fatalf("expected status %+v, got %+v", tt.status, applied.Status)
+			}
+		})
+	}
+}
+
+func TestNewPodApplyConfiguration(t *testing.T) {
+	greeter := newRunningGreeter("hello", v1alpha1.GreeterSpec{
+		Cron: "* * * * *",
+		Template: &corev1.PodTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"example.org/team": "greeters"}},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Name: "hello", Image: "busybox", Command: []string{"printenv"}}},
+			},
+		},
+	}, "hello-28269840")
+	pod := newPodForGreeter(greeter)
+	pod.OwnerReferences = []metav1.OwnerReference{*metav1.NewControllerRef(greeter, v1alpha1.SchemeGroupVersion.WithKind("Greeter"))}
+	// The status is never applied, even if it's set
+	pod.Status.Phase = corev1.PodRunning
+
+	applyPod, err := newPodApplyConfiguration(pod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if applyPod.Status != nil {
+		t.Fatalf("expected no status, got %+v", applyPod.Status)
+	}
+	expectFields(t, getFields(t, applyPod), "kind", "apiVersion", "metadata", "spec")
+	expectFields(t, getFields(t, applyPod, "metadata"), "name", "namespace", "labels", "annotations", "ownerReferences")
+	expectFields(t, getFields(t, applyPod, "spec"), "containers", "restartPolicy")
+
+	// The fields set in the pod are applied as they are
+	data, err := json.Marshal(applyPod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var applied corev1.Pod
+	if err = json.Unmarshal(data, &applied); err != nil {
+		t.Fatal(err)
+	}
+	if !apiequality.Semantic.DeepEqual(applied.ObjectMeta, pod.ObjectMeta) || !apiequality.Semantic.DeepEqual(applied.Spec, pod.Spec) {
+		t.Fatalf("expected the pod %+v, got %+v", pod, &applied)
+	}
+}
+
+// applyRequest is a request received by the API server of TestApplyOptions.
+type applyRequest struct {
+	method, path, contentType string
+	fieldManager, force       string
+}
+
+func TestApplyOptions(t *testing.T) {
+	var requests []applyRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		requests = append(requests, applyRequest{
+			method:       r.Method,
+			path:         r.URL.Path,
+			contentType:  r.Header.Get("Content-Type"),
+			fieldManager: r.URL.Query().Get("fieldManager"),
+			force:        r.URL.Query().Get("force"),
+		})
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"metadata":{"name":"hello"}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	config := &rest.Config{Host: server.URL}
+	kubeClientset := kubernetes.NewForConfigOrDie(config)
+	greeterClientset := greeterclientset.NewForConfigOrDie(config)
+
+	greeter := newRunningGreeter("hello", v1alpha1.GreeterSpec{Cron: "* * * * *"}, "hello-28269840")
+	applyPod, err := newPodApplyConfiguration(newPodForGreeter(greeter))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	if _, err = greeterClientset.GreeterV1alpha1().Greeters(simulationNamespace).ApplyStatus(ctx, newStatusApplyConfiguration(greeter), statusApplyOptions); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = kubeClientset.CoreV1().Pods(simulationNamespace).Apply(ctx, applyPod, podApplyOptions); err != nil {
+		t.Fatal(err)
+	}
+
+	// Both are applied by the field manager of the controller, only the status is forced
+	expected := []applyRequest{
+		{
+			method:       http.MethodPatch,
+			path:         "/apis/example.org/v1alpha1/namespaces/default/greeters/hello/status",
+			contentType:  string(types.ApplyPatchType),
+			fieldManager: controllerAgentName,
+			force:        "true",
+		},
+		{
+			method:       http.MethodPatch,
+			path:         "/api/v1/namespaces/default/pods/hello-28269840",
+			contentType:  string(types.ApplyPatchType),
+			fieldManager: controllerAgentName,
+			force:        "false",
+		},
+	}
+	if !reflect.DeepEqual(requests, expected) {
+		t.Fatalf("expected requests %+v, got %+v", expected, requests)
+	}
+}
@@ -3,13 +3,21 @@ package greeter
 import (
 	"context"
 	"fmt"
+	"hash/fnv"
 	"reflect"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/robfig/cron"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/apimachinery/pkg/util/wait"
 	coreinformers "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
@@ -20,6 +28,7 @@ import (
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
 
 	"github.com/wjiec/programming_k8s/greeter/pkg/apis/greeter/v1alpha1"
 	clientset "github.com/wjiec/programming_k8s/greeter/pkg/generated/clientset/versioned"
@@ -35,14 +44,110 @@ const (
 	// MessageResourceSynced is the message used for an Event fired when a Greeter
 	// is synced successfully
 	MessageResourceSynced = "Greeter synced successfully"
+	// MessageRunCompleted is the message used for an Event fired when a run of
+	// a recurring Greeter is finished
+	MessageRunCompleted = "Run %q finished with phase %s"
+	// MessageInvalidTimeZone is the message used for Events when a Greeter
+	// fails to sync due to an unknown time zone
+	MessageInvalidTimeZone = "Unknown time zone %q"
+	// MessageInvalidMessage is the message used for Events when a Greeter
+	// is not fired due to an invalid message
+	MessageInvalidMessage = "Invalid message: %s"
+	// MessageOutputNotCaptured is the message used for Events when the output
+	// of the runner pod can't be captured
+	MessageOutputNotCaptured = "Unable to capture the output of pod %q: %s"
+	// MessageRetryScheduled is the message used for an Event fired when a
+	// failed attempt of a run is going to be retried
+	MessageRetryScheduled = "Run %q failed, attempt %d is retried after %s"
+	// MessageDeadlineExceeded is the message used for an Event fired when a
+	// run is stopped due to its active deadline
+	MessageDeadlineExceeded = "Run %q was active longer than the deadline of %d seconds"
+	// MessagePodDeleted is the message used for an Event fired when the
+	// runner pod of a running Greeter is deleted
+	MessagePodDeleted = "Pod %q was deleted while running"
+	// MessageRunTriggered is the message used for an Event fired when a run
+	// is triggered by the run-now annotation
+	MessageRunTriggered = "Run triggered by token %q"
+	// MessageInvalidDelivery is the message used for Events when a Greeter
+	// is not fired due to an invalid delivery
+	MessageInvalidDelivery = "Invalid delivery: %s"
+	// MessageDeliveryFailed is the message used when the greeting of a run
+	// fails to be delivered
+	MessageDeliveryFailed = "Run %q failed to deliver: %s"
+	// MessageEventDelivered is the message used when the greeting of a run
+	// is delivered as an Event
+	MessageEventDelivered = "Run %q delivered as an Event on %s %q"
+	// MessageConfigMapDelivered is the message used when the greeting of a
+	// run is delivered into a ConfigMap
+	MessageConfigMapDelivered = "Run %q delivered into key %q of ConfigMap %q"
+	// MessageHTTPDelivered is the message used when the greeting of a run
+	// is delivered by an HTTP request
+	MessageHTTPDelivered = "Run %q delivered to %s with status %d"
+	// MessageRunsMissed is the message used for an Event fired when the due
+	// runs of a recurring Greeter are missed
+	MessageRunsMissed = "%d runs were missed, the last one was scheduled at %s"
 
 	// ErrResourceExists is used as part of the Event 'reason' when a Greeter fails
 	// to sync due to a Pod of the same name already existing.
 	ErrResourceExists = "ErrResourceExists"
+	// ErrInvalidTimeZone is used as part of the Event 'reason' when a Greeter
+	// fails to sync due to an unknown time zone.
+	ErrInvalidTimeZone = "ErrInvalidTimeZone"
+	// ErrInvalidMessage is used as part of the Event 'reason' when a Greeter
+	// is not fired due to an invalid message.
+	ErrInvalidMessage = "ErrInvalidMessage"
+	// ErrOutputNotCaptured is used as part of the Event 'reason' when the
+	// output of the runner pod can't be captured.
+	ErrOutputNotCaptured = "ErrOutputNotCaptured"
+	// ErrInvalidDelivery is used as part of the Event 'reason' when a Greeter
+	// is not fired due to an invalid delivery.
+	ErrInvalidDelivery = "ErrInvalidDelivery"
 	// SuccessSynced is used as part of the Event 'reason' when a Greeter is synced
 	SuccessSynced = "Synced"
+	// RunCompleted is used as part of the Event 'reason' when a run of a
+	// recurring Greeter is finished
+	RunCompleted = "RunCompleted"
+	// RetryScheduled is used as part of the Event 'reason' when a failed
+	// attempt of a run is going to be retried
+	RetryScheduled = "RetryScheduled"
+	// DeadlineExceeded is used as part of the Event 'reason' when a run is
+	// stopped due to its active deadline
+	DeadlineExceeded = "DeadlineExceeded"
+	// PodDeleted is used as part of the Event 'reason' when the runner pod
+	// of a running Greeter is deleted
+	PodDeleted = "PodDeleted"
+	// RunTriggered is used as part of the Event 'reason' when a run is
+	// triggered by the run-now annotation
+	RunTriggered = "RunTriggered"
+	// RunsMissed is used as part of the Event 'reason' when the due runs of
+	// a recurring Greeter are missed
+	RunsMissed = "RunsMissed"
 
 	controllerAgentName = "greeter-controller"
+
+	// ScheduleLayout is the layout of the one-shot schedule of Greeters, the
+	// schedule is evaluated in the time zone of the Greeter.
+	ScheduleLayout = "2006-01-02 15:04:05"
+
+	// nameLabel is the label set on runner pods with the name of their Greeter
+	nameLabel = "greeter.example.org/name"
+	// messageEnvName is the environment variable which holds the message of
+	// the Greeter in the containers of the runner pod
+	messageEnvName = "GREETER_MESSAGE"
+	// outputTailLines is the number of lines captured from the end of the
+	// logs of the runner pod
+	outputTailLines = 10
+	// defaultMaxOutputBytes is the default maximum size in bytes of the output
+	// captured from the runner pod
+	defaultMaxOutputBytes = 1024
+
+	// defaultRetryBackoff is the delay before the first retry of a failed run
+	defaultRetryBackoff = 10 * time.Second
+	// maxRetryBackoff is the maximum delay between retries of a failed run
+	maxRetryBackoff = 6 * time.Minute
+	// maxScheduledTimes bounds the scheduled times of a recurring greeter
+	// evaluated in a sync, the rest are evaluated in the following syncs
+	maxScheduledTimes = 1000
 )
 
 type Controller struct {
@@ -67,6 +172,132 @@ type Controller struct {
 	// recorder is an event recorder for recording Event resource to the
 	// kubernetes API.
 	recorder record.EventRecorder
+
+	// clock is the source of the current time of the controller, the
+	// schedules, deadlines and backoffs are all evaluated by it.
+	clock clock.WithTicker
+
+	// inflight holds the cancel functions of the syncs in progress by their
+	// keys, so that the work of a deleted Greeter can be dropped.
+	inflight sync.Map
+
+	// defaultTimeZone is the location in which the schedule is evaluated
+	// when the Greeter doesn't specify a time zone.
+	defaultTimeZone *time.Location
+
+	// maxMessageSize is the maximum size in bytes of the message of a
+	// Greeter, zero means unlimited.
+	maxMessageSize int
+
+	// clusterDomain is the DNS domain of the cluster which the host of an
+	// HTTP sink may be qualified by.
+	clusterDomain string
+
+	// maxOutputBytes is the maximum size in bytes of the output captured
+	// from the runner pod, zero means the output is not captured.
+	maxOutputBytes int64
+
+	// namespaces is the allow-list of namespaces the Greeters are watched
+	// in, an empty set means all namespaces.
+	namespaces sets.Set[string]
+
+	// sinks are the sinks the greetings are delivered to by their names.
+	sinks map[string]Sink
+
+	// maxRunning and maxRunningPerNamespace are the maximum numbers of the
+	// active runs cluster-wide and in each namespace, zero means unlimited.
+	maxRunning, maxRunningPerNamespace int
+	// launchQPS and launchBurst are the token bucket of the launches of the
+	// runs, zero QPS means unlimited.
+	launchQPS   float64
+	launchBurst int
+	// throttle limits the launches of the runs, nil if there is no limit.
+	throttle *launchThrottle
+}
+
+// Option configures the optional behaviours of the Controller.
+type Option func(*Controller)
+
+// WithDefaultTimeZone sets the time zone used by Greeters without an
+// explicit time zone. It defaults to UTC.
+func WithDefaultTimeZone(location *time.Location) Option {
+	return func(c *Controller) {
+		c.defaultTimeZone = location
+	}
+}
+
+// WithMaxMessageSize sets the maximum size in bytes of the message, Greeters
+// with a larger message are never fired. Zero means unlimited.
+func WithMaxMessageSize(size int) Option {
+	return func(c *Controller) {
+		c.maxMessageSize = size
+	}
+}
+
+// WithClusterDomain sets the DNS domain of the cluster, the host of an HTTP
+// sink must be a Service, optionally qualified by the domain. It defaults to
+// DefaultClusterDomain.
+func WithClusterDomain(domain string) Option {
+	return func(c *Controller) {
+		c.clusterDomain = domain
+	}
+}
+
+// WithMaxOutputBytes sets the maximum size in bytes of the output captured
+// from the runner pod into the status. Zero disables the capture.
+func WithMaxOutputBytes(size int64) Option {
+	return func(c *Controller) {
+		c.maxOutputBytes = size
+	}
+}
+
+// WithNamespaces restricts the Controller to the Greeters in the namespaces,
+// the Greeters in all namespaces are handled if not set.
+func WithNamespaces(namespaces ...string) Option {
+	return func(c *Controller) {
+		c.namespaces = sets.New(namespaces...)
+	}
+}
+
+// WithClock sets the clock of the Controller, it's also used by the delays of
+// the work queue. It defaults to the real clock.
+func WithClock(clock clock.WithTicker) Option {
+	return func(c *Controller) {
+		c.clock = clock
+	}
+}
+
+// WithEventRecorder sets the recorder of the Events of the Controller, the
+// Events are recorded to the kubernetes API if not set.
+func WithEventRecorder(recorder record.EventRecorder) Option {
+	return func(c *Controller) {
+		c.recorder = recorder
+	}
+}
+
+// WithMaxRunning limits the number of the runs active at the same time, both
+// cluster-wide and in each namespace. The due Greeters beyond the limits stay
+// pending with the Throttled condition. Zero means unlimited.
+func WithMaxRunning(maxRunning, maxRunningPerNamespace int) Option {
+	return func(c *Controller) {
+		c.maxRunning = maxRunning
+		c.maxRunningPerNamespace = maxRunningPerNamespace
+	}
+}
+
+// WithLaunchRate limits the rate of the launches of the runs by a token bucket
+// of the QPS and burst. Zero QPS means unlimited.
+func WithLaunchRate(qps float64, burst int) Option {
+	return func(c *Controller) {
+		c.launchQPS = qps
+		c.launchBurst = burst
+	}
+}
+
+// TweakPodListOptions restricts the list and watch of pods to the runner pods
+// of Greeters, it should be used by the informer factory of the pod informer.
+func TweakPodListOptions(options *metav1.ListOptions) {
+	options.LabelSelector = nameLabel
 }
 
 // Run will set up the event handlers for types we are interested in, as well
@@ -144,9 +375,25 @@ func (c *Controller) processNextWorkItem(ctx context.Context) bool {
 			return nil
 		}
 
+		// The sync is cancelled when the Greeter is deleted in the meantime.
+		syncCtx, cancel := context.WithCancel(ctx)
+		c.inflight.Store(key, cancel)
+		defer func() {
+			c.inflight.Delete(key)
+			cancel()
+		}()
+
 		// Run the syncHandler, passing it the namespace/name string of the
 		// Greeter resource to be synced.
-		if when, err := c.syncHandler(ctx, key); err != nil {
+		startTime := time.Now()
+		when, err := c.syncHandler(syncCtx, key)
+		result := syncResultSuccess
+		if err != nil {
+			result = syncResultError
+		}
+		syncDuration.WithLabelValues(result).Observe(time.Since(startTime).Seconds())
+
+		if err != nil {
 			// Put the item back on the workqueue to handle any transient errors.
 			c.workQueue.AddRateLimited(key)
 			return fmt.Errorf("error syncing '%s': %s, requeuing", key, err.Error())
@@ -184,8 +431,9 @@ func (c *Controller) syncHandler(ctx context.Context, key string) (time.Duration
 	greeter, err := c.greeterLister.Greeters(namespace).Get(name)
 	if err != nil {
 		// The Greeter resource may no longer exist, in which case we stop processing.
+		// This is expected for the delayed requeues of a deleted Greeter.
 		if errors.IsNotFound(err) {
-			utilruntime.HandleError(fmt.Errorf("greeter '%s' in work queue no longer exists", key))
+			klog.FromContext(ctx).V(4).Info("greeter in work queue no longer exists", "resourceName", key)
 			return 0, nil
 		}
 		return 0, err
@@ -210,63 +458,238 @@ func (c *Controller) syncGreeter(ctx context.Context, greeter *v1alpha1.Greeter)
 	// You can use DeepCopy() to make a deep copy of original object and modify this copy
 	// Or create a copy manually for better performance
 	instance := greeter.DeepCopy()
+	instance.Status.ObservedGeneration = instance.Generation
+	requeueAfter, syncErr, throttled := time.Duration(0), error(nil), false
+	// admitted is true if the launch of the run is admitted by the throttle,
+	// it's undone if the launch fails to be persisted.
+	admitted := false
 	if instance.Status.Phase == "" {
 		instance.Status.Phase = v1alpha1.PhasePending
 	}
+	// A new token of the trigger fires the finished greeter again, the token
+	// of a running greeter is consumed once the current run is finished.
+	finished := instance.Status.Phase == v1alpha1.PhaseSucceeded || instance.Status.Phase == v1alpha1.PhaseFailed
+	if finished && len(getRunNowToken(instance)) != 0 {
+		instance.Status.Phase = v1alpha1.PhasePending
+	}
 
 	// If no phase set, default to pending (the initial phase)
 	switch instance.Status.Phase {
 	case v1alpha1.PhasePending:
-		logger.Info("pending greeter", "schedule", instance.Spec.Schedule)
+		logger.Info("pending greeter", "schedule", instance.Spec.Schedule, "cron", instance.Spec.Cron)
+
+		if err := ValidateMessage(instance.Spec.Message, c.maxMessageSize); err != nil {
+			msg := fmt.Sprintf(MessageInvalidMessage, err)
+			c.recorder.Event(instance, corev1.EventTypeWarning, ErrInvalidMessage, msg)
+			setCondition(instance, v1alpha1.ConditionScheduled, metav1.ConditionFalse, v1alpha1.ReasonInvalidMessage, msg)
+			// Don't requeue until we get a change to the spec
+			break
+		}
 
-		// Check if it's already time to execute
-		when, err := timeUntilSchedule(instance.Spec.Schedule)
+		if errs := ValidateDelivery(&instance.Spec, instance.Namespace, c.clusterDomain, field.NewPath("spec")); len(errs) != 0 {
+			msg := fmt.Sprintf(MessageInvalidDelivery, errs.ToAggregate())
+			c.recorder.Event(instance, corev1.EventTypeWarning, ErrInvalidDelivery, msg)
+			setCondition(instance, v1alpha1.ConditionScheduled, metav1.ConditionFalse, v1alpha1.ReasonInvalidDelivery, msg)
+			// Don't requeue until we get a change to the spec
+			break
+		}
+
+		location, err := c.getTimeZone(instance)
 		if err != nil {
-			utilruntime.HandleError(fmt.Errorf("schedule parsing failed: %v", err))
-			// Error reading the schedule - requeue the request:
-			return 0, err
+			msg := fmt.Sprintf(MessageInvalidTimeZone, *instance.Spec.TimeZone)
+			c.recorder.Event(instance, corev1.EventTypeWarning, ErrInvalidTimeZone, msg)
+			setCondition(instance, v1alpha1.ConditionScheduled, metav1.ConditionFalse, v1alpha1.ReasonInvalidTimeZone, msg)
+			// Error loading the time zone - requeue the request:
+			syncErr = err
+			break
 		}
 
-		// Not yet time to execute, wait until the scheduled time
-		if when > 0 {
-			return when, nil
+		now := c.clock.Now().In(location)
+		scheduledTime, runNowToken := time.Time{}, ""
+		// The greeter is resumed if it's no longer suspended since the last sync
+		scheduled := meta.FindStatusCondition(instance.Status.Conditions, v1alpha1.ConditionScheduled)
+		resumed := scheduled != nil && scheduled.Reason == v1alpha1.ReasonSuspended
+		if token := getRunNowToken(instance); len(token) != 0 {
+			// A new token of the trigger fires a run immediately, even if the
+			// greeter is suspended. The token is consumed once the run is fired.
+			scheduledTime, runNowToken = now, token
+		} else if instance.Spec.Suspend != nil && *instance.Spec.Suspend {
+			// Don't requeue until the greeter is resumed or triggered
+			instance.Status.NextScheduleTime = nil
+			setCondition(instance, v1alpha1.ConditionScheduled, metav1.ConditionFalse, v1alpha1.ReasonSuspended,
+				"Greeter is suspended")
+			break
+		} else if len(instance.Spec.Cron) != 0 {
+			// Figure out the run we need to fire now (or anything we missed)
+			dueRun, missedRuns, nextRun, err := getNextScheduledTime(instance, now)
+			if err != nil {
+				utilruntime.HandleError(fmt.Errorf("cron parsing failed: %v", err))
+				setCondition(instance, v1alpha1.ConditionScheduled, metav1.ConditionFalse, v1alpha1.ReasonInvalidSchedule, err.Error())
+				// Error reading the schedule - requeue the request:
+				syncErr = err
+				break
+			}
+			instance.Status.NextScheduleTime = &metav1.Time{Time: nextRun}
+
+			// The runs which were due while the greeter was suspended are all
+			// missed, so the resumed greeter restarts from the current time
+			// instead of firing the latest of them.
+			moreDueRuns := dueRun.IsZero() && len(missedRuns) != 0
+			if resumed && !dueRun.IsZero() {
+				missedRuns, dueRun = append(missedRuns, dueRun), time.Time{}
+			}
+			c.recordMissedRuns(instance, missedRuns)
+
+			// There are more due runs than evaluated in a sync, the rest are
+			// evaluated once the missed runs are recorded.
+			if moreDueRuns {
+				requeueAfter = time.Second
+				break
+			}
+
+			// Not yet time to execute, wait until the next scheduled time
+			if dueRun.IsZero() {
+				requeueAfter = nextRun.Sub(now)
+				setCondition(instance, v1alpha1.ConditionScheduled, metav1.ConditionFalse, v1alpha1.ReasonWaitingForSchedule,
+					fmt.Sprintf("Next run at %s", nextRun.Format(time.RFC3339)))
+				break
+			}
+			scheduledTime = dueRun
+		} else {
+			// Check if it's already time to execute
+			scheduledTime, err = getScheduledTime(instance.Spec.Schedule, location)
+			if err != nil {
+				utilruntime.HandleError(fmt.Errorf("schedule parsing failed: %v", err))
+				setCondition(instance, v1alpha1.ConditionScheduled, metav1.ConditionFalse, v1alpha1.ReasonInvalidSchedule, err.Error())
+				// Error reading the schedule - requeue the request:
+				syncErr = err
+				break
+			}
+			instance.Status.NextScheduleTime = &metav1.Time{Time: scheduledTime}
+
+			// Not yet time to execute, wait until the scheduled time
+			if when := scheduledTime.Sub(now); when > 0 {
+				requeueAfter = when
+				setCondition(instance, v1alpha1.ConditionScheduled, metav1.ConditionFalse, v1alpha1.ReasonWaitingForSchedule,
+					fmt.Sprintf("Run at %s", scheduledTime.Format(time.RFC3339)))
+				break
+			}
+		}
+
+		// The due run stays pending until the controller has the capacity to
+		// launch it, so the missed run of a recurring greeter isn't lost.
+		if reason, msg, when := c.admitRun(key, greeter, now); len(reason) != 0 {
+			throttled, requeueAfter = true, when
+			setCondition(instance, v1alpha1.ConditionThrottled, metav1.ConditionTrue, reason, msg)
+			break
+		}
+		admitted = c.throttle != nil
+
+		if len(runNowToken) != 0 {
+			instance.Status.LastRunNowToken = runNowToken
+			c.recorder.Eventf(instance, corev1.EventTypeNormal, RunTriggered, MessageRunTriggered, runNowToken)
 		}
+		if len(instance.Spec.Cron) == 0 {
+			instance.Status.NextScheduleTime = nil
+		}
+		instance.Status.LastScheduleTime = &metav1.Time{Time: scheduledTime}
+		instance.Status.PodName = getPodNameForRun(instance, scheduledTime, runNowToken)
 
 		klog.Infof("it's time! ready to greet: %s", instance.Spec.Message)
 		instance.Status.Phase = v1alpha1.PhaseRunning
+
+		// Reset the observations of the previous run
+		instance.Status.StartTime = nil
+		instance.Status.CompletionTime = nil
+		instance.Status.ExitCode = nil
+		instance.Status.TerminationMessage = ""
+		instance.Status.Output = ""
+		instance.Status.Attempts = 0
+		instance.Status.NextRetryTime = nil
+		meta.RemoveStatusCondition(&instance.Status.Conditions, v1alpha1.ConditionCompleted)
+		meta.RemoveStatusCondition(&instance.Status.Conditions, v1alpha1.ConditionFailed)
+		meta.RemoveStatusCondition(&instance.Status.Conditions, v1alpha1.ConditionDeadlineExceeded)
+		meta.RemoveStatusCondition(&instance.Status.Conditions, v1alpha1.ConditionPodCreated)
+		setCondition(instance, v1alpha1.ConditionScheduled, metav1.ConditionTrue, v1alpha1.ReasonScheduleReached,
+			fmt.Sprintf("Fired run %q", instance.Status.PodName))
 	case v1alpha1.PhaseRunning:
 		logger.Info("running greeter", "message", instance.Spec.Message)
-		podForGreeter := newPodForGreeter(instance)
+		sink := c.sinks[getSinkName(instance)]
 
-		// Set Greeter instance as the owner and controller
-		owner := metav1.NewControllerRef(instance, v1alpha1.SchemeGroupVersion.WithKind("Greeter"))
-		podForGreeter.OwnerReferences = append(podForGreeter.OwnerReferences, *owner)
+		// Stop the run once it has been active for longer than the deadline,
+		// otherwise check again when the deadline is reached.
+		if deadline, ok := getActiveDeadline(instance); ok {
+			if when := deadline.Sub(c.clock.Now()); when > 0 {
+				requeueAfter = when
+			} else {
+				if err := sink.Reset(ctx, instance); err != nil {
+					return 0, err
+				}
 
-		// Try to see if the pod already exists and if not
-		// (which we expect) then create a one-shot pod as per spec
-		found, err := c.podLister.Pods(podForGreeter.Namespace).Get(podForGreeter.Name)
-		if err != nil && errors.IsNotFound(err) {
-			found, err = c.kubeClientset.CoreV1().Pods(podForGreeter.Namespace).Create(ctx, podForGreeter, metav1.CreateOptions{})
-			if err != nil {
-				return 0, err
+				msg := fmt.Sprintf(MessageDeadlineExceeded, instance.Status.PodName, *instance.Spec.ActiveDeadlineSeconds)
+				c.recorder.Event(instance, corev1.EventTypeWarning, DeadlineExceeded, msg)
+
+				instance.Status.NextRetryTime = nil
+				instance.Status.CompletionTime = &metav1.Time{Time: c.clock.Now()}
+				setCondition(instance, v1alpha1.ConditionDeadlineExceeded, metav1.ConditionTrue, v1alpha1.ReasonDeadlineExceeded, msg)
+				c.completeRun(instance, false, v1alpha1.ReasonDeadlineExceeded, msg)
+				break
+			}
+		}
+
+		// Wait for the backoff of the failed attempt before retrying
+		if retryTime := instance.Status.NextRetryTime; retryTime != nil {
+			if when := retryTime.Sub(c.clock.Now()); when > 0 {
+				requeueAfter = shorterDuration(requeueAfter, when)
+				break
 			}
-			logger.Info("pod launched", "podName", podForGreeter.Name)
-		} else if err != nil {
+		}
+
+		outcome, err := sink.Deliver(ctx, instance)
+		if err != nil {
 			// requeue with error
 			return 0, err
 		}
 
-		// If the Pod is not controlled by this Greeter resource, we should log
-		// a warning to the event recorder and return error msg.
-		if !metav1.IsControlledBy(found, instance) {
-			msg := fmt.Sprintf(MessageResourceExists, podForGreeter.Name)
-			c.recorder.Event(instance, corev1.EventTypeWarning, ErrResourceExists, msg)
-			return 0, fmt.Errorf("%s", msg)
+		instance.Status.NextRetryTime = nil
+		if instance.Status.StartTime == nil {
+			instance.Status.StartTime = &metav1.Time{Time: c.clock.Now()}
+		}
+		if !outcome.Finished {
+			// Wait for the sink to finish the delivery
+			break
 		}
+		logger.Info("delivery finished", "succeeded", outcome.Succeeded, "reason", outcome.Reason)
 
-		if found.Status.Phase == corev1.PodFailed || found.Status.Phase == corev1.PodSucceeded {
-			logger.Info("container terminated", "reason", found.Status.Phase)
-			instance.Status.Phase = string(found.Status.Phase)
+		instance.Status.CompletionTime = outcome.CompletionTime
+		if instance.Status.CompletionTime == nil {
+			instance.Status.CompletionTime = &metav1.Time{Time: c.clock.Now()}
+		}
+
+		// Retry the failed attempt until the attempts are exhausted
+		if !outcome.Succeeded && !outcome.Permanent && instance.Status.Attempts < getBackoffLimit(instance) {
+			if err := sink.Reset(ctx, instance); err != nil {
+				return 0, err
+			}
+
+			failedRun := instance.Status.PodName
+			instance.Status.Attempts++
+			instance.Status.PodName = getPodNameForRetry(failedRun, instance.Status.Attempts)
+
+			backoff := getRetryBackoff(instance, instance.Status.Attempts)
+			requeueAfter = shorterDuration(requeueAfter, backoff)
+			instance.Status.NextRetryTime = &metav1.Time{Time: c.clock.Now().Add(backoff)}
+
+			msg := fmt.Sprintf(MessageRetryScheduled, failedRun, instance.Status.Attempts, backoff)
+			c.recorder.Event(instance, corev1.EventTypeWarning, RetryScheduled, msg)
+			setCondition(instance, v1alpha1.ConditionFailed, metav1.ConditionFalse, v1alpha1.ReasonRetrying, msg)
+			break
+		}
+
+		if !outcome.Succeeded && !outcome.Permanent && instance.Status.Attempts > 0 {
+			c.completeRun(instance, false, v1alpha1.ReasonBackoffLimitExceeded, outcome.Message)
+		} else {
+			c.completeRun(instance, outcome.Succeeded, outcome.Reason, outcome.Message)
 		}
 	case v1alpha1.PhaseSucceeded:
 		logger.Info("greeter succeeded")
@@ -274,21 +697,147 @@ func (c *Controller) syncGreeter(ctx context.Context, greeter *v1alpha1.Greeter)
 		logger.Info("greeter failed")
 	}
 
-	// If the CustomResourceSubresources feature gate is not enabled,
-	// we must use Update instead of UpdateStatus to update the Status block of the Greeter resource.
-	if !reflect.DeepEqual(greeter, instance) {
-		// UpdateStatus will not allow changes to the Spec of the resource,
-		// which is ideal for ensuring nothing other than resource status has been updated.
-		_, err := c.greeterClientset.GreeterV1alpha1().Greeters(instance.Namespace).UpdateStatus(ctx, instance, metav1.UpdateOptions{})
+	// The greeter is no longer waiting for the capacity, e.g. it's launched
+	// or suspended in the meantime.
+	if !throttled {
+		meta.RemoveStatusCondition(&instance.Status.Conditions, v1alpha1.ConditionThrottled)
+		if c.throttle != nil {
+			c.throttle.forget(key)
+		}
+	}
+
+	if !reflect.DeepEqual(greeter.Status, instance.Status) {
+		// The status is applied through the status subresource, which will not
+		// allow changes to the Spec of the resource. The apply doesn't depend on
+		// the resourceVersion of the cached object, and the fields are forced to
+		// be owned by the controller, so concurrent writers never conflict with it.
+		applyStatus := newStatusApplyConfiguration(instance)
+		_, err := c.greeterClientset.GreeterV1alpha1().Greeters(instance.Namespace).ApplyStatus(ctx, applyStatus, statusApplyOptions)
 		if err != nil {
+			// The run is not launched, so it must not hold the capacity
+			if admitted {
+				c.throttle.undo(key, c.clock.Now())
+			}
 			return 0, err
 		}
 
 		c.recorder.Event(instance, corev1.EventTypeNormal, SuccessSynced, MessageResourceSynced)
 	}
 
-	// Don't requeue. We should be reconciled because either the pod or the CR changes.
-	return 0, nil
+	// Clean up the finished runs once their time to live is expired. This is
+	// based on the persisted status, so the result of a run is never lost.
+	if instance.Spec.TTLSecondsAfterFinished != nil {
+		when, deleted, err := c.cleanupFinished(ctx, instance)
+		if err != nil || deleted {
+			return 0, err
+		}
+		requeueAfter = shorterDuration(requeueAfter, when)
+	}
+
+	// Don't requeue unless we are waiting for the next run, deadline or time to
+	// live. Otherwise, we should be reconciled because either the pod or the CR changes.
+	return requeueAfter, syncErr
+}
+
+// recordMissedRuns counts the missed runs of the greeter in its status.
+func (c *Controller) recordMissedRuns(greeter *v1alpha1.Greeter, missedRuns []time.Time) {
+	if len(missedRuns) == 0 {
+		return
+	}
+
+	lastMissed := missedRuns[len(missedRuns)-1]
+	greeter.Status.MissedRuns += int64(len(missedRuns))
+	greeter.Status.LastMissedTime = &metav1.Time{Time: lastMissed}
+	c.recorder.Eventf(greeter, corev1.EventTypeWarning, RunsMissed, MessageRunsMissed, len(missedRuns), lastMissed.Format(time.RFC3339))
+}
+
+// completeRun records the result of the current run. A recurring greeter goes
+// back to wait for its next run, otherwise the greeter is finished.
+func (c *Controller) completeRun(greeter *v1alpha1.Greeter, succeeded bool, reason, message string) {
+	if succeeded {
+		greeter.Status.Phase = v1alpha1.PhaseSucceeded
+		setCondition(greeter, v1alpha1.ConditionCompleted, metav1.ConditionTrue, reason, message)
+		setCondition(greeter, v1alpha1.ConditionFailed, metav1.ConditionFalse, reason, message)
+	} else {
+		greeter.Status.Phase = v1alpha1.PhaseFailed
+		setCondition(greeter, v1alpha1.ConditionCompleted, metav1.ConditionFalse, reason, message)
+		setCondition(greeter, v1alpha1.ConditionFailed, metav1.ConditionTrue, reason, message)
+	}
+
+	// A recurring greeter goes back to wait for its next run
+	if len(greeter.Spec.Cron) != 0 {
+		c.recorder.Event(greeter, corev1.EventTypeNormal, RunCompleted, message)
+		greeter.Status.Phase = v1alpha1.PhasePending
+	}
+}
+
+// cleanupFinished deletes the finished runner pods of the greeter whose time
+// to live is expired, and the greeter itself if requested by its policy. It
+// returns the duration until the next expiry and whether the greeter is deleted.
+func (c *Controller) cleanupFinished(ctx context.Context, greeter *v1alpha1.Greeter) (time.Duration, bool, error) {
+	logger := klog.FromContext(ctx)
+	ttl := time.Duration(*greeter.Spec.TTLSecondsAfterFinished) * time.Second
+
+	selector := labels.SelectorFromSet(labels.Set{nameLabel: greeter.Name})
+	pods, err := c.podLister.Pods(greeter.Namespace).List(selector)
+	if err != nil {
+		return 0, false, err
+	}
+
+	requeueAfter := time.Duration(0)
+	for _, pod := range pods {
+		if !metav1.IsControlledBy(pod, greeter) || pod.DeletionTimestamp != nil {
+			continue
+		}
+		if pod.Status.Phase != corev1.PodSucceeded && pod.Status.Phase != corev1.PodFailed {
+			continue
+		}
+		// The result of the current run is not recorded in the status yet
+		if greeter.Status.Phase == v1alpha1.PhaseRunning && pod.Name == greeter.Status.PodName {
+			continue
+		}
+
+		if when := getFinishedTime(pod).Add(ttl).Sub(c.clock.Now()); when > 0 {
+			requeueAfter = shorterDuration(requeueAfter, when)
+			continue
+		}
+
+		err := c.kubeClientset.CoreV1().Pods(pod.Namespace).Delete(ctx, pod.Name, metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return 0, false, err
+		}
+		logger.Info("deleted expired pod", "podName", pod.Name)
+	}
+
+	finished := greeter.Status.Phase == v1alpha1.PhaseSucceeded || greeter.Status.Phase == v1alpha1.PhaseFailed
+	if finished && greeter.Status.CompletionTime != nil && greeter.Spec.TTLAfterFinishedPolicy == v1alpha1.TTLPolicyDeleteGreeter {
+		if when := greeter.Status.CompletionTime.Add(ttl).Sub(c.clock.Now()); when > 0 {
+			return shorterDuration(requeueAfter, when), false, nil
+		}
+
+		// The runner pods are deleted by the garbage collector
+		propagation := metav1.DeletePropagationBackground
+		err := c.greeterClientset.GreeterV1alpha1().Greeters(greeter.Namespace).Delete(ctx, greeter.Name, metav1.DeleteOptions{PropagationPolicy: &propagation})
+		if err != nil && !errors.IsNotFound(err) {
+			return 0, false, err
+		}
+		logger.Info("deleted expired greeter")
+		return 0, true, nil
+	}
+
+	return requeueAfter, false, nil
+}
+
+// setCondition sets the condition of the Greeter to the given status, the
+// transition time is only changed when the status changes.
+func setCondition(greeter *v1alpha1.Greeter, conditionType string, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&greeter.Status.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		ObservedGeneration: greeter.Generation,
+		Reason:             reason,
+		Message:            message,
+	})
 }
 
 // enqueueGreeter takes a Greeter resource and converts it into a namespace/name
@@ -302,6 +851,93 @@ func (c *Controller) enqueueGreeter(object any) {
 	}
 }
 
+// updateGreeter enqueues the updated Greeter, and the throttled Greeters once
+// the capacity of its run is released.
+func (c *Controller) updateGreeter(oldObj, newObj any) {
+	c.enqueueGreeter(newObj)
+
+	oldPhase, newPhase := oldObj.(*v1alpha1.Greeter).Status.Phase, newObj.(*v1alpha1.Greeter).Status.Phase
+	if oldPhase == v1alpha1.PhaseRunning && newPhase != v1alpha1.PhaseRunning {
+		c.enqueueThrottled()
+	}
+}
+
+// deleteGreeter drops the work of a deleted Greeter. The in-flight sync is
+// cancelled, and the delayed requeues are dropped once they find the Greeter
+// no longer exists.
+func (c *Controller) deleteGreeter(object any) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(object)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+
+	c.workQueue.Forget(key)
+	if cancel, ok := c.inflight.Load(key); ok {
+		cancel.(context.CancelFunc)()
+	}
+
+	// The deleted Greeter may release the capacity of its run
+	if c.throttle != nil {
+		c.throttle.forget(key)
+		c.enqueueThrottled()
+	}
+}
+
+// admitRun checks the capacity of the controller to launch the due run of
+// the Greeter. It returns the reason and message of the throttle, and when to
+// check again, if the run has to wait.
+func (c *Controller) admitRun(key string, greeter *v1alpha1.Greeter, now time.Time) (string, string, time.Duration) {
+	if c.throttle == nil {
+		return "", "", 0
+	}
+
+	greeters, err := c.greeterLister.List(labels.Everything())
+	if err != nil {
+		utilruntime.HandleError(err)
+		return v1alpha1.ReasonMaxRunning, "Unable to count the active runs", throttleRequeueDelay
+	}
+
+	var scoped []*v1alpha1.Greeter
+	for _, g := range greeters {
+		if c.inScope(g) {
+			scoped = append(scoped, g)
+		}
+	}
+
+	reason, msg, when := c.throttle.admit(key, greeter, scoped, now)
+	if len(reason) != 0 {
+		launchesThrottled.WithLabelValues(reason).Inc()
+	}
+	return reason, msg, when
+}
+
+// enqueueThrottled enqueues the throttled Greeters in the order they started
+// waiting, so that they are checked as soon as the capacity is released.
+func (c *Controller) enqueueThrottled() {
+	if c.throttle == nil {
+		return
+	}
+
+	for _, key := range c.throttle.waiters(c.clock.Now()) {
+		c.workQueue.Add(key)
+	}
+}
+
+// inScope returns true if the object is in one of the allowed namespaces.
+func (c *Controller) inScope(object any) bool {
+	if tombstone, ok := object.(cache.DeletedFinalStateUnknown); ok {
+		object = tombstone.Obj
+	}
+
+	accessor, err := meta.Accessor(object)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return false
+	}
+	return c.namespaces.Len() == 0 || c.namespaces.Has(accessor.GetNamespace())
+}
+
 // enqueuePod enqueue a pod and checks that the owner reference points to a Greeter object. It then
 // enqueues this Greeter object.
 func (c *Controller) enqueuePod(object any) {
@@ -336,47 +972,176 @@ func (c *Controller) enqueuePod(object any) {
 	}
 }
 
-// timeUntilSchedule parses the schedule string and returns the time until the schedule.
-// When it is overdue, the duration is negative.
-func timeUntilSchedule(schedule string) (time.Duration, error) {
-	expected, err := time.ParseInLocation("2006-01-02 15:04:05", schedule, time.Local)
+// getScheduledTime parses the schedule string in the specified location and
+// returns the absolute time of the schedule.
+func getScheduledTime(schedule string, location *time.Location) (time.Time, error) {
+	return time.ParseInLocation(ScheduleLayout, schedule, location)
+}
+
+// getTimeZone returns the location in which the schedule of the greeter is
+// evaluated, falling back to the default time zone of the controller.
+func (c *Controller) getTimeZone(greeter *v1alpha1.Greeter) (*time.Location, error) {
+	if greeter.Spec.TimeZone == nil {
+		return c.defaultTimeZone, nil
+	}
+	return LoadTimeZone(*greeter.Spec.TimeZone)
+}
+
+// LoadTimeZone returns the location with the given IANA time zone name. Unlike
+// time.LoadLocation, the host dependent "Local" and the empty name are rejected.
+func LoadTimeZone(name string) (*time.Location, error) {
+	if len(name) == 0 || strings.EqualFold(name, "Local") {
+		return nil, fmt.Errorf("unknown time zone %q", name)
+	}
+	return time.LoadLocation(name)
+}
+
+// getNextScheduledTime calculate the latest run which is due based on the last
+// fired or missed run, the earlier due runs which are missed, as well as the
+// next run time after the current time. If there are more due runs than
+// maxScheduledTimes, all the evaluated runs are missed and no run is due, the
+// rest are evaluated from the last missed run next time.
+func getNextScheduledTime(greeter *v1alpha1.Greeter, now time.Time) (dueRun time.Time, missedRuns []time.Time, nextRun time.Time, err error) {
+	schedule, err := cron.ParseStandard(greeter.Spec.Cron)
 	if err != nil {
-		return 0, err
+		return time.Time{}, nil, time.Time{}, err
+	}
+
+	// we’ll start calculating appropriate times from our last run or missed
+	// run, or the creation of the Greeter if we can’t find them. The schedule
+	// is evaluated in the location of the current time.
+	earliestTime := greeter.CreationTimestamp.Time
+	for _, t := range []*metav1.Time{greeter.Status.LastScheduleTime, greeter.Status.LastMissedTime} {
+		if t != nil && t.After(earliestTime) {
+			earliestTime = t.Time
+		}
 	}
-	return expected.Sub(time.Now().UTC()), nil
+	earliestTime = earliestTime.In(now.Location())
+
+	// There are currently no runs to fire
+	if earliestTime.After(now) {
+		return time.Time{}, nil, schedule.Next(now), nil
+	}
+
+	var dueRuns []time.Time
+	for t := schedule.Next(earliestTime); !t.IsZero() && !t.After(now); t = schedule.Next(t) {
+		// We only fire the most recent due run, but in case of an incorrect
+		// clock there could be so many missed runs that it would eat up all the
+		// CPU of this controller, so we walk through a bounded number of them
+		// at a time.
+		if len(dueRuns) == maxScheduledTimes {
+			return time.Time{}, dueRuns, schedule.Next(now), nil
+		}
+		dueRuns = append(dueRuns, t)
+	}
+	if len(dueRuns) == 0 {
+		return time.Time{}, nil, schedule.Next(now), nil
+	}
+
+	return dueRuns[len(dueRuns)-1], dueRuns[:len(dueRuns)-1], schedule.Next(now), nil
 }
 
-// newPodForGreeter returns a busybox pod with the same name/namespace as the greeter
-func newPodForGreeter(greeter *v1alpha1.Greeter) *corev1.Pod {
-	return &corev1.Pod{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      greeter.Name + "-runner",
-			Namespace: greeter.Namespace,
-			Labels: map[string]string{
-				"app.k8s.io/name": greeter.Name,
-			},
-		},
-		Spec: corev1.PodSpec{
-			Containers: []corev1.Container{
-				{
-					Image:   "busybox",
-					Name:    "greeter",
-					Command: []string{"sh"},
-					Args:    []string{"-c", "echo " + greeter.Spec.Message},
-				},
-			},
-			RestartPolicy: corev1.RestartPolicyNever,
-		},
+// getActiveDeadline returns the time when the current run exceeds its
+// active deadline, if the greeter has a deadline and the run is started.
+func getActiveDeadline(greeter *v1alpha1.Greeter) (time.Time, bool) {
+	if greeter.Spec.ActiveDeadlineSeconds == nil || greeter.Status.StartTime == nil {
+		return time.Time{}, false
+	}
+	return greeter.Status.StartTime.Add(time.Duration(*greeter.Spec.ActiveDeadlineSeconds) * time.Second), true
+}
+
+// getFinishedTime returns the time when the last container of the finished
+// pod was terminated, or the creation time of the pod if unknown.
+func getFinishedTime(pod *corev1.Pod) time.Time {
+	finishedAt := pod.CreationTimestamp.Time
+	for _, containerStatus := range pod.Status.ContainerStatuses {
+		if terminated := containerStatus.State.Terminated; terminated != nil && terminated.FinishedAt.After(finishedAt) {
+			finishedAt = terminated.FinishedAt.Time
+		}
 	}
+	return finishedAt
 }
 
-// NewController returns a new greeter controller
+// shorterDuration returns the shorter of the non-zero durations, zero means
+// there is nothing to wait for.
+func shorterDuration(a, b time.Duration) time.Duration {
+	if a == 0 || (b != 0 && b < a) {
+		return b
+	}
+	return a
+}
+
+// getRunNowToken returns the token of the run-now annotation of the greeter
+// if it's not consumed yet.
+func getRunNowToken(greeter *v1alpha1.Greeter) string {
+	if token := greeter.Annotations[v1alpha1.AnnotationRunNow]; token != greeter.Status.LastRunNowToken {
+		return token
+	}
+	return ""
+}
+
+// getPodNameForRun returns the name of the runner pod for the first attempt
+// of the run scheduled at the specified time, so that each run of a recurring
+// greeter gets its own pod. A run fired by the run-now token is named after the
+// token, as it may be fired in the same minute as another run.
+func getPodNameForRun(greeter *v1alpha1.Greeter, scheduledTime time.Time, runNowToken string) string {
+	if len(runNowToken) != 0 {
+		hash := fnv.New32a()
+		hash.Write([]byte(runNowToken))
+		return fmt.Sprintf("%s-now-%08x", greeter.Name, hash.Sum32())
+	}
+	if len(greeter.Spec.Cron) != 0 {
+		return fmt.Sprintf("%s-%d", greeter.Name, scheduledTime.Unix()/60)
+	}
+	return greeter.Name + "-runner"
+}
+
+// getPodNameForRetry returns the name of the runner pod for the attempt of
+// the run whose previous attempt ran in the specified pod, so that each retry
+// gets its own pod.
+func getPodNameForRetry(previousPod string, attempt int32) string {
+	name := previousPod
+	if attempt > 1 {
+		name = strings.TrimSuffix(name, fmt.Sprintf("-%d", attempt-1))
+	}
+	return fmt.Sprintf("%s-%d", name, attempt)
+}
+
+// getBackoffLimit returns the number of retries of a failed run.
+func getBackoffLimit(greeter *v1alpha1.Greeter) int32 {
+	if greeter.Spec.BackoffLimit == nil {
+		return 0
+	}
+	return *greeter.Spec.BackoffLimit
+}
+
+// getRetryBackoff returns the delay before the retry after the specified
+// number of failed attempts, the delay is doubled for each failed attempt.
+func getRetryBackoff(greeter *v1alpha1.Greeter, attempts int32) time.Duration {
+	backoff := defaultRetryBackoff
+	if greeter.Spec.RetryBackoff != nil {
+		backoff = greeter.Spec.RetryBackoff.Duration
+	}
+
+	for i := int32(1); i < attempts && backoff < maxRetryBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxRetryBackoff {
+		backoff = maxRetryBackoff
+	}
+	return backoff
+}
+
+// NewController returns a new greeter controller. The objects are watched by
+// the pod and Greeter informers of each namespace, or a single pair of them
+// for all namespaces.
 func NewController(
 	ctx context.Context,
 	kubeClientset kubernetes.Interface,
 	greeterClientset clientset.Interface,
-	podInformer coreinformers.PodInformer,
-	greeterInformer greeterinformers.GreeterInformer,
+	podInformers []coreinformers.PodInformer,
+	greeterInformers []greeterinformers.GreeterInformer,
+	options ...Option,
 ) *Controller {
 	logger := klog.FromContext(ctx)
 
@@ -384,50 +1149,87 @@ func NewController(
 	// Add greeter-controller types to the default Kubernetes Scheme so Events can be
 	// logged for greeter-controller types.
 	utilruntime.Must(greeterscheme.AddToScheme(scheme.Scheme))
-	logger.V(4).Info("Creating event broadcaster")
-
-	eventBroadcaster := record.NewBroadcaster()
-	eventBroadcaster.StartStructuredLogging(0)
-	eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: kubeClientset.CoreV1().Events("")})
-	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: controllerAgentName})
-
-	rateLimiter := workqueue.DefaultControllerRateLimiter()
 	controller := &Controller{
 		kubeClientset:    kubeClientset,
 		greeterClientset: greeterClientset,
-		podLister:        podInformer.Lister(),
-		podSynced:        podInformer.Informer().HasSynced,
-		greeterLister:    greeterInformer.Lister(),
-		greeterSynced:    greeterInformer.Informer().HasSynced,
-		workQueue: workqueue.NewRateLimitingQueueWithConfig(rateLimiter, workqueue.RateLimitingQueueConfig{
-			Name: "Greeters",
-		}),
-		recorder: recorder,
+		clock:            clock.RealClock{},
+		defaultTimeZone:  time.UTC,
+		clusterDomain:    DefaultClusterDomain,
+		maxOutputBytes:   defaultMaxOutputBytes,
+	}
+	for _, option := range options {
+		option(controller)
 	}
 
-	logger.Info("Setting up event handlers")
-	// Set up an event handler for when Greeter resources change
-	_, err := greeterInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc: controller.enqueueGreeter,
-		UpdateFunc: func(oldObj, newObj interface{}) {
-			controller.enqueueGreeter(newObj)
-		},
-	})
-	if err != nil {
-		logger.Error(err, "Error setup event handler for Greeter")
-		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+	var podListers podListers
+	var greeterListers greeterListers
+	var podSynced, greeterSynced []cache.InformerSynced
+	for _, podInformer := range podInformers {
+		podListers = append(podListers, podInformer.Lister())
+		podSynced = append(podSynced, podInformer.Informer().HasSynced)
+	}
+	for _, greeterInformer := range greeterInformers {
+		greeterListers = append(greeterListers, greeterInformer.Lister())
+		greeterSynced = append(greeterSynced, greeterInformer.Informer().HasSynced)
+	}
+	controller.podLister, controller.podSynced = podListers, allSynced(podSynced)
+	controller.greeterLister, controller.greeterSynced = greeterListers, allSynced(greeterSynced)
+	if len(podInformers) == 1 && len(greeterInformers) == 1 {
+		controller.podLister, controller.podSynced = podInformers[0].Lister(), podSynced[0]
+		controller.greeterLister, controller.greeterSynced = greeterInformers[0].Lister(), greeterSynced[0]
+	}
+
+	if controller.recorder == nil {
+		logger.V(4).Info("Creating event broadcaster")
+
+		eventBroadcaster := record.NewBroadcaster()
+		eventBroadcaster.StartStructuredLogging(0)
+		eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: kubeClientset.CoreV1().Events("")})
+		controller.recorder = eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: controllerAgentName})
 	}
 
-	// Set up an event handler for when Pod resources change
-	_, err = podInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc: controller.enqueuePod,
-		UpdateFunc: func(oldObj, newObj interface{}) {
-			controller.enqueuePod(newObj)
-		},
+	rateLimiter := workqueue.DefaultControllerRateLimiter()
+	controller.workQueue = workqueue.NewRateLimitingQueueWithConfig(rateLimiter, workqueue.RateLimitingQueueConfig{
+		Name:  "Greeters",
+		Clock: controller.clock,
 	})
-	if err != nil {
-		logger.Error(err, "Error setup event handler for Pod")
-		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+	controller.sinks = newSinks(controller)
+	controller.throttle = newLaunchThrottle(controller.maxRunning, controller.maxRunningPerNamespace,
+		controller.launchQPS, controller.launchBurst)
+
+	logger.Info("Setting up event handlers")
+	for _, greeterInformer := range greeterInformers {
+		// Set up an event handler for when Greeter resources change
+		_, err := greeterInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+			FilterFunc: controller.inScope,
+			Handler: cache.ResourceEventHandlerFuncs{
+				AddFunc:    controller.enqueueGreeter,
+				UpdateFunc: controller.updateGreeter,
+				DeleteFunc: controller.deleteGreeter,
+			},
+		})
+		if err != nil {
+			logger.Error(err, "Error setup event handler for Greeter")
+			klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+		}
+	}
+
+	for _, podInformer := range podInformers {
+		// Set up an event handler for when Pod resources change
+		_, err := podInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+			FilterFunc: controller.inScope,
+			Handler: cache.ResourceEventHandlerFuncs{
+				AddFunc: controller.enqueuePod,
+				UpdateFunc: func(oldObj, newObj interface{}) {
+					controller.enqueuePod(newObj)
+				},
+				DeleteFunc: controller.enqueuePod,
+			},
+		})
+		if err != nil {
+			logger.Error(err, "Error setup event handler for Pod")
+			klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+		}
 	}
 
 	return controller
@@ -0,0 +1,413 @@
+package greeter
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/wjiec/programming_k8s/greeter/pkg/apis/greeter/v1alpha1"
+)
+
+var simulationStart = time.Date(2023, time.October, 1, 12, 0, 0, 0, time.UTC)
+
+func newGreeter(name string, spec v1alpha1.GreeterSpec) *v1alpha1.Greeter {
+	if len(spec.Message) == 0 {
+		spec.Message = "hello " + name
+	}
+	return &v1alpha1.Greeter{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       spec,
+	}
+}
+
+func at(clock string) time.Time {
+	t, err := time.ParseInLocation(ScheduleLayout, "2023-10-01 "+clock, time.UTC)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func expectPhase(t *testing.T, greeter *v1alpha1.Greeter, phase string) {
+	t.Helper()
+	if greeter.Status.Phase != phase {
+		t.Fatalf("expected phase %s of greeter %q, got %s", phase, greeter.Name, greeter.Status.Phase)
+	}
+}
+
+func expectCondition(t *testing.T, greeter *v1alpha1.Greeter, conditionType string, status metav1.ConditionStatus, reason string) {
+	t.Helper()
+	condition := meta.FindStatusCondition(greeter.Status.Conditions, conditionType)
+	if condition == nil {
+		t.Fatalf("expected condition %s of greeter %q, got none", conditionType, greeter.Name)
+	}
+	if condition.Status != status || condition.Reason != reason {
+		t.Fatalf("expected condition %s of greeter %q to be %s (%s), got %s (%s)",
+			conditionType, greeter.Name, status, reason, condition.Status, condition.Reason)
+	}
+}
+
+func expectTime(t *testing.T, what string, actual *metav1.Time, expected time.Time) {
+	t.Helper()
+	if actual == nil || !actual.Time.Equal(expected) {
+		t.Fatalf("expected %s to be %s, got %v", what, expected.Format(time.RFC3339), actual)
+	}
+}
+
+func expectLaunches(t *testing.T, s *simulation, expected ...launchRecord) {
+	t.Helper()
+	if len(s.launches) != len(expected) {
+		t.Fatalf("expected %d launches, got %v", len(expected), s.launches)
+	}
+	for i, launch := range s.launches {
+		if launch.name != expected[i].name || !launch.at.Equal(expected[i].at) {
+			t.Fatalf("expected launch %d to be %q at %s, got %q at %s", i, expected[i].name,
+				expected[i].at.Format(time.RFC3339), launch.name, launch.at.Format(time.RFC3339))
+		}
+	}
+}
+
+func TestOneShotGreeter(t *testing.T) {
+	s := newSimulation(t, simulationStart)
+	s.createGreeter(newGreeter("once", v1alpha1.GreeterSpec{Schedule: "2023-10-01 12:05:00"}))
+
+	s.runUntil(at("12:04:59"))
+	greeter := s.greeter("once")
+	expectPhase(t, greeter, v1alpha1.PhasePending)
+	expectCondition(t, greeter, v1alpha1.ConditionScheduled, metav1.ConditionFalse, v1alpha1.ReasonWaitingForSchedule)
+	expectTime(t, "next schedule time", greeter.Status.NextScheduleTime, at("12:05:00"))
+	expectLaunches(t, s)
+
+	s.runUntil(at("12:10:00"))
+	greeter = s.greeter("once")
+	expectPhase(t, greeter, v1alpha1.PhaseSucceeded)
+	expectCondition(t, greeter, v1alpha1.ConditionCompleted, metav1.ConditionTrue, v1alpha1.ReasonPodSucceeded)
+	expectLaunches(t, s, launchRecord{name: "once-runner", at: at("12:05:00")})
+	expectTime(t, "last schedule time", greeter.Status.LastScheduleTime, at("12:05:00"))
+	expectTime(t, "start time", greeter.Status.StartTime, at("12:05:00"))
+	expectTime(t, "completion time", greeter.Status.CompletionTime, at("12:05:03"))
+	if greeter.Status.NextScheduleTime != nil {
+		t.Fatalf("expected no next schedule time, got %s", greeter.Status.NextScheduleTime)
+	}
+	if greeter.Status.ExitCode == nil || *greeter.Status.ExitCode != 0 {
+		t.Fatalf("expected exit code 0, got %v", greeter.Status.ExitCode)
+	}
+}
+
+func TestRecurringGreeter(t *testing.T) {
+	s := newSimulation(t, simulationStart)
+	s.createGreeter(newGreeter("cron", v1alpha1.GreeterSpec{Cron: "*/5 * * * *"}))
+
+	s.runUntil(at("12:21:00"))
+	greeter := s.greeter("cron")
+	expectPhase(t, greeter, v1alpha1.PhasePending)
+	expectCondition(t, greeter, v1alpha1.ConditionCompleted, metav1.ConditionTrue, v1alpha1.ReasonPodSucceeded)
+	expectTime(t, "last schedule time", greeter.Status.LastScheduleTime, at("12:20:00"))
+	expectTime(t, "next schedule time", greeter.Status.NextScheduleTime, at("12:25:00"))
+
+	var expected []launchRecord
+	for _, scheduled := range []time.Time{at("12:05:00"), at("12:10:00"), at("12:15:00"), at("12:20:00")} {
+		expected = append(expected, launchRecord{name: getPodNameForRun(greeter, scheduled, ""), at: scheduled})
+	}
+	expectLaunches(t, s, expected...)
+}
+
+func TestRetryWithBackoff(t *testing.T) {
+	s := newSimulation(t, simulationStart)
+	s.podScript = func(*corev1.Pod) podLifecycle {
+		return podLifecycle{startAfter: time.Second, runFor: 2 * time.Second, exitCode: 1}
+	}
+
+	backoffLimit := int32(2)
+	s.createGreeter(newGreeter("retry", v1alpha1.GreeterSpec{Schedule: "2023-10-01 12:00:00", BackoffLimit: &backoffLimit}))
+
+	s.runUntil(at("12:00:20"))
+	greeter := s.greeter("retry")
+	expectPhase(t, greeter, v1alpha1.PhaseRunning)
+	expectCondition(t, greeter, v1alpha1.ConditionFailed, metav1.ConditionFalse, v1alpha1.ReasonRetrying)
+
+	s.runUntil(at("12:05:00"))
+	greeter = s.greeter("retry")
+	expectPhase(t, greeter, v1alpha1.PhaseFailed)
+	expectCondition(t, greeter, v1alpha1.ConditionFailed, metav1.ConditionTrue, v1alpha1.ReasonBackoffLimitExceeded)
+	if greeter.Status.Attempts != backoffLimit {
+		t.Fatalf("expected %d attempts, got %d", backoffLimit, greeter.Status.Attempts)
+	}
+
+	// The backoff is doubled for each failed attempt
+	expectLaunches(t, s,
+		launchRecord{name: "retry-runner", at: at("12:00:00")},
+		launchRecord{name: "retry-runner-1", at: at("12:00:13")},
+		launchRecord{name: "retry-runner-2", at: at("12:00:36")},
+	)
+	if pods := s.podNames(); !reflect.DeepEqual(pods, []string{"retry-runner-2"}) {
+		t.Fatalf("expected only the pod of the last attempt to be kept, got %v", pods)
+	}
+}
+
+func TestActiveDeadline(t *testing.T) {
+	s := newSimulation(t, simulationStart)
+	s.podScript = func(*corev1.Pod) podLifecycle {
+		return podLifecycle{startAfter: time.Second, runFor: -1}
+	}
+
+	deadline := int64(30)
+	s.createGreeter(newGreeter("stuck", v1alpha1.GreeterSpec{Schedule: "2023-10-01 12:00:00", ActiveDeadlineSeconds: &deadline}))
+
+	s.runUntil(at("12:00:29"))
+	expectPhase(t, s.greeter("stuck"), v1alpha1.PhaseRunning)
+
+	s.runUntil(at("12:01:00"))
+	greeter := s.greeter("stuck")
+	expectPhase(t, greeter, v1alpha1.PhaseFailed)
+	expectCondition(t, greeter, v1alpha1.ConditionDeadlineExceeded, metav1.ConditionTrue, v1alpha1.ReasonDeadlineExceeded)
+	expectTime(t, "completion time", greeter.Status.CompletionTime, at("12:00:30"))
+	if pods := s.podNames(); len(pods) != 0 {
+		t.Fatalf("expected the pod to be deleted, got %v", pods)
+	}
+}
+
+func TestSuspendAndRunNow(t *testing.T) {
+	s := newSimulation(t, simulationStart)
+
+	suspend := true
+	s.createGreeter(newGreeter("paused", v1alpha1.GreeterSpec{Cron: "* * * * *", Suspend: &suspend}))
+	s.at(at("12:03:30"), func() {
+		s.patchGreeter("paused", `{"metadata":{"annotations":{"`+v1alpha1.AnnotationRunNow+`":"token-1"}}}`)
+	})
+
+	s.runUntil(at("12:03:00"))
+	greeter := s.greeter("paused")
+	expectPhase(t, greeter, v1alpha1.PhasePending)
+	expectCondition(t, greeter, v1alpha1.ConditionScheduled, metav1.ConditionFalse, v1alpha1.ReasonSuspended)
+	expectLaunches(t, s)
+
+	// The run is fired exactly once for the token, even though suspended
+	s.runUntil(at("12:10:00"))
+	greeter = s.greeter("paused")
+	expectPhase(t, greeter, v1alpha1.PhasePending)
+	expectCondition(t, greeter, v1alpha1.ConditionScheduled, metav1.ConditionFalse, v1alpha1.ReasonSuspended)
+	expectLaunches(t, s, launchRecord{name: getPodNameForRun(greeter, at("12:03:30"), "token-1"), at: at("12:03:30")})
+	if greeter.Status.LastRunNowToken != "token-1" {
+		t.Fatalf("expected the token to be consumed, got %q", greeter.Status.LastRunNowToken)
+	}
+	if !s.hasEvent(RunTriggered) {
+		t.Fatalf("expected a %s event, got %v", RunTriggered, s.events)
+	}
+}
+
+func TestRunNowInScheduledMinute(t *testing.T) {
+	s := newSimulation(t, simulationStart)
+	s.createGreeter(newGreeter("busy", v1alpha1.GreeterSpec{Cron: "*/5 * * * *"}))
+	for clock, token := range map[string]string{"12:05:20": "token-1", "12:05:40": "token-2"} {
+		patch := `{"metadata":{"annotations":{"` + v1alpha1.AnnotationRunNow + `":"` + token + `"}}}`
+		s.at(at(clock), func() { s.patchGreeter("busy", patch) })
+	}
+
+	// Each run fired in the same minute gets its own pod instead of adopting
+	// the finished pod of the previous run.
+	s.runUntil(at("12:06:00"))
+	greeter := s.greeter("busy")
+	expectPhase(t, greeter, v1alpha1.PhasePending)
+	expectLaunches(t, s,
+		launchRecord{name: getPodNameForRun(greeter, at("12:05:00"), ""), at: at("12:05:00")},
+		launchRecord{name: getPodNameForRun(greeter, at("12:05:20"), "token-1"), at: at("12:05:20")},
+		launchRecord{name: getPodNameForRun(greeter, at("12:05:40"), "token-2"), at: at("12:05:40")},
+	)
+	if greeter.Status.LastRunNowToken != "token-2" {
+		t.Fatalf("expected the last token to be consumed, got %q", greeter.Status.LastRunNowToken)
+	}
+}
+
+func TestMaxRunning(t *testing.T) {
+	s := newSimulation(t, simulationStart, WithMaxRunning(1, 0))
+	s.podScript = func(*corev1.Pod) podLifecycle {
+		return podLifecycle{startAfter: time.Second, runFor: 10 * time.Second}
+	}
+
+	s.createGreeter(newGreeter("a", v1alpha1.GreeterSpec{Schedule: "2023-10-01 12:01:00"}))
+	s.createGreeter(newGreeter("b", v1alpha1.GreeterSpec{Schedule: "2023-10-01 12:01:00"}))
+
+	s.runUntil(at("12:01:05"))
+	expectPhase(t, s.greeter("a"), v1alpha1.PhaseRunning)
+	greeter := s.greeter("b")
+	expectPhase(t, greeter, v1alpha1.PhasePending)
+	expectCondition(t, greeter, v1alpha1.ConditionThrottled, metav1.ConditionTrue, v1alpha1.ReasonMaxRunning)
+
+	// The condition doesn't change while the greeter is waiting
+	throttled := meta.FindStatusCondition(greeter.Status.Conditions, v1alpha1.ConditionThrottled)
+	if throttled.Message != "Active runs are limited to 1 cluster-wide" {
+		t.Fatalf("expected a stable message, got %q", throttled.Message)
+	}
+	s.runUntil(at("12:01:10"))
+	if condition := meta.FindStatusCondition(s.greeter("b").Status.Conditions, v1alpha1.ConditionThrottled); !reflect.DeepEqual(condition, throttled) {
+		t.Fatalf("expected the throttled condition %v to be unchanged, got %v", throttled, condition)
+	}
+
+	// The throttled greeter is launched as soon as the capacity is released
+	s.runUntil(at("12:05:00"))
+	expectPhase(t, s.greeter("a"), v1alpha1.PhaseSucceeded)
+	greeter = s.greeter("b")
+	expectPhase(t, greeter, v1alpha1.PhaseSucceeded)
+	if meta.FindStatusCondition(greeter.Status.Conditions, v1alpha1.ConditionThrottled) != nil {
+		t.Fatalf("expected the throttled condition to be removed, got %v", greeter.Status.Conditions)
+	}
+	expectLaunches(t, s,
+		launchRecord{name: "a-runner", at: at("12:01:00")},
+		launchRecord{name: "b-runner", at: at("12:01:11")},
+	)
+}
+
+func TestThrottleUndoneOnFailedLaunch(t *testing.T) {
+	s := newSimulation(t, simulationStart, WithMaxRunning(1, 0), WithLaunchRate(1.0/60, 1))
+
+	// The first launch fails to be persisted
+	failed := false
+	s.greeterClientset.PrependReactor("patch", "greeters", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		patch := action.(k8stesting.PatchAction)
+		if failed || !strings.Contains(string(patch.GetPatch()), `"phase":"Running"`) {
+			return false, nil, nil
+		}
+		failed = true
+		return true, nil, fmt.Errorf("injected apply failure")
+	})
+
+	s.createGreeter(newGreeter("a", v1alpha1.GreeterSpec{Schedule: "2023-10-01 12:01:00"}))
+
+	// Neither the launch slot nor the token is held by the failed launch,
+	// so the run is launched once retried.
+	s.runUntil(at("12:02:00"))
+	if !failed {
+		t.Fatalf("expected the launch to fail once")
+	}
+	greeter := s.greeter("a")
+	expectPhase(t, greeter, v1alpha1.PhaseSucceeded)
+	expectLaunches(t, s, launchRecord{name: "a-runner", at: at("12:01:01")})
+}
+
+func TestMissedRunsAfterDowntime(t *testing.T) {
+	tests := []struct {
+		name     string
+		downtime time.Duration
+		// missed is the number of the missed runs, all the runs before the
+		// latest one are missed.
+		missed int64
+	}{
+		{name: "more than a hundred runs", downtime: 3 * time.Hour, missed: 179},
+		{name: "more runs than evaluated in a sync", downtime: 20 * time.Hour, missed: 1199},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newSimulation(t, simulationStart)
+			s.createGreeter(newGreeter("minutely", v1alpha1.GreeterSpec{Cron: "* * * * *"}))
+			s.runUntil(at("12:00:30"))
+
+			// The controller is down, nothing is synced in the meantime
+			latest := simulationStart.Add(tt.downtime)
+			s.clock.SetTime(latest.Add(30 * time.Second))
+
+			// Only the latest run is fired, the earlier ones are recorded as missed
+			s.runUntil(latest.Add(45 * time.Second))
+			greeter := s.greeter("minutely")
+			expectLaunches(t, s, launchRecord{name: getPodNameForRun(greeter, latest, ""), at: latest.Add(30 * time.Second)})
+			expectTime(t, "last schedule time", greeter.Status.LastScheduleTime, latest)
+			expectTime(t, "last missed time", greeter.Status.LastMissedTime, latest.Add(-time.Minute))
+			if greeter.Status.MissedRuns != tt.missed {
+				t.Fatalf("expected %d missed runs, got %d", tt.missed, greeter.Status.MissedRuns)
+			}
+			if !s.hasEvent(RunsMissed) {
+				t.Fatalf("expected a %s event, got %v", RunsMissed, s.events)
+			}
+
+			// The greeter is back on schedule
+			s.runUntil(latest.Add(time.Minute + 30*time.Second))
+			expectLaunches(t, s,
+				launchRecord{name: getPodNameForRun(greeter, latest, ""), at: latest.Add(30 * time.Second)},
+				launchRecord{name: getPodNameForRun(greeter, latest.Add(time.Minute), ""), at: latest.Add(time.Minute)},
+			)
+			if missed := s.greeter("minutely").Status.MissedRuns; missed != tt.missed {
+				t.Fatalf("expected %d missed runs, got %d", tt.missed, missed)
+			}
+		})
+	}
+}
+
+func TestSuspendAndResume(t *testing.T) {
+	s := newSimulation(t, simulationStart)
+	s.createGreeter(newGreeter("minutely", v1alpha1.GreeterSpec{Cron: "* * * * *"}))
+	s.at(at("12:02:30"), func() { s.patchGreeter("minutely", `{"spec":{"suspend":true}}`) })
+	s.at(at("14:30:30"), func() { s.patchGreeter("minutely", `{"spec":{"suspend":false}}`) })
+
+	s.runUntil(at("14:30:00"))
+	greeter := s.greeter("minutely")
+	expectCondition(t, greeter, v1alpha1.ConditionScheduled, metav1.ConditionFalse, v1alpha1.ReasonSuspended)
+	if greeter.Status.MissedRuns != 0 {
+		t.Fatalf("expected no missed runs while suspended, got %d", greeter.Status.MissedRuns)
+	}
+
+	// The runs due while suspended are missed, none of them is fired once resumed
+	s.runUntil(at("14:30:45"))
+	greeter = s.greeter("minutely")
+	expectPhase(t, greeter, v1alpha1.PhasePending)
+	expectCondition(t, greeter, v1alpha1.ConditionScheduled, metav1.ConditionFalse, v1alpha1.ReasonWaitingForSchedule)
+	expectTime(t, "last missed time", greeter.Status.LastMissedTime, at("14:30:00"))
+	expectTime(t, "next schedule time", greeter.Status.NextScheduleTime, at("14:31:00"))
+	if greeter.Status.MissedRuns != 148 {
+		t.Fatalf("expected 148 missed runs, got %d", greeter.Status.MissedRuns)
+	}
+
+	// The greeter restarts from the time it's resumed
+	s.runUntil(at("14:31:30"))
+	expectLaunches(t, s,
+		launchRecord{name: getPodNameForRun(greeter, at("12:01:00"), ""), at: at("12:01:00")},
+		launchRecord{name: getPodNameForRun(greeter, at("12:02:00"), ""), at: at("12:02:00")},
+		launchRecord{name: getPodNameForRun(greeter, at("14:31:00"), ""), at: at("14:31:00")},
+	)
+	if missed := s.greeter("minutely").Status.MissedRuns; missed != 148 {
+		t.Fatalf("expected 148 missed runs, got %d", missed)
+	}
+}
+
+func TestRunNowFinishedGreeter(t *testing.T) {
+	s := newSimulation(t, simulationStart)
+	s.podScript = func(*corev1.Pod) podLifecycle {
+		return podLifecycle{startAfter: time.Second, runFor: 10 * time.Second}
+	}
+	s.createGreeter(newGreeter("once", v1alpha1.GreeterSpec{Schedule: "2023-10-01 12:01:00"}))
+	for clock, token := range map[string]string{"12:01:05": "token-1", "12:05:00": "token-2"} {
+		patch := `{"metadata":{"annotations":{"` + v1alpha1.AnnotationRunNow + `":"` + token + `"}}}`
+		s.at(at(clock), func() { s.patchGreeter("once", patch) })
+	}
+
+	// The token of the running greeter fires once the run is finished
+	s.runUntil(at("12:02:00"))
+	greeter := s.greeter("once")
+	expectPhase(t, greeter, v1alpha1.PhaseSucceeded)
+	expectLaunches(t, s,
+		launchRecord{name: "once-runner", at: at("12:01:00")},
+		launchRecord{name: getPodNameForRun(greeter, at("12:01:11"), "token-1"), at: at("12:01:11")},
+	)
+
+	// The finished greeter is fired again by a new token
+	s.runUntil(at("12:06:00"))
+	greeter = s.greeter("once")
+	expectPhase(t, greeter, v1alpha1.PhaseSucceeded)
+	expectTime(t, "last schedule time", greeter.Status.LastScheduleTime, at("12:05:00"))
+	expectLaunches(t, s,
+		launchRecord{name: "once-runner", at: at("12:01:00")},
+		launchRecord{name: getPodNameForRun(greeter, at("12:01:11"), "token-1"), at: at("12:01:11")},
+		launchRecord{name: getPodNameForRun(greeter, at("12:05:00"), "token-2"), at: at("12:05:00")},
+	)
+	if greeter.Status.LastRunNowToken != "token-2" {
+		t.Fatalf("expected the last token to be consumed, got %q", greeter.Status.LastRunNowToken)
+	}
+}
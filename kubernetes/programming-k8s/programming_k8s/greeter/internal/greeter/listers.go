@@ -0,0 +1,125 @@
+package greeter
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	corelister "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/wjiec/programming_k8s/greeter/pkg/apis/greeter/v1alpha1"
+	greeterlisters "github.com/wjiec/programming_k8s/greeter/pkg/generated/listers/greeter/v1alpha1"
+)
+
+// podListers lists the pods from the listers of several informers, each of
+// them caches the pods of its own namespace only.
+type podListers []corelister.PodLister
+
+// List implements the corelister.PodLister interface.
+func (l podListers) List(selector labels.Selector) ([]*corev1.Pod, error) {
+	var pods []*corev1.Pod
+	for _, lister := range l {
+		found, err := lister.List(selector)
+		if err != nil {
+			return nil, err
+		}
+		pods = append(pods, found...)
+	}
+	return pods, nil
+}
+
+// Pods implements the corelister.PodLister interface.
+func (l podListers) Pods(namespace string) corelister.PodNamespaceLister {
+	return podNamespaceListers{listers: l, namespace: namespace}
+}
+
+type podNamespaceListers struct {
+	listers   podListers
+	namespace string
+}
+
+// List implements the corelister.PodNamespaceLister interface.
+func (l podNamespaceListers) List(selector labels.Selector) ([]*corev1.Pod, error) {
+	var pods []*corev1.Pod
+	for _, lister := range l.listers {
+		found, err := lister.Pods(l.namespace).List(selector)
+		if err != nil {
+			return nil, err
+		}
+		pods = append(pods, found...)
+	}
+	return pods, nil
+}
+
+// Get implements the corelister.PodNamespaceLister interface.
+func (l podNamespaceListers) Get(name string) (*corev1.Pod, error) {
+	for _, lister := range l.listers {
+		if pod, err := lister.Pods(l.namespace).Get(name); !errors.IsNotFound(err) {
+			return pod, err
+		}
+	}
+	return nil, errors.NewNotFound(corev1.Resource("pod"), name)
+}
+
+// greeterListers lists the Greeters from the listers of several informers,
+// each of them caches the Greeters of its own namespace only.
+type greeterListers []greeterlisters.GreeterLister
+
+// List implements the greeterlisters.GreeterLister interface.
+func (l greeterListers) List(selector labels.Selector) ([]*v1alpha1.Greeter, error) {
+	var greeters []*v1alpha1.Greeter
+	for _, lister := range l {
+		found, err := lister.List(selector)
+		if err != nil {
+			return nil, err
+		}
+		greeters = append(greeters, found...)
+	}
+	return greeters, nil
+}
+
+// Greeters implements the greeterlisters.GreeterLister interface.
+func (l greeterListers) Greeters(namespace string) greeterlisters.GreeterNamespaceLister {
+	return greeterNamespaceListers{listers: l, namespace: namespace}
+}
+
+type greeterNamespaceListers struct {
+	listers   greeterListers
+	namespace string
+}
+
+// List implements the greeterlisters.GreeterNamespaceLister interface.
+func (l greeterNamespaceListers) List(selector labels.Selector) ([]*v1alpha1.Greeter, error) {
+	var greeters []*v1alpha1.Greeter
+	for _, lister := range l.listers {
+		found, err := lister.Greeters(l.namespace).List(selector)
+		if err != nil {
+			return nil, err
+		}
+		greeters = append(greeters, found...)
+	}
+	return greeters, nil
+}
+
+// Get implements the greeterlisters.GreeterNamespaceLister interface.
+func (l greeterNamespaceListers) Get(name string) (*v1alpha1.Greeter, error) {
+	for _, lister := range l.listers {
+		if greeter, err := lister.Greeters(l.namespace).Get(name); !errors.IsNotFound(err) {
+			return greeter, err
+		}
+	}
+	return nil, errors.NewNotFound(v1alpha1.Resource("greeter"), name)
+}
+
+// allSynced returns an InformerSynced which is true once all the informers
+// are synced.
+func allSynced(synced []cache.InformerSynced) cache.InformerSynced {
+	return func() bool {
+		for _, hasSynced := range synced {
+			if !hasSynced() {
+				return false
+			}
+		}
+		return true
+	}
+}
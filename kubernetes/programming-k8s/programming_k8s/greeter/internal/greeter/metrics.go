@@ -0,0 +1,143 @@
+package greeter
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	// The metrics package of the controller-runtime registers itself as the
+	// provider of the workqueue metrics, so the depth, latency and retries
+	// of the "Greeters" queue are published on the same registry.
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/wjiec/programming_k8s/greeter/pkg/apis/greeter/v1alpha1"
+	greeterlisters "github.com/wjiec/programming_k8s/greeter/pkg/generated/listers/greeter/v1alpha1"
+)
+
+const (
+	// metricsNamespace is the prefix of all metrics exposed by the controller.
+	metricsNamespace = "greeter"
+
+	// syncResultSuccess is the result label of the syncs finished without error.
+	syncResultSuccess = "success"
+	// syncResultError is the result label of the syncs failed with an error.
+	syncResultError = "error"
+)
+
+var (
+	// syncDuration observes how long it takes to sync a Greeter, partitioned
+	// by the result of the sync.
+	syncDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "sync_duration_seconds",
+		Help:      "Time taken to sync a Greeter, partitioned by result.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 2, 15),
+	}, []string{"result"})
+
+	// podCreateFailures counts the runner pods failed to create, partitioned
+	// by the reason returned from the API server.
+	podCreateFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "pod_create_failures_total",
+		Help:      "Total number of runner pods failed to create, partitioned by reason.",
+	}, []string{"reason"})
+
+	// launchesThrottled counts the launches of runs throttled by the limits
+	// of the controller, partitioned by the reason of the throttle.
+	launchesThrottled = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "launches_throttled_total",
+		Help:      "Total number of launches of runs throttled, partitioned by reason.",
+	}, []string{"reason"})
+
+	// greetersDesc describes the number of Greeters in each phase.
+	greetersDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "", "greeters"),
+		"Number of Greeters, partitioned by phase.",
+		[]string{"phase"}, nil,
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(syncDuration, podCreateFailures, launchesThrottled)
+}
+
+// recordPodCreateFailure counts a failure of creating the runner pod.
+func recordPodCreateFailure(err error) {
+	reason := string(errors.ReasonForError(err))
+	if len(reason) == 0 {
+		reason = "Unknown"
+	}
+	podCreateFailures.WithLabelValues(reason).Inc()
+}
+
+// phaseCollector collects the number of Greeters per phase from the informer
+// cache at scrape time, so that it never drifts from the cached state.
+type phaseCollector struct {
+	lister  greeterlisters.GreeterLister
+	synced  func() bool
+	inScope func(any) bool
+}
+
+// Describe implements the prometheus.Collector interface.
+func (pc *phaseCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- greetersDesc
+}
+
+// Collect implements the prometheus.Collector interface.
+func (pc *phaseCollector) Collect(ch chan<- prometheus.Metric) {
+	// Nothing is reported until the cache is synced, e.g. on a standby replica
+	if !pc.synced() {
+		return
+	}
+
+	greeters, err := pc.lister.List(labels.Everything())
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+
+	counts := map[string]int{
+		v1alpha1.PhasePending:   0,
+		v1alpha1.PhaseRunning:   0,
+		v1alpha1.PhaseSucceeded: 0,
+		v1alpha1.PhaseFailed:    0,
+	}
+	for _, greeter := range greeters {
+		if !pc.inScope(greeter) {
+			continue
+		}
+
+		phase := greeter.Status.Phase
+		// A Greeter never synced yet is waiting for its schedule
+		if len(phase) == 0 {
+			phase = v1alpha1.PhasePending
+		}
+		counts[phase]++
+	}
+
+	for phase, count := range counts {
+		ch <- prometheus.MustNewConstMetric(greetersDesc, prometheus.GaugeValue, float64(count), phase)
+	}
+}
+
+// Collector returns a prometheus.Collector reporting the number of Greeters
+// per phase, it should be registered once for each Controller.
+func (c *Controller) Collector() prometheus.Collector {
+	return &phaseCollector{lister: c.greeterLister, synced: c.greeterSynced, inScope: c.inScope}
+}
+
+// ReadyzCheck is a healthz.Checker which reports an error until the informer
+// caches of both Pods and Greeters are synced.
+func (c *Controller) ReadyzCheck(_ *http.Request) error {
+	if !c.podSynced() {
+		return fmt.Errorf("pod informer not synced")
+	}
+	if !c.greeterSynced() {
+		return fmt.Errorf("greeter informer not synced")
+	}
+	return nil
+}
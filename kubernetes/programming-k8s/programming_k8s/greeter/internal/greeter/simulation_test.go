@@ -0,0 +1,572 @@
+package greeter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	kubeinformers "k8s.io/client-go/informers"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"github.com/wjiec/programming_k8s/greeter/pkg/apis/greeter/v1alpha1"
+	greeterfake "github.com/wjiec/programming_k8s/greeter/pkg/generated/clientset/versioned/fake"
+	greeterinformers "github.com/wjiec/programming_k8s/greeter/pkg/generated/informers/externalversions"
+	greeterv1alpha1informers "github.com/wjiec/programming_k8s/greeter/pkg/generated/informers/externalversions/greeter/v1alpha1"
+)
+
+const (
+	// simulationNamespace is the namespace of the simulated Greeters.
+	simulationNamespace = "default"
+	// simulationErrorDelay is the delay before a failed sync is retried.
+	simulationErrorDelay = time.Second
+	// simulationMaxSteps bounds the steps of a simulation, so that a livelock
+	// of the controller fails the test rather than hanging it.
+	simulationMaxSteps = 10000
+)
+
+var (
+	podsResource     = corev1.SchemeGroupVersion.WithResource("pods")
+	greetersResource = v1alpha1.SchemeGroupVersion.WithResource("greeters")
+)
+
+// podLifecycle is the scripted lifecycle of a runner pod.
+type podLifecycle struct {
+	// startAfter is the delay from the creation of the pod until its
+	// container is running.
+	startAfter time.Duration
+	// runFor is how long the container runs before it exits, the container
+	// never exits if it's negative.
+	runFor time.Duration
+	// exitCode is the exit code of the container.
+	exitCode int32
+}
+
+// launchRecord is a runner pod created by the controller.
+type launchRecord struct {
+	name string
+	at   time.Time
+}
+
+// action is an action scheduled at a virtual time.
+type action struct {
+	at  time.Time
+	seq int
+	fn  func()
+}
+
+// simulation runs the controller against the fake clientsets with a virtual
+// clock. The informers are never started, instead the caches are refreshed
+// from the fake clientsets and the event handlers are invoked after each step,
+// so the whole lifecycle of Greeters is replayed deterministically.
+type simulation struct {
+	t   *testing.T
+	ctx context.Context
+
+	clock            *clocktesting.FakeClock
+	kubeClientset    *kubefake.Clientset
+	greeterClientset *greeterfake.Clientset
+	podIndexer       cache.Indexer
+	greeterIndexer   cache.Indexer
+	recorder         *record.FakeRecorder
+	controller       *Controller
+
+	// podScript returns the lifecycle of the runner pod, the pod is started
+	// after a second and succeeds after another two seconds by default.
+	podScript func(pod *corev1.Pod) podLifecycle
+
+	// due are the keys of the Greeters to be synced by the time they're due.
+	due map[string]time.Time
+	// actions are the scripted actions in no particular order.
+	actions []*action
+	seq     int
+
+	// pods and greeters are the objects last observed in the caches by keys.
+	pods     map[string]*corev1.Pod
+	greeters map[string]*v1alpha1.Greeter
+	version  int
+	uid      int
+
+	// launches are the runner pods created by the controller in order.
+	launches []launchRecord
+	// events are the Events recorded by the controller in order.
+	events []string
+}
+
+// newSimulation returns a simulation starting at the time, the controller is
+// configured with the options.
+func newSimulation(t *testing.T, start time.Time, options ...Option) *simulation {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	s := &simulation{
+		t:                t,
+		ctx:              ctx,
+		clock:            clocktesting.NewFakeClock(start),
+		kubeClientset:    kubefake.NewSimpleClientset(),
+		greeterClientset: greeterfake.NewSimpleClientset(),
+		recorder:         record.NewFakeRecorder(1000),
+		podScript: func(*corev1.Pod) podLifecycle {
+			return podLifecycle{startAfter: time.Second, runFor: 2 * time.Second}
+		},
+		due:      map[string]time.Time{},
+		pods:     map[string]*corev1.Pod{},
+		greeters: map[string]*v1alpha1.Greeter{},
+	}
+	s.kubeClientset.PrependReactor("patch", "pods", s.applyPod)
+	s.greeterClientset.PrependReactor("patch", "greeters", s.applyGreeterStatus)
+
+	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(s.kubeClientset, 0)
+	greeterInformerFactory := greeterinformers.NewSharedInformerFactory(s.greeterClientset, 0)
+	podInformer := kubeInformerFactory.Core().V1().Pods()
+	greeterInformer := greeterInformerFactory.Greeter().V1alpha1().Greeters()
+	s.podIndexer = podInformer.Informer().GetIndexer()
+	s.greeterIndexer = greeterInformer.Informer().GetIndexer()
+
+	options = append([]Option{WithClock(s.clock), WithEventRecorder(s.recorder)}, options...)
+	s.controller = NewController(ctx, s.kubeClientset, s.greeterClientset,
+		[]coreinformers.PodInformer{podInformer}, []greeterv1alpha1informers.GreeterInformer{greeterInformer}, options...)
+	t.Cleanup(s.controller.workQueue.ShutDown)
+
+	return s
+}
+
+// now returns the current virtual time.
+func (s *simulation) now() time.Time {
+	return s.clock.Now()
+}
+
+// at schedules the action at the time.
+func (s *simulation) at(at time.Time, fn func()) {
+	s.seq++
+	s.actions = append(s.actions, &action{at: at, seq: s.seq, fn: fn})
+}
+
+// after schedules the action after the duration from now.
+func (s *simulation) after(d time.Duration, fn func()) {
+	s.at(s.now().Add(d), fn)
+}
+
+// createGreeter creates the Greeter in the simulation namespace now.
+func (s *simulation) createGreeter(greeter *v1alpha1.Greeter) {
+	greeter = greeter.DeepCopy()
+	greeter.Namespace = simulationNamespace
+	greeter.UID = s.newUID()
+	greeter.Generation = 1
+	greeter.CreationTimestamp = metav1.NewTime(s.now()).Rfc3339Copy()
+
+	_, err := s.greeterClientset.GreeterV1alpha1().Greeters(simulationNamespace).Create(s.ctx, greeter, metav1.CreateOptions{})
+	if err != nil {
+		s.t.Fatalf("create greeter %q: %v", greeter.Name, err)
+	}
+	s.observe()
+}
+
+// patchGreeter applies the merge patch to the Greeter now.
+func (s *simulation) patchGreeter(name string, patch string) {
+	_, err := s.greeterClientset.GreeterV1alpha1().Greeters(simulationNamespace).Patch(s.ctx, name, types.MergePatchType, []byte(patch), metav1.PatchOptions{})
+	if err != nil {
+		s.t.Fatalf("patch greeter %q: %v", name, err)
+	}
+	s.observe()
+}
+
+// greeter returns the Greeter with the name from the fake clientset.
+func (s *simulation) greeter(name string) *v1alpha1.Greeter {
+	greeter, err := s.greeterClientset.GreeterV1alpha1().Greeters(simulationNamespace).Get(s.ctx, name, metav1.GetOptions{})
+	if err != nil {
+		s.t.Fatalf("get greeter %q: %v", name, err)
+	}
+	return greeter
+}
+
+// podNames returns the sorted names of the existing pods.
+func (s *simulation) podNames() []string {
+	pods, err := s.kubeClientset.CoreV1().Pods(simulationNamespace).List(s.ctx, metav1.ListOptions{})
+	if err != nil {
+		s.t.Fatalf("list pods: %v", err)
+	}
+
+	names := make([]string, 0, len(pods.Items))
+	for _, pod := range pods.Items {
+		names = append(names, pod.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// hasEvent returns true if an Event containing the text is recorded.
+func (s *simulation) hasEvent(text string) bool {
+	for _, event := range s.events {
+		if strings.Contains(event, text) {
+			return true
+		}
+	}
+	return false
+}
+
+// runUntil runs the scripted actions and the syncs of the controller in the
+// order of their virtual times, and stops the clock at the end time.
+func (s *simulation) runUntil(end time.Time) {
+	for step := 0; ; step++ {
+		if step > simulationMaxSteps {
+			s.t.Fatalf("simulation didn't settle after %d steps at %s", simulationMaxSteps, s.now())
+		}
+
+		next, ok := s.next()
+		if !ok || next.After(end) {
+			if end.After(s.now()) {
+				s.clock.SetTime(end)
+			}
+			return
+		}
+		if next.After(s.now()) {
+			s.clock.SetTime(next)
+		}
+
+		// The actions happen before the syncs due at the same time
+		if a := s.popAction(); a != nil {
+			a.fn()
+			s.observe()
+			continue
+		}
+		s.sync(s.popDue())
+	}
+}
+
+// next returns the time of the earliest action or sync.
+func (s *simulation) next() (time.Time, bool) {
+	next, ok := time.Time{}, false
+	for _, a := range s.actions {
+		if !ok || a.at.Before(next) {
+			next, ok = a.at, true
+		}
+	}
+	for _, due := range s.due {
+		if !ok || due.Before(next) {
+			next, ok = due, true
+		}
+	}
+	return next, ok
+}
+
+// popAction removes and returns the earliest action due now, if any.
+func (s *simulation) popAction() *action {
+	index := -1
+	for i, a := range s.actions {
+		if a.at.After(s.now()) {
+			continue
+		}
+		if index < 0 || a.at.Before(s.actions[index].at) ||
+			(a.at.Equal(s.actions[index].at) && a.seq < s.actions[index].seq) {
+			index = i
+		}
+	}
+	if index < 0 {
+		return nil
+	}
+
+	a := s.actions[index]
+	s.actions = append(s.actions[:index], s.actions[index+1:]...)
+	return a
+}
+
+// popDue removes and returns the key of the earliest sync due now, the keys
+// due at the same time are synced in their lexical order.
+func (s *simulation) popDue() string {
+	found := ""
+	for key, due := range s.due {
+		if due.After(s.now()) {
+			continue
+		}
+		if len(found) == 0 || due.Before(s.due[found]) || (due.Equal(s.due[found]) && key < found) {
+			found = key
+		}
+	}
+	delete(s.due, found)
+	return found
+}
+
+// enqueue schedules the sync of the key at the time, unless it's due earlier.
+func (s *simulation) enqueue(key string, at time.Time) {
+	if due, ok := s.due[key]; !ok || at.Before(due) {
+		s.due[key] = at
+	}
+}
+
+// sync runs the sync handler of the controller for the key the same way as
+// the workers, except the requeues are scheduled in the virtual time.
+func (s *simulation) sync(key string) {
+	when, err := s.controller.syncHandler(s.ctx, key)
+	if err != nil {
+		s.t.Logf("%s: error syncing %q: %v", s.now().Format(time.RFC3339), key, err)
+		s.enqueue(key, s.now().Add(simulationErrorDelay))
+	} else if when != 0 {
+		s.enqueue(key, s.now().Add(when))
+	}
+	s.observe()
+}
+
+// observe refreshes the caches from the fake clientsets and invokes the event
+// handlers of the controller for the changed objects, the same way as the
+// informers. The garbage collector is also emulated for the orphaned pods.
+func (s *simulation) observe() {
+	s.observeGreeters()
+	s.collectGarbage()
+	s.observePods()
+
+	// The keys enqueued by the event handlers are due now
+	for s.controller.workQueue.Len() > 0 {
+		item, _ := s.controller.workQueue.Get()
+		s.controller.workQueue.Done(item)
+		s.enqueue(item.(string), s.now())
+	}
+
+	for {
+		select {
+		case event := <-s.recorder.Events:
+			s.events = append(s.events, event)
+		default:
+			return
+		}
+	}
+}
+
+// observeGreeters refreshes the cache of the Greeters.
+func (s *simulation) observeGreeters() {
+	list, err := s.greeterClientset.GreeterV1alpha1().Greeters(metav1.NamespaceAll).List(s.ctx, metav1.ListOptions{})
+	if err != nil {
+		s.t.Fatalf("list greeters: %v", err)
+	}
+
+	observed, objects := map[string]*v1alpha1.Greeter{}, []any{}
+	for i := range list.Items {
+		greeter := list.Items[i].DeepCopy()
+		key := greeter.Namespace + "/" + greeter.Name
+		observed[key] = greeter
+		objects = append(objects, greeter)
+
+		// The resource version is changed once the object is changed
+		if old, ok := s.greeters[key]; ok {
+			greeter.ResourceVersion = old.ResourceVersion
+			if !apiequality.Semantic.DeepEqual(old, greeter) {
+				greeter.ResourceVersion = s.newResourceVersion()
+			}
+		} else {
+			greeter.ResourceVersion = s.newResourceVersion()
+		}
+	}
+	if err = s.greeterIndexer.Replace(objects, ""); err != nil {
+		s.t.Fatalf("replace greeters: %v", err)
+	}
+
+	c := s.controller
+	for key, greeter := range observed {
+		if !c.inScope(greeter) {
+			continue
+		}
+		if old, ok := s.greeters[key]; !ok {
+			c.enqueueGreeter(greeter)
+		} else if old.ResourceVersion != greeter.ResourceVersion {
+			c.updateGreeter(old, greeter)
+		}
+	}
+	for key, old := range s.greeters {
+		if _, ok := observed[key]; !ok && c.inScope(old) {
+			c.deleteGreeter(old)
+		}
+	}
+	s.greeters = observed
+}
+
+// observePods refreshes the cache of the runner pods.
+func (s *simulation) observePods() {
+	options := metav1.ListOptions{}
+	TweakPodListOptions(&options)
+	list, err := s.kubeClientset.CoreV1().Pods(metav1.NamespaceAll).List(s.ctx, options)
+	if err != nil {
+		s.t.Fatalf("list pods: %v", err)
+	}
+
+	observed, objects := map[string]*corev1.Pod{}, []any{}
+	for i := range list.Items {
+		pod := list.Items[i].DeepCopy()
+		key := pod.Namespace + "/" + pod.Name
+		observed[key] = pod
+		objects = append(objects, pod)
+
+		if old, ok := s.pods[key]; ok {
+			pod.ResourceVersion = old.ResourceVersion
+			if !apiequality.Semantic.DeepEqual(old, pod) {
+				pod.ResourceVersion = s.newResourceVersion()
+			}
+		} else {
+			pod.ResourceVersion = s.newResourceVersion()
+		}
+	}
+	if err = s.podIndexer.Replace(objects, ""); err != nil {
+		s.t.Fatalf("replace pods: %v", err)
+	}
+
+	c := s.controller
+	for key, pod := range observed {
+		if old, ok := s.pods[key]; (!ok || old.ResourceVersion != pod.ResourceVersion) && c.inScope(pod) {
+			c.enqueuePod(pod)
+		}
+	}
+	for key, old := range s.pods {
+		if _, ok := observed[key]; !ok && c.inScope(old) {
+			c.enqueuePod(old)
+		}
+	}
+	s.pods = observed
+}
+
+// collectGarbage deletes the pods whose controller is deleted.
+func (s *simulation) collectGarbage() {
+	for _, pod := range s.pods {
+		owner := metav1.GetControllerOf(pod)
+		if owner == nil || s.greeters[pod.Namespace+"/"+owner.Name] != nil {
+			continue
+		}
+
+		err := s.kubeClientset.CoreV1().Pods(pod.Namespace).Delete(s.ctx, pod.Name, metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			s.t.Fatalf("delete orphaned pod %q: %v", pod.Name, err)
+		}
+	}
+}
+
+// applyPod creates the applied pod if not exists, and schedules its lifecycle.
+// The existing pod is left untouched, as the controller never changes its pods.
+func (s *simulation) applyPod(action k8stesting.Action) (bool, runtime.Object, error) {
+	patch := action.(k8stesting.PatchAction)
+	if patch.GetPatchType() != types.ApplyPatchType {
+		return false, nil, nil
+	}
+
+	tracker := s.kubeClientset.Tracker()
+	if found, err := tracker.Get(podsResource, patch.GetNamespace(), patch.GetName()); err == nil {
+		return true, found, nil
+	} else if !errors.IsNotFound(err) {
+		return true, nil, err
+	}
+
+	pod := &corev1.Pod{}
+	if err := json.Unmarshal(patch.GetPatch(), pod); err != nil {
+		return true, nil, err
+	}
+	pod.UID = s.newUID()
+	pod.CreationTimestamp = metav1.NewTime(s.now()).Rfc3339Copy()
+	pod.Status.Phase = corev1.PodPending
+	if err := tracker.Create(podsResource, pod, pod.Namespace); err != nil {
+		return true, nil, err
+	}
+
+	s.launches = append(s.launches, launchRecord{name: pod.Name, at: s.now()})
+	s.schedulePod(pod)
+	return true, pod, nil
+}
+
+// applyGreeterStatus replaces the status of the Greeter with the applied one,
+// the controller is the only manager of the status.
+func (s *simulation) applyGreeterStatus(action k8stesting.Action) (bool, runtime.Object, error) {
+	patch := action.(k8stesting.PatchAction)
+	if patch.GetPatchType() != types.ApplyPatchType || patch.GetSubresource() != "status" {
+		return false, nil, nil
+	}
+
+	applied := &v1alpha1.Greeter{}
+	if err := json.Unmarshal(patch.GetPatch(), applied); err != nil {
+		return true, nil, err
+	}
+
+	tracker := s.greeterClientset.Tracker()
+	found, err := tracker.Get(greetersResource, patch.GetNamespace(), patch.GetName())
+	if err != nil {
+		return true, nil, err
+	}
+	greeter := found.(*v1alpha1.Greeter)
+	greeter.Status = applied.Status
+	if err = tracker.Update(greetersResource, greeter, greeter.Namespace); err != nil {
+		return true, nil, err
+	}
+	return true, greeter, nil
+}
+
+// schedulePod schedules the scripted lifecycle of the created pod.
+func (s *simulation) schedulePod(pod *corev1.Pod) {
+	lifecycle := s.podScript(pod)
+	container := pod.Spec.Containers[0].Name
+
+	s.after(lifecycle.startAfter, func() {
+		s.updatePod(pod, func(pod *corev1.Pod) {
+			startedAt := metav1.NewTime(s.now())
+			pod.Status.Phase = corev1.PodRunning
+			pod.Status.StartTime = &startedAt
+			pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+				Name:  container,
+				State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: startedAt}},
+			}}
+		})
+	})
+	if lifecycle.runFor < 0 {
+		return
+	}
+
+	s.after(lifecycle.startAfter+lifecycle.runFor, func() {
+		s.updatePod(pod, func(pod *corev1.Pod) {
+			pod.Status.Phase = corev1.PodSucceeded
+			if lifecycle.exitCode != 0 {
+				pod.Status.Phase = corev1.PodFailed
+			}
+			pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+				Name: container,
+				State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+					ExitCode:   lifecycle.exitCode,
+					FinishedAt: metav1.NewTime(s.now()),
+				}},
+			}}
+		})
+	})
+}
+
+// updatePod updates the status of the pod, unless the pod is deleted or
+// replaced by another pod of the same name in the meantime.
+func (s *simulation) updatePod(pod *corev1.Pod, update func(pod *corev1.Pod)) {
+	found, err := s.kubeClientset.CoreV1().Pods(pod.Namespace).Get(s.ctx, pod.Name, metav1.GetOptions{})
+	if errors.IsNotFound(err) || (err == nil && found.UID != pod.UID) {
+		return
+	} else if err != nil {
+		s.t.Fatalf("get pod %q: %v", pod.Name, err)
+	}
+
+	update(found)
+	if _, err = s.kubeClientset.CoreV1().Pods(pod.Namespace).UpdateStatus(s.ctx, found, metav1.UpdateOptions{}); err != nil {
+		s.t.Fatalf("update pod %q: %v", pod.Name, err)
+	}
+}
+
+func (s *simulation) newResourceVersion() string {
+	s.version++
+	return fmt.Sprint(s.version)
+}
+
+func (s *simulation) newUID() types.UID {
+	s.uid++
+	return types.UID(fmt.Sprintf("uid-%d", s.uid))
+}
@@ -0,0 +1,90 @@
+package greeter
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/wjiec/programming_k8s/greeter/pkg/apis/greeter/v1alpha1"
+)
+
+// Sink delivers the greetings of the runs of Greeters.
+//
+// The run is identified by the Status.PodName of the Greeter, and Deliver is
+// called on each sync of the running Greeter until the outcome is finished,
+// so a sink is able to deliver asynchronously (e.g. by a pod) as well. A sink
+// may record its own observations in the status of the Greeter, which is
+// persisted by the controller.
+type Sink interface {
+	// Deliver starts the delivery of the current run, or observes it if it
+	// has been started already. An error is retried with rate limiting.
+	Deliver(ctx context.Context, greeter *v1alpha1.Greeter) (*Outcome, error)
+
+	// Reset stops the delivery of the current attempt and releases all its
+	// resources, before it's retried or once the run is stopped.
+	Reset(ctx context.Context, greeter *v1alpha1.Greeter) error
+}
+
+// Outcome is the observed result of a delivery.
+type Outcome struct {
+	// Finished is true once the delivery is finished, either succeeded or failed.
+	Finished bool
+	// Succeeded is true if the finished delivery succeeded.
+	Succeeded bool
+	// Permanent is true if the failed delivery must not be retried.
+	Permanent bool
+
+	// Reason and Message describe the finished delivery.
+	Reason  string
+	Message string
+
+	// CompletionTime is when the delivery was finished, it's the time of
+	// the observation if not set.
+	CompletionTime *metav1.Time
+}
+
+// These are the names of the sinks the greetings can be delivered to.
+const (
+	sinkPod       = "Pod"
+	sinkEvent     = "Event"
+	sinkConfigMap = "ConfigMap"
+	sinkHTTP      = "HTTP"
+)
+
+// isDeliveryAllowed returns true if the object is labeled to be used by the
+// delivery of the Greeters in its namespace.
+func isDeliveryAllowed(object metav1.Object) bool {
+	return object.GetLabels()[v1alpha1.LabelDelivery] == v1alpha1.LabelDeliveryAllowed
+}
+
+// errDeliveryNotAllowed returns the error of the object which is not labeled
+// to be used by the delivery.
+func errDeliveryNotAllowed(kind, name string) error {
+	return fmt.Errorf("%s %q is not labeled %s=%s", kind, name, v1alpha1.LabelDelivery, v1alpha1.LabelDeliveryAllowed)
+}
+
+// getSinkName returns the name of the sink the Greeter is delivered to, the
+// delivery is validated in advance so at most one sink is set.
+func getSinkName(greeter *v1alpha1.Greeter) string {
+	switch delivery := greeter.Spec.Delivery; {
+	case delivery.Event != nil:
+		return sinkEvent
+	case delivery.ConfigMap != nil:
+		return sinkConfigMap
+	case delivery.HTTP != nil:
+		return sinkHTTP
+	default:
+		return sinkPod
+	}
+}
+
+// newSinks returns all the sinks supported by the Controller by their names.
+func newSinks(c *Controller) map[string]Sink {
+	return map[string]Sink{
+		sinkPod:       &podSink{c: c},
+		sinkEvent:     &eventSink{recorder: c.recorder},
+		sinkConfigMap: &configMapSink{kubeClientset: c.kubeClientset},
+		sinkHTTP:      &httpSink{kubeClientset: c.kubeClientset, client: newHTTPClient(), maxOutputBytes: c.maxOutputBytes},
+	}
+}
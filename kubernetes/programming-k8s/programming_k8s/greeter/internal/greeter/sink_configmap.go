@@ -0,0 +1,73 @@
+package greeter
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/wjiec/programming_k8s/greeter/pkg/apis/greeter/v1alpha1"
+)
+
+// configMapSink delivers the greeting into a key of a ConfigMap.
+type configMapSink struct {
+	kubeClientset kubernetes.Interface
+}
+
+// Deliver writes the message into the key of the ConfigMap, the ConfigMap is
+// created with the delivery label if it doesn't exist. The other keys of the
+// ConfigMap are kept, and the existing ConfigMap without the label is never
+// overwritten.
+func (s *configMapSink) Deliver(ctx context.Context, greeter *v1alpha1.Greeter) (*Outcome, error) {
+	delivery := greeter.Spec.Delivery.ConfigMap
+	configMaps := s.kubeClientset.CoreV1().ConfigMaps(greeter.Namespace)
+
+	configMap, err := configMaps.Get(ctx, delivery.Name, metav1.GetOptions{})
+	switch {
+	case errors.IsNotFound(err):
+		configMap = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      delivery.Name,
+				Namespace: greeter.Namespace,
+				Labels:    map[string]string{v1alpha1.LabelDelivery: v1alpha1.LabelDeliveryAllowed},
+			},
+			Data: map[string]string{delivery.Key: greeter.Spec.Message},
+		}
+		_, err = configMaps.Create(ctx, configMap, metav1.CreateOptions{})
+	case err == nil && !isDeliveryAllowed(configMap):
+		err = errors.NewForbidden(corev1.Resource("configmaps"), delivery.Name, errDeliveryNotAllowed("configmap", delivery.Name))
+	case err == nil:
+		if configMap.Data == nil {
+			configMap.Data = map[string]string{}
+		}
+		configMap.Data[delivery.Key] = greeter.Spec.Message
+		_, err = configMaps.Update(ctx, configMap, metav1.UpdateOptions{})
+	}
+
+	// The ConfigMap can't be written as is (e.g. it's immutable), which is a
+	// failed attempt. Other errors (e.g. conflicts) are retried with rate limiting.
+	if errors.IsForbidden(err) || errors.IsInvalid(err) {
+		return &Outcome{
+			Finished: true,
+			Reason:   v1alpha1.ReasonDeliveryFailed,
+			Message:  fmt.Sprintf(MessageDeliveryFailed, greeter.Status.PodName, err),
+		}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &Outcome{
+		Finished:  true,
+		Succeeded: true,
+		Reason:    v1alpha1.ReasonDelivered,
+		Message:   fmt.Sprintf(MessageConfigMapDelivered, greeter.Status.PodName, delivery.Key, delivery.Name),
+	}, nil
+}
+
+// Reset does nothing as the key is overwritten by the next attempt.
+func (s *configMapSink) Reset(context.Context, *v1alpha1.Greeter) error {
+	return nil
+}
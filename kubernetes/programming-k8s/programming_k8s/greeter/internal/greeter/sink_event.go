@@ -0,0 +1,53 @@
+package greeter
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/wjiec/programming_k8s/greeter/pkg/apis/greeter/v1alpha1"
+)
+
+// defaultEventReason is the reason of the delivered Events without an explicit reason.
+const defaultEventReason = "Greeting"
+
+// eventSink delivers the greeting as an Event on the referenced object.
+type eventSink struct {
+	recorder record.EventRecorder
+}
+
+// Deliver records the message as an Event on the referenced object, the
+// referenced object is not required to exist.
+func (s *eventSink) Deliver(_ context.Context, greeter *v1alpha1.Greeter) (*Outcome, error) {
+	delivery := greeter.Spec.Delivery.Event
+
+	eventType := delivery.Type
+	if len(eventType) == 0 {
+		eventType = corev1.EventTypeNormal
+	}
+	reason := delivery.Reason
+	if len(reason) == 0 {
+		reason = defaultEventReason
+	}
+
+	s.recorder.Event(&corev1.ObjectReference{
+		APIVersion: delivery.APIVersion,
+		Kind:       delivery.Kind,
+		Namespace:  greeter.Namespace,
+		Name:       delivery.Name,
+	}, eventType, reason, greeter.Spec.Message)
+
+	return &Outcome{
+		Finished:  true,
+		Succeeded: true,
+		Reason:    v1alpha1.ReasonDelivered,
+		Message:   fmt.Sprintf(MessageEventDelivered, greeter.Status.PodName, delivery.Kind, delivery.Name),
+	}, nil
+}
+
+// Reset does nothing as the recorded Event can't be withdrawn.
+func (s *eventSink) Reset(context.Context, *v1alpha1.Greeter) error {
+	return nil
+}
@@ -0,0 +1,155 @@
+package greeter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/wjiec/programming_k8s/greeter/pkg/apis/greeter/v1alpha1"
+)
+
+const (
+	// httpDeliveryTimeout is the maximum duration of a request delivering a greeting
+	httpDeliveryTimeout = 10 * time.Second
+	// runHeader is the header with the name of the run, so that the receiver
+	// is able to drop the greeting delivered more than once.
+	runHeader = "X-Greeter-Run"
+)
+
+// httpGreeting is the body of the request delivering a greeting.
+type httpGreeting struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Run       string `json:"run"`
+	Message   string `json:"message"`
+}
+
+// httpSink delivers the greeting by an HTTP POST request.
+type httpSink struct {
+	kubeClientset kubernetes.Interface
+	client        *http.Client
+
+	// maxOutputBytes is the maximum size in bytes of the response body
+	// captured into the status, zero means it's not captured.
+	maxOutputBytes int64
+}
+
+// newHTTPClient returns the client used to deliver greetings, the redirects
+// are not followed as they may lead out of the cluster.
+func newHTTPClient() *http.Client {
+	return &http.Client{
+		Timeout: httpDeliveryTimeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// Deliver posts the greeting to the URL, any response other than 2xx is a
+// failed attempt. The bounded response body is captured as the output.
+func (s *httpSink) Deliver(ctx context.Context, greeter *v1alpha1.Greeter) (*Outcome, error) {
+	delivery, run := greeter.Spec.Delivery.HTTP, greeter.Status.PodName
+	failed := func(err error) *Outcome {
+		return &Outcome{
+			Finished: true,
+			Reason:   v1alpha1.ReasonDeliveryFailed,
+			Message:  fmt.Sprintf(MessageDeliveryFailed, run, err),
+		}
+	}
+
+	body, err := json.Marshal(&httpGreeting{
+		Namespace: greeter.Namespace,
+		Name:      greeter.Name,
+		Run:       run,
+		Message:   greeter.Spec.Message,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(body))
+	if err != nil {
+		return failed(err), nil
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(runHeader, run)
+
+	if header := delivery.SecretHeader; header != nil {
+		selector := &header.SecretKeyRef
+		value, found, err := s.getSecretValue(ctx, greeter.Namespace, selector)
+		if errors.IsForbidden(err) {
+			return failed(err), nil
+		}
+		if err != nil && !errors.IsNotFound(err) {
+			return nil, err
+		}
+
+		// The header is omitted if the optional Secret or key doesn't exist
+		if found {
+			req.Header.Set(header.Name, value)
+		} else if selector.Optional == nil || !*selector.Optional {
+			if err == nil {
+				err = fmt.Errorf("key %q not found in secret %q", selector.Key, selector.Name)
+			}
+			return failed(err), nil
+		}
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		// The sync is cancelled, e.g. the Greeter is deleted
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return failed(err), nil
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if s.maxOutputBytes > 0 {
+		// The limit may split a multibyte character, which can't be stored in the status.
+		output, _ := io.ReadAll(io.LimitReader(resp.Body, s.maxOutputBytes))
+		greeter.Status.Output = strings.ToValidUTF8(string(output), "")
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return failed(fmt.Errorf("unexpected status %q", resp.Status)), nil
+	}
+	return &Outcome{
+		Finished:  true,
+		Succeeded: true,
+		Reason:    v1alpha1.ReasonDelivered,
+		Message:   fmt.Sprintf(MessageHTTPDelivered, run, delivery.URL, resp.StatusCode),
+	}, nil
+}
+
+// Reset does nothing as the request is already finished.
+func (s *httpSink) Reset(context.Context, *v1alpha1.Greeter) error {
+	return nil
+}
+
+// getSecretValue returns the value of the key in the Secret, and whether the
+// key exists. The Secret is read from the API so that no Secrets are cached
+// by the controller. The Secret which is not labeled to be used by the
+// delivery is forbidden.
+func (s *httpSink) getSecretValue(ctx context.Context, namespace string, selector *corev1.SecretKeySelector) (string, bool, error) {
+	secret, err := s.kubeClientset.CoreV1().Secrets(namespace).Get(ctx, selector.Name, metav1.GetOptions{})
+	if err != nil {
+		return "", false, err
+	}
+	if !isDeliveryAllowed(secret) {
+		return "", false, errors.NewForbidden(corev1.Resource("secrets"), selector.Name, errDeliveryNotAllowed("secret", selector.Name))
+	}
+
+	value, found := secret.Data[selector.Key]
+	return string(value), found, nil
+}
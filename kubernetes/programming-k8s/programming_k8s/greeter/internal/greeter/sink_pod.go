@@ -0,0 +1,223 @@
+package greeter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/wjiec/programming_k8s/greeter/pkg/apis/greeter/v1alpha1"
+)
+
+// podSink delivers the greeting by a runner pod which prints the message, or
+// runs the template of the Greeter with the message in its environment.
+type podSink struct {
+	c *Controller
+}
+
+// Deliver creates the runner pod of the current run if not exists, and observes
+// its result once the pod is terminated.
+func (s *podSink) Deliver(ctx context.Context, greeter *v1alpha1.Greeter) (*Outcome, error) {
+	c, logger := s.c, klog.FromContext(ctx)
+	podForGreeter := newPodForGreeter(greeter)
+
+	// Set Greeter instance as the owner and controller
+	owner := metav1.NewControllerRef(greeter, v1alpha1.SchemeGroupVersion.WithKind("Greeter"))
+	podForGreeter.OwnerReferences = append(podForGreeter.OwnerReferences, *owner)
+
+	// Try to see if the pod already exists and if not
+	// (which we expect) then create a one-shot pod as per spec
+	found, err := c.podLister.Pods(podForGreeter.Namespace).Get(podForGreeter.Name)
+	if err != nil && errors.IsNotFound(err) {
+		// The pod was created before, check with the server whether it
+		// is actually deleted or just not in our cache yet.
+		if meta.IsStatusConditionTrue(greeter.Status.Conditions, v1alpha1.ConditionPodCreated) {
+			_, err = c.kubeClientset.CoreV1().Pods(podForGreeter.Namespace).Get(ctx, podForGreeter.Name, metav1.GetOptions{})
+			if err == nil {
+				// Wait for the pod to show up in our cache
+				return nil, fmt.Errorf("pod %q not found in cache", podForGreeter.Name)
+			} else if !errors.IsNotFound(err) {
+				return nil, err
+			}
+
+			msg := fmt.Sprintf(MessagePodDeleted, podForGreeter.Name)
+			c.recorder.Event(greeter, corev1.EventTypeWarning, PodDeleted, msg)
+			if greeter.Spec.PodDeletionPolicy == v1alpha1.PodDeletionPolicyFail {
+				return &Outcome{Finished: true, Permanent: true, Reason: v1alpha1.ReasonPodDeleted, Message: msg}, nil
+			}
+		}
+
+		// The pod is created by applying it, so that the fields set by the
+		// controller are owned by its field manager.
+		applyPod, err := newPodApplyConfiguration(podForGreeter)
+		if err != nil {
+			return nil, err
+		}
+		found, err = c.kubeClientset.CoreV1().Pods(podForGreeter.Namespace).Apply(ctx, applyPod, podApplyOptions)
+		if err != nil {
+			recordPodCreateFailure(err)
+			return nil, err
+		}
+		logger.Info("pod launched", "podName", podForGreeter.Name)
+	} else if err != nil {
+		// requeue with error
+		return nil, err
+	}
+
+	// If the Pod is not controlled by this Greeter resource, we should log
+	// a warning to the event recorder and return error msg.
+	if !metav1.IsControlledBy(found, greeter) {
+		msg := fmt.Sprintf(MessageResourceExists, podForGreeter.Name)
+		c.recorder.Event(greeter, corev1.EventTypeWarning, ErrResourceExists, msg)
+		return nil, fmt.Errorf("%s", msg)
+	}
+
+	if greeter.Status.StartTime == nil {
+		greeter.Status.StartTime = found.CreationTimestamp.DeepCopy()
+	}
+	setCondition(greeter, v1alpha1.ConditionPodCreated, metav1.ConditionTrue, v1alpha1.ReasonPodCreated,
+		fmt.Sprintf("Created pod %q", found.Name))
+
+	if found.Status.Phase != corev1.PodFailed && found.Status.Phase != corev1.PodSucceeded {
+		return &Outcome{}, nil
+	}
+	logger.Info("container terminated", "reason", found.Status.Phase)
+
+	outcome := &Outcome{
+		Finished:  true,
+		Succeeded: found.Status.Phase == corev1.PodSucceeded,
+		Reason:    v1alpha1.ReasonPodFailed,
+		Message:   fmt.Sprintf(MessageRunCompleted, found.Name, found.Status.Phase),
+	}
+	if outcome.Succeeded {
+		outcome.Reason = v1alpha1.ReasonPodSucceeded
+	}
+
+	if containerStatus := getTerminatedContainer(found); containerStatus != nil {
+		terminated := containerStatus.State.Terminated
+		greeter.Status.ExitCode = &terminated.ExitCode
+		greeter.Status.TerminationMessage = terminated.Message
+		if !terminated.FinishedAt.IsZero() {
+			outcome.CompletionTime = terminated.FinishedAt.DeepCopy()
+		}
+
+		// Capture the output before the pod is gone, the greeting is
+		// lost if we failed to fetch it, so don't block the run on it.
+		if c.maxOutputBytes > 0 {
+			output, err := c.getPodOutput(ctx, found, containerStatus.Name)
+			if err != nil {
+				msg := fmt.Sprintf(MessageOutputNotCaptured, found.Name, err)
+				c.recorder.Event(greeter, corev1.EventTypeWarning, ErrOutputNotCaptured, msg)
+			}
+			greeter.Status.Output = output
+		}
+	}
+
+	return outcome, nil
+}
+
+// Reset deletes the runner pod of the current attempt.
+func (s *podSink) Reset(ctx context.Context, greeter *v1alpha1.Greeter) error {
+	err := s.c.kubeClientset.CoreV1().Pods(greeter.Namespace).Delete(ctx, greeter.Status.PodName, metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+
+	// The pod of the next attempt is not created yet
+	meta.RemoveStatusCondition(&greeter.Status.Conditions, v1alpha1.ConditionPodCreated)
+	return nil
+}
+
+// getTerminatedContainer returns the status of the terminated container which
+// determines the result of the pod, containers which failed take precedence.
+func getTerminatedContainer(pod *corev1.Pod) *corev1.ContainerStatus {
+	var found *corev1.ContainerStatus
+	for i := range pod.Status.ContainerStatuses {
+		containerStatus := &pod.Status.ContainerStatuses[i]
+		if state := containerStatus.State.Terminated; state != nil {
+			if found == nil || (found.State.Terminated.ExitCode == 0 && state.ExitCode != 0) {
+				found = containerStatus
+			}
+		}
+	}
+	return found
+}
+
+// getPodOutput fetches the tail of the logs of the container in the pod through
+// the pods/log subresource, the output is bounded to maxOutputBytes bytes.
+func (c *Controller) getPodOutput(ctx context.Context, pod *corev1.Pod, container string) (string, error) {
+	tailLines, limitBytes := int64(outputTailLines), c.maxOutputBytes
+	raw, err := c.kubeClientset.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
+		Container:  container,
+		TailLines:  &tailLines,
+		LimitBytes: &limitBytes,
+	}).DoRaw(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	// The server may not honour the limit, and the limit may split a
+	// multibyte character, which can't be stored in the status.
+	if int64(len(raw)) > limitBytes {
+		raw = raw[:limitBytes]
+	}
+	return strings.ToValidUTF8(string(raw), ""), nil
+}
+
+// newPodForGreeter returns a pod for the current run of the greeter. The pod
+// is built from the template of the greeter if any, otherwise it's a busybox
+// pod which prints the message.
+func newPodForGreeter(greeter *v1alpha1.Greeter) *corev1.Pod {
+	name := greeter.Status.PodName
+	if len(name) == 0 {
+		name = greeter.Name + "-runner"
+	}
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   greeter.Namespace,
+			Labels:      map[string]string{},
+			Annotations: map[string]string{},
+		},
+	}
+
+	if template := greeter.Spec.Template; template != nil {
+		pod.Spec = *template.Spec.DeepCopy()
+		for k, v := range template.Labels {
+			pod.Labels[k] = v
+		}
+		for k, v := range template.Annotations {
+			pod.Annotations[k] = v
+		}
+	}
+
+	// The labels owned by the controller always take precedence over the template
+	pod.Labels[nameLabel] = greeter.Name
+
+	// The message is passed to the containers as data and never goes through
+	// a shell, so it can't be interpreted by the container.
+	if len(pod.Spec.Containers) == 0 {
+		pod.Spec.Containers = []corev1.Container{
+			{
+				Image:   "busybox",
+				Name:    "greeter",
+				Command: []string{"printenv", messageEnvName},
+			},
+		}
+	}
+	for i := range pod.Spec.Containers {
+		container := &pod.Spec.Containers[i]
+		container.Env = append(container.Env, corev1.EnvVar{Name: messageEnvName, Value: greeter.Spec.Message})
+	}
+
+	// The runner pod is one-shot, it must not be restarted once terminated
+	pod.Spec.RestartPolicy = corev1.RestartPolicyNever
+
+	return pod
+}
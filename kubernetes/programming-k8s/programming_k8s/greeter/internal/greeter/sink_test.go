@@ -0,0 +1,501 @@
+package greeter
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/wjiec/programming_k8s/greeter/pkg/apis/greeter/v1alpha1"
+)
+
+// newRunningGreeter returns a Greeter in the default namespace whose current
+// run is the named one.
+func newRunningGreeter(name string, spec v1alpha1.GreeterSpec, run string) *v1alpha1.Greeter {
+	greeter := newGreeter(name, spec)
+	greeter.Namespace = simulationNamespace
+	greeter.UID = types.UID("uid-" + name)
+	greeter.Status.Phase = v1alpha1.PhaseRunning
+	greeter.Status.PodName = run
+	return greeter
+}
+
+func expectOutcome(t *testing.T, outcome *Outcome, err error, expected *Outcome) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if outcome.Finished != expected.Finished || outcome.Succeeded != expected.Succeeded ||
+		outcome.Permanent != expected.Permanent || outcome.Reason != expected.Reason {
+		t.Fatalf("expected outcome %+v, got %+v", expected, outcome)
+	}
+	if !strings.Contains(outcome.Message, expected.Message) {
+		t.Fatalf("expected message containing %q, got %q", expected.Message, outcome.Message)
+	}
+}
+
+func TestPodSink(t *testing.T) {
+	tests := []struct {
+		name      string
+		phase     corev1.PodPhase
+		exitCode  int32
+		succeeded bool
+		reason    string
+	}{
+		{name: "succeeded", phase: corev1.PodSucceeded, succeeded: true, reason: v1alpha1.ReasonPodSucceeded},
+		{name: "failed", phase: corev1.PodFailed, exitCode: 2, reason: v1alpha1.ReasonPodFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newSimulation(t, at("12:00:00"))
+			s.createGreeter(newGreeter("hello", v1alpha1.GreeterSpec{Schedule: "2023-10-01 12:00:00"}))
+			greeter := s.greeter("hello")
+			greeter.Status.PodName = "hello-runner"
+			sink := s.controller.sinks[sinkPod]
+
+			outcome, err := sink.Deliver(s.ctx, greeter)
+			expectOutcome(t, outcome, err, &Outcome{})
+			if !meta.IsStatusConditionTrue(greeter.Status.Conditions, v1alpha1.ConditionPodCreated) {
+				t.Fatalf("expected the pod created condition, got %v", greeter.Status.Conditions)
+			}
+			s.observe()
+
+			// Observing the running pod again doesn't create another one
+			outcome, err = sink.Deliver(s.ctx, greeter)
+			expectOutcome(t, outcome, err, &Outcome{})
+			expectLaunches(t, s, launchRecord{name: "hello-runner", at: at("12:00:00")})
+
+			s.updatePod(s.pods[simulationNamespace+"/hello-runner"], func(pod *corev1.Pod) {
+				pod.Status.Phase = tt.phase
+				pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+					Name: "greeter",
+					State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+						ExitCode:   tt.exitCode,
+						FinishedAt: metav1.NewTime(at("12:00:05")),
+					}},
+				}}
+			})
+			s.observe()
+
+			outcome, err = sink.Deliver(s.ctx, greeter)
+			expectOutcome(t, outcome, err, &Outcome{
+				Finished:  true,
+				Succeeded: tt.succeeded,
+				Reason:    tt.reason,
+				Message:   string(tt.phase),
+			})
+			if greeter.Status.ExitCode == nil || *greeter.Status.ExitCode != tt.exitCode {
+				t.Fatalf("expected exit code %d, got %v", tt.exitCode, greeter.Status.ExitCode)
+			}
+			expectTime(t, "completion time", outcome.CompletionTime, at("12:00:05"))
+
+			if err = sink.Reset(s.ctx, greeter); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if names := s.podNames(); len(names) != 0 {
+				t.Fatalf("expected the pod to be deleted, got %v", names)
+			}
+			if meta.FindStatusCondition(greeter.Status.Conditions, v1alpha1.ConditionPodCreated) != nil {
+				t.Fatalf("expected no pod created condition, got %v", greeter.Status.Conditions)
+			}
+
+			// The pod is already gone
+			if err = sink.Reset(s.ctx, greeter); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestPodSinkPodDeleted(t *testing.T) {
+	s := newSimulation(t, at("12:00:00"))
+	s.createGreeter(newGreeter("hello", v1alpha1.GreeterSpec{
+		Schedule:          "2023-10-01 12:00:00",
+		PodDeletionPolicy: v1alpha1.PodDeletionPolicyFail,
+	}))
+	greeter := s.greeter("hello")
+	greeter.Status.PodName = "hello-runner"
+	sink := s.controller.sinks[sinkPod]
+
+	outcome, err := sink.Deliver(s.ctx, greeter)
+	expectOutcome(t, outcome, err, &Outcome{})
+	if err = s.kubeClientset.CoreV1().Pods(simulationNamespace).Delete(s.ctx, "hello-runner", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	s.observe()
+
+	outcome, err = sink.Deliver(s.ctx, greeter)
+	expectOutcome(t, outcome, err, &Outcome{
+		Finished:  true,
+		Permanent: true,
+		Reason:    v1alpha1.ReasonPodDeleted,
+		Message:   "hello-runner",
+	})
+}
+
+func TestPodSinkForeignPod(t *testing.T) {
+	s := newSimulation(t, at("12:00:00"))
+	s.createGreeter(newGreeter("hello", v1alpha1.GreeterSpec{Schedule: "2023-10-01 12:00:00"}))
+	greeter := s.greeter("hello")
+	greeter.Status.PodName = "hello-runner"
+
+	// A pod of the same name which is not controlled by the Greeter
+	_, err := s.kubeClientset.CoreV1().Pods(simulationNamespace).Create(s.ctx, &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "hello-runner",
+			Namespace: simulationNamespace,
+			Labels:    map[string]string{nameLabel: "hello"},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.observe()
+
+	if _, err = s.controller.sinks[sinkPod].Deliver(s.ctx, greeter); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("expected the pod not to be taken over, got %v", err)
+	}
+}
+
+// deliveryLabels are the labels which allow the Secrets and ConfigMaps to be
+// used by the delivery.
+var deliveryLabels = map[string]string{v1alpha1.LabelDelivery: v1alpha1.LabelDeliveryAllowed}
+
+func TestHTTPSink(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "hook-token", Namespace: simulationNamespace, Labels: deliveryLabels},
+		Data:       map[string][]byte{"token": []byte("s3cr3t")},
+	}
+	unlabeledSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "db-password", Namespace: simulationNamespace},
+		Data:       map[string][]byte{"token": []byte("p4ssw0rd")},
+	}
+	optional := true
+
+	tests := []struct {
+		name     string
+		status   int
+		header   *v1alpha1.HTTPSecretHeader
+		expected *Outcome
+		// token is the value of the secret header received, empty if the
+		// request is not expected at all.
+		token  string
+		output string
+	}{
+		{
+			name:     "delivered",
+			status:   http.StatusOK,
+			expected: &Outcome{Finished: true, Succeeded: true, Reason: v1alpha1.ReasonDelivered, Message: "with status 200"},
+			output:   "hello",
+		},
+		{
+			name:   "with secret header",
+			status: http.StatusAccepted,
+			header: &v1alpha1.HTTPSecretHeader{Name: "X-Token", SecretKeyRef: corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "hook-token"},
+				Key:                  "token",
+			}},
+			expected: &Outcome{Finished: true, Succeeded: true, Reason: v1alpha1.ReasonDelivered, Message: "with status 202"},
+			token:    "s3cr3t",
+			output:   "hello",
+		},
+		{
+			name:   "optional secret missing",
+			status: http.StatusOK,
+			header: &v1alpha1.HTTPSecretHeader{Name: "X-Token", SecretKeyRef: corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "other-token"},
+				Key:                  "token",
+				Optional:             &optional,
+			}},
+			expected: &Outcome{Finished: true, Succeeded: true, Reason: v1alpha1.ReasonDelivered, Message: "with status 200"},
+			output:   "hello",
+		},
+		{
+			name:   "secret key missing",
+			status: http.StatusOK,
+			header: &v1alpha1.HTTPSecretHeader{Name: "X-Token", SecretKeyRef: corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "hook-token"},
+				Key:                  "password",
+			}},
+			expected: &Outcome{Finished: true, Reason: v1alpha1.ReasonDeliveryFailed, Message: `key "password" not found`},
+		},
+		{
+			name:   "secret not labeled",
+			status: http.StatusOK,
+			header: &v1alpha1.HTTPSecretHeader{Name: "X-Token", SecretKeyRef: corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "db-password"},
+				Key:                  "token",
+			}},
+			expected: &Outcome{Finished: true, Reason: v1alpha1.ReasonDeliveryFailed, Message: `secret "db-password" is not labeled`},
+		},
+		{
+			// The optional Secret is omitted only if it doesn't exist
+			name:   "optional secret not labeled",
+			status: http.StatusOK,
+			header: &v1alpha1.HTTPSecretHeader{Name: "X-Token", SecretKeyRef: corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "db-password"},
+				Key:                  "token",
+				Optional:             &optional,
+			}},
+			expected: &Outcome{Finished: true, Reason: v1alpha1.ReasonDeliveryFailed, Message: `secret "db-password" is not labeled`},
+		},
+		{
+			name:     "server error",
+			status:   http.StatusInternalServerError,
+			expected: &Outcome{Finished: true, Reason: v1alpha1.ReasonDeliveryFailed, Message: "unexpected status"},
+			output:   "hello",
+		},
+		{
+			name:     "redirect is not followed",
+			status:   http.StatusFound,
+			expected: &Outcome{Finished: true, Reason: v1alpha1.ReasonDeliveryFailed, Message: "unexpected status"},
+			output:   "hello",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests []*http.Request
+			var greetings []httpGreeting
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var greeting httpGreeting
+				if err := json.NewDecoder(r.Body).Decode(&greeting); err != nil {
+					t.Errorf("decode greeting: %v", err)
+				}
+				requests, greetings = append(requests, r), append(greetings, greeting)
+
+				w.Header().Set("Location", "http://example.com/")
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte("hello world"))
+			}))
+			t.Cleanup(server.Close)
+
+			greeter := newRunningGreeter("hello", v1alpha1.GreeterSpec{
+				Cron:     "* * * * *",
+				Delivery: v1alpha1.GreeterDelivery{HTTP: &v1alpha1.HTTPDelivery{URL: server.URL + "/hook", SecretHeader: tt.header}},
+			}, "hello-28269840")
+			sink := &httpSink{kubeClientset: kubefake.NewSimpleClientset(secret, unlabeledSecret), client: newHTTPClient(), maxOutputBytes: 5}
+
+			outcome, err := sink.Deliver(context.Background(), greeter)
+			expectOutcome(t, outcome, err, tt.expected)
+			if greeter.Status.Output != tt.output {
+				t.Fatalf("expected output %q, got %q", tt.output, greeter.Status.Output)
+			}
+
+			if tt.expected.Succeeded || len(tt.output) != 0 {
+				if len(requests) != 1 {
+					t.Fatalf("expected a request, got %d", len(requests))
+				}
+				req, greeting := requests[0], greetings[0]
+				if req.Method != http.MethodPost || req.URL.Path != "/hook" || req.Header.Get(runHeader) != "hello-28269840" {
+					t.Fatalf("unexpected request %s %s with run %q", req.Method, req.URL.Path, req.Header.Get(runHeader))
+				}
+				if got := req.Header.Get("X-Token"); got != tt.token {
+					t.Fatalf("expected secret header %q, got %q", tt.token, got)
+				}
+				expected := httpGreeting{Namespace: simulationNamespace, Name: "hello", Run: "hello-28269840", Message: "hello hello"}
+				if greeting != expected {
+					t.Fatalf("expected greeting %+v, got %+v", expected, greeting)
+				}
+			} else if len(requests) != 0 {
+				t.Fatalf("expected no request, got %d", len(requests))
+			}
+
+			if err = sink.Reset(context.Background(), greeter); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestHTTPSinkUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	greeter := newRunningGreeter("hello", v1alpha1.GreeterSpec{
+		Cron:     "* * * * *",
+		Delivery: v1alpha1.GreeterDelivery{HTTP: &v1alpha1.HTTPDelivery{URL: server.URL + "/hook"}},
+	}, "hello-28269840")
+	sink := &httpSink{kubeClientset: kubefake.NewSimpleClientset(), client: newHTTPClient()}
+
+	outcome, err := sink.Deliver(context.Background(), greeter)
+	expectOutcome(t, outcome, err, &Outcome{Finished: true, Reason: v1alpha1.ReasonDeliveryFailed, Message: "hello-28269840"})
+}
+
+func TestConfigMapSink(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing *corev1.ConfigMap
+		reactor  k8stesting.ReactionFunc
+		expected *Outcome
+		data     map[string]string
+		err      string
+	}{
+		{
+			name:     "created",
+			expected: &Outcome{Finished: true, Succeeded: true, Reason: v1alpha1.ReasonDelivered, Message: `key "latest" of ConfigMap "greetings"`},
+			data:     map[string]string{"latest": "hello hello"},
+		},
+		{
+			name: "updated",
+			existing: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "greetings", Namespace: simulationNamespace, Labels: deliveryLabels},
+				Data:       map[string]string{"latest": "hello before", "other": "kept"},
+			},
+			expected: &Outcome{Finished: true, Succeeded: true, Reason: v1alpha1.ReasonDelivered, Message: `key "latest" of ConfigMap "greetings"`},
+			data:     map[string]string{"latest": "hello hello", "other": "kept"},
+		},
+		{
+			name: "updated without data",
+			existing: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "greetings", Namespace: simulationNamespace, Labels: deliveryLabels},
+			},
+			expected: &Outcome{Finished: true, Succeeded: true, Reason: v1alpha1.ReasonDelivered, Message: `key "latest" of ConfigMap "greetings"`},
+			data:     map[string]string{"latest": "hello hello"},
+		},
+		{
+			name: "immutable",
+			existing: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "greetings", Namespace: simulationNamespace, Labels: deliveryLabels},
+				Data:       map[string]string{"latest": "hello before"},
+			},
+			reactor: func(k8stesting.Action) (bool, runtime.Object, error) {
+				return true, nil, errors.NewInvalid(corev1.SchemeGroupVersion.WithKind("ConfigMap").GroupKind(), "greetings", nil)
+			},
+			expected: &Outcome{Finished: true, Reason: v1alpha1.ReasonDeliveryFailed, Message: "greetings"},
+			data:     map[string]string{"latest": "hello before"},
+		},
+		{
+			name: "not labeled",
+			existing: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "greetings", Namespace: simulationNamespace},
+				Data:       map[string]string{"latest": "hello before"},
+			},
+			expected: &Outcome{Finished: true, Reason: v1alpha1.ReasonDeliveryFailed, Message: `configmap "greetings" is not labeled`},
+			data:     map[string]string{"latest": "hello before"},
+		},
+		{
+			name: "conflict",
+			existing: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "greetings", Namespace: simulationNamespace, Labels: deliveryLabels},
+				Data:       map[string]string{"latest": "hello before"},
+			},
+			reactor: func(k8stesting.Action) (bool, runtime.Object, error) {
+				return true, nil, errors.NewConflict(corev1.Resource("configmaps"), "greetings", nil)
+			},
+			data: map[string]string{"latest": "hello before"},
+			err:  "cannot be fulfilled",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kubeClientset := kubefake.NewSimpleClientset()
+			if tt.existing != nil {
+				kubeClientset = kubefake.NewSimpleClientset(tt.existing)
+			}
+			if tt.reactor != nil {
+				kubeClientset.PrependReactor("update", "configmaps", tt.reactor)
+			}
+
+			greeter := newRunningGreeter("hello", v1alpha1.GreeterSpec{
+				Cron:     "* * * * *",
+				Delivery: v1alpha1.GreeterDelivery{ConfigMap: &v1alpha1.ConfigMapDelivery{Name: "greetings", Key: "latest"}},
+			}, "hello-28269840")
+			sink := &configMapSink{kubeClientset: kubeClientset}
+
+			outcome, err := sink.Deliver(context.Background(), greeter)
+			if len(tt.err) != 0 {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected error containing %q, got %v", tt.err, err)
+				}
+			} else {
+				expectOutcome(t, outcome, err, tt.expected)
+			}
+
+			configMap, err := kubeClientset.CoreV1().ConfigMaps(simulationNamespace).Get(context.Background(), "greetings", metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !isDeliveryAllowed(configMap) && tt.existing == nil {
+				t.Fatalf("expected the created ConfigMap to be labeled, got %v", configMap.Labels)
+			}
+			if len(configMap.Data) != len(tt.data) {
+				t.Fatalf("expected data %v, got %v", tt.data, configMap.Data)
+			}
+			for key, value := range tt.data {
+				if configMap.Data[key] != value {
+					t.Fatalf("expected data %v, got %v", tt.data, configMap.Data)
+				}
+			}
+
+			if err = sink.Reset(context.Background(), greeter); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestEventSink(t *testing.T) {
+	tests := []struct {
+		name     string
+		delivery v1alpha1.EventDelivery
+		event    string
+	}{
+		{
+			name:     "defaults",
+			delivery: v1alpha1.EventDelivery{APIVersion: "apps/v1", Kind: "Deployment", Name: "web"},
+			event:    "Normal Greeting hello hello",
+		},
+		{
+			name:     "type and reason",
+			delivery: v1alpha1.EventDelivery{APIVersion: "v1", Kind: "Pod", Name: "web-0", Type: corev1.EventTypeWarning, Reason: "Hello"},
+			event:    "Warning Hello hello hello",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(10)
+			greeter := newRunningGreeter("hello", v1alpha1.GreeterSpec{
+				Cron:     "* * * * *",
+				Delivery: v1alpha1.GreeterDelivery{Event: &tt.delivery},
+			}, "hello-28269840")
+			sink := &eventSink{recorder: recorder}
+
+			outcome, err := sink.Deliver(context.Background(), greeter)
+			expectOutcome(t, outcome, err, &Outcome{
+				Finished:  true,
+				Succeeded: true,
+				Reason:    v1alpha1.ReasonDelivered,
+				Message:   tt.delivery.Kind + ` "` + tt.delivery.Name + `"`,
+			})
+
+			select {
+			case event := <-recorder.Events:
+				if event != tt.event {
+					t.Fatalf("expected event %q, got %q", tt.event, event)
+				}
+			default:
+				t.Fatalf("expected event %q, got none", tt.event)
+			}
+
+			if err = sink.Reset(context.Background(), greeter); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+		})
+	}
+}
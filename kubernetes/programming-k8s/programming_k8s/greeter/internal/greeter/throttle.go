@@ -0,0 +1,257 @@
+package greeter
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/wjiec/programming_k8s/greeter/pkg/apis/greeter/v1alpha1"
+)
+
+const (
+	// throttleRequeueDelay is the delay before a throttled Greeter is checked
+	// again, a throttled Greeter is also requeued as soon as a run finishes.
+	throttleRequeueDelay = 10 * time.Second
+	// launchObserveTimeout is how long a launched run is counted as running
+	// until the Running phase of its Greeter is observed in the cache.
+	launchObserveTimeout = 30 * time.Second
+	// waiterTimeout is how long a throttled Greeter keeps its place in the
+	// line without being checked again, e.g. after it's no longer due.
+	waiterTimeout = 3 * throttleRequeueDelay
+)
+
+// launchThrottle limits the runs launched by the controller by the number of
+// the runs active at the same time, both cluster-wide and per namespace, and
+// by the rate of the launches.
+//
+// The running Greeters are counted from the informer cache, which lags behind
+// the launches of the controller. The runs launched recently are tracked
+// until their Greeters are observed to be changed in the cache. The throttled
+// Greeters are admitted in the order they started waiting, so that none of
+// them is starved by the others.
+type launchThrottle struct {
+	// maxRunning is the maximum number of active runs cluster-wide, zero
+	// means unlimited.
+	maxRunning int
+	// maxRunningPerNamespace is the maximum number of active runs in each
+	// namespace, zero means unlimited.
+	maxRunningPerNamespace int
+	// limiter is the token bucket of the launches, nil means unlimited.
+	limiter *rate.Limiter
+
+	mu sync.Mutex
+	// launched are the runs launched but not observed in the cache yet by
+	// the keys of their Greeters.
+	launched map[string]*launch
+	// waiting are the throttled Greeters by their keys.
+	waiting map[string]*waiter
+}
+
+// launch is a run launched by the controller.
+type launch struct {
+	namespace string
+	// resourceVersion is the version of the Greeter in the cache when the
+	// run is launched, the run is observed once the version is changed.
+	resourceVersion string
+	launchedAt      time.Time
+	// waitingSince is when the Greeter started waiting for the launch, and
+	// reservation is the token taken from the limiter. They are kept so that
+	// the launch can be undone.
+	waitingSince time.Time
+	reservation  *rate.Reservation
+}
+
+// waiter is a Greeter throttled from launching its run.
+type waiter struct {
+	key       string
+	namespace string
+	since     time.Time
+	lastSeen  time.Time
+}
+
+// newLaunchThrottle returns a launchThrottle, it returns nil if no limit is set.
+func newLaunchThrottle(maxRunning, maxRunningPerNamespace int, launchQPS float64, launchBurst int) *launchThrottle {
+	if maxRunning <= 0 && maxRunningPerNamespace <= 0 && launchQPS <= 0 {
+		return nil
+	}
+
+	t := &launchThrottle{
+		maxRunning:             maxRunning,
+		maxRunningPerNamespace: maxRunningPerNamespace,
+		launched:               map[string]*launch{},
+		waiting:                map[string]*waiter{},
+	}
+	if launchQPS > 0 {
+		if launchBurst < 1 {
+			launchBurst = 1
+		}
+		t.limiter = rate.NewLimiter(rate.Limit(launchQPS), launchBurst)
+	}
+	return t
+}
+
+// admit decides whether the run of the Greeter can be launched now, greeters
+// are all the Greeters in the scope of the controller. It returns the reason
+// and message of the throttle and the delay before checking again if the run
+// is throttled, or an empty reason if the run is admitted. The message only
+// describes the limit, so that it's stable while the Greeter is waiting.
+func (t *launchThrottle) admit(key string, greeter *v1alpha1.Greeter, greeters []*v1alpha1.Greeter, now time.Time) (string, string, time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	running, runningPerNamespace := t.countRunning(greeters, now)
+	hasCapacity := func(namespace string) bool {
+		return (t.maxRunning <= 0 || running < t.maxRunning) &&
+			(t.maxRunningPerNamespace <= 0 || runningPerNamespace[namespace] < t.maxRunningPerNamespace)
+	}
+
+	self, ok := t.waiting[key]
+	if !ok {
+		self = &waiter{key: key, namespace: greeter.Namespace, since: now}
+		t.waiting[key] = self
+	}
+	self.lastSeen = now
+
+	// Only the waiters which are able to run take precedence, so that a
+	// saturated namespace doesn't block the Greeters in other namespaces.
+	ahead, aheadInNamespace := 0, 0
+	for _, w := range t.sortedWaiters(now) {
+		if w == self {
+			break
+		}
+		if !hasCapacity(w.namespace) {
+			continue
+		}
+		ahead++
+		if w.namespace == self.namespace {
+			aheadInNamespace++
+		}
+	}
+
+	if t.maxRunning > 0 && running+ahead >= t.maxRunning {
+		return v1alpha1.ReasonMaxRunning, fmt.Sprintf("Active runs are limited to %d cluster-wide", t.maxRunning), throttleRequeueDelay
+	}
+	if limit := t.maxRunningPerNamespace; limit > 0 && runningPerNamespace[self.namespace]+aheadInNamespace >= limit {
+		return v1alpha1.ReasonMaxRunningPerNamespace, fmt.Sprintf("Active runs are limited to %d in each namespace", limit), throttleRequeueDelay
+	}
+
+	var reservation *rate.Reservation
+	if t.limiter != nil {
+		// The tokens are left for the waiters ahead, which are about to take them
+		limit := float64(t.limiter.Limit())
+		if tokens := t.limiter.TokensAt(now); tokens < float64(ahead+1) {
+			delay := time.Duration(math.Ceil((float64(ahead+1) - tokens) / limit * float64(time.Second)))
+			if minDelay := time.Duration(float64(time.Second) / limit); delay < minDelay {
+				delay = minDelay
+			}
+			return v1alpha1.ReasonLaunchRateLimited, fmt.Sprintf("Launches are limited to %g per second", limit), delay
+		}
+		// There is a token available, so the reservation is never delayed
+		reservation = t.limiter.ReserveN(now, 1)
+	}
+
+	delete(t.waiting, key)
+	t.launched[key] = &launch{
+		namespace:       greeter.Namespace,
+		resourceVersion: greeter.ResourceVersion,
+		launchedAt:      now,
+		waitingSince:    self.since,
+		reservation:     reservation,
+	}
+	return "", "", 0
+}
+
+// undo reverts the admission of the run of the Greeter, e.g. the launch
+// failed to be persisted. The launch slot and the token are released, and the
+// Greeter gets back its place in the line.
+func (t *launchThrottle) undo(key string, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	l, ok := t.launched[key]
+	if !ok {
+		return
+	}
+	delete(t.launched, key)
+
+	// The token is only given back as of the launch, it's considered used
+	// once the time of the launch has passed.
+	if l.reservation != nil {
+		l.reservation.CancelAt(l.launchedAt)
+	}
+	t.waiting[key] = &waiter{key: key, namespace: l.namespace, since: l.waitingSince, lastSeen: now}
+}
+
+// forget removes the Greeter from the line of the throttled Greeters, e.g.
+// once it's no longer due or deleted.
+func (t *launchThrottle) forget(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.waiting, key)
+}
+
+// waiters returns the keys of the throttled Greeters in the order they started waiting.
+func (t *launchThrottle) waiters(now time.Time) []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var keys []string
+	for _, w := range t.sortedWaiters(now) {
+		keys = append(keys, w.key)
+	}
+	return keys
+}
+
+// countRunning returns the number of active runs cluster-wide and in each
+// namespace, including the runs launched but not observed in the cache yet.
+func (t *launchThrottle) countRunning(greeters []*v1alpha1.Greeter, now time.Time) (int, map[string]int) {
+	running, runningPerNamespace := 0, map[string]int{}
+	observed := make(map[string]string, len(greeters))
+	for _, greeter := range greeters {
+		key := greeter.Namespace + "/" + greeter.Name
+		observed[key] = greeter.ResourceVersion
+
+		if greeter.Status.Phase == v1alpha1.PhaseRunning {
+			running++
+			runningPerNamespace[greeter.Namespace]++
+		}
+	}
+
+	for key, l := range t.launched {
+		resourceVersion, found := observed[key]
+		if !found || resourceVersion != l.resourceVersion || now.Sub(l.launchedAt) > launchObserveTimeout {
+			delete(t.launched, key)
+			continue
+		}
+		running++
+		runningPerNamespace[l.namespace]++
+	}
+
+	return running, runningPerNamespace
+}
+
+// sortedWaiters returns the throttled Greeters in the order they started
+// waiting, the waiters not checked for a while are dropped.
+func (t *launchThrottle) sortedWaiters(now time.Time) []*waiter {
+	waiters := make([]*waiter, 0, len(t.waiting))
+	for key, w := range t.waiting {
+		if now.Sub(w.lastSeen) > waiterTimeout {
+			delete(t.waiting, key)
+			continue
+		}
+		waiters = append(waiters, w)
+	}
+
+	sort.Slice(waiters, func(i, j int) bool {
+		if !waiters[i].since.Equal(waiters[j].since) {
+			return waiters[i].since.Before(waiters[j].since)
+		}
+		return waiters[i].key < waiters[j].key
+	})
+	return waiters
+}
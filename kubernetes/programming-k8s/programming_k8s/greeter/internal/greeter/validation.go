@@ -0,0 +1,255 @@
+package greeter
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/robfig/cron"
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"github.com/wjiec/programming_k8s/greeter/pkg/apis/greeter/v1alpha1"
+)
+
+// DefaultClusterDomain is the default DNS domain of the cluster, the fully
+// qualified name of a Service is "<service>.<namespace>.svc.<cluster domain>".
+const DefaultClusterDomain = "cluster.local"
+
+// ValidateMessage checks the message of a Greeter is no larger than maxSize
+// bytes and contains no control characters other than tab and newline. A
+// maxSize of zero means the size is unlimited.
+func ValidateMessage(message string, maxSize int) error {
+	if maxSize > 0 && len(message) > maxSize {
+		return fmt.Errorf("message must have at most %d bytes, got %d", maxSize, len(message))
+	}
+
+	if !utf8.ValidString(message) {
+		return fmt.Errorf("message must be valid UTF-8")
+	}
+
+	for i, r := range message {
+		if unicode.IsControl(r) && r != '\t' && r != '\n' {
+			return fmt.Errorf("message must not contain control character %U at offset %d", r, i)
+		}
+	}
+
+	return nil
+}
+
+// Validator validates Greeters the same way as they are evaluated by the
+// controller, so that the invalid ones can be rejected at admission time.
+type Validator struct {
+	// DefaultTimeZone is the location of the schedule for Greeters without
+	// an explicit time zone.
+	DefaultTimeZone *time.Location
+	// MaxMessageSize is the maximum size in bytes of the message, zero
+	// means unlimited.
+	MaxMessageSize int
+	// ClusterDomain is the DNS domain of the cluster allowed to follow the
+	// "svc" in the host of an HTTP sink, empty allows none.
+	ClusterDomain string
+}
+
+// ValidateCreate validates a new Greeter, a one-shot schedule in the past
+// relative to now is rejected as it would never be fired.
+func (v *Validator) ValidateCreate(greeter *v1alpha1.Greeter, now time.Time) field.ErrorList {
+	return v.validateSpec(&greeter.Spec, greeter.Namespace, true, now, field.NewPath("spec"))
+}
+
+// ValidateUpdate validates the update of a Greeter. The schedule is only
+// rejected for being in the past when it's changed, and the fields which
+// affect the run are immutable while the Greeter is running.
+func (v *Validator) ValidateUpdate(greeter, old *v1alpha1.Greeter, now time.Time) field.ErrorList {
+	specPath := field.NewPath("spec")
+	scheduleChanged := greeter.Spec.Schedule != old.Spec.Schedule ||
+		!apiequality.Semantic.DeepEqual(greeter.Spec.TimeZone, old.Spec.TimeZone)
+
+	allErrs := v.validateSpec(&greeter.Spec, greeter.Namespace, scheduleChanged, now, specPath)
+	if old.Status.Phase == v1alpha1.PhaseRunning {
+		allErrs = append(allErrs, validateImmutable(greeter.Spec.Schedule, old.Spec.Schedule, specPath.Child("schedule"))...)
+		allErrs = append(allErrs, validateImmutable(greeter.Spec.Cron, old.Spec.Cron, specPath.Child("cron"))...)
+		allErrs = append(allErrs, validateImmutable(greeter.Spec.TimeZone, old.Spec.TimeZone, specPath.Child("timeZone"))...)
+		allErrs = append(allErrs, validateImmutable(greeter.Spec.Message, old.Spec.Message, specPath.Child("message"))...)
+		allErrs = append(allErrs, validateImmutable(greeter.Spec.Template, old.Spec.Template, specPath.Child("template"))...)
+		allErrs = append(allErrs, validateImmutable(greeter.Spec.Delivery, old.Spec.Delivery, specPath.Child("delivery"))...)
+	}
+
+	return allErrs
+}
+
+// validateSpec validates the schedule, message and delivery of the Greeter in
+// the namespace.
+func (v *Validator) validateSpec(spec *v1alpha1.GreeterSpec, namespace string, rejectPast bool, now time.Time, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	location := v.DefaultTimeZone
+	if spec.TimeZone != nil {
+		var err error
+		if location, err = LoadTimeZone(*spec.TimeZone); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("timeZone"), *spec.TimeZone, err.Error()))
+		}
+	}
+
+	switch {
+	case len(spec.Cron) != 0:
+		if _, err := cron.ParseStandard(spec.Cron); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("cron"), spec.Cron, err.Error()))
+		}
+	case len(spec.Schedule) != 0:
+		if location == nil {
+			// The time zone is already reported as invalid
+			break
+		}
+
+		scheduledTime, err := getScheduledTime(spec.Schedule, location)
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("schedule"), spec.Schedule,
+				fmt.Sprintf("must be in %q format", ScheduleLayout)))
+		} else if rejectPast && scheduledTime.Before(now) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("schedule"), spec.Schedule,
+				fmt.Sprintf("must not be in the past (now is %s)", now.In(location).Format(ScheduleLayout))))
+		}
+	default:
+		allErrs = append(allErrs, field.Required(fldPath.Child("schedule"), "either schedule or cron must be specified"))
+	}
+
+	if len(spec.Message) == 0 {
+		allErrs = append(allErrs, field.Required(fldPath.Child("message"), ""))
+	} else if err := ValidateMessage(spec.Message, v.MaxMessageSize); err != nil {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("message"), spec.Message, err.Error()))
+	}
+
+	allErrs = append(allErrs, ValidateDelivery(spec, namespace, v.ClusterDomain, fldPath)...)
+
+	return allErrs
+}
+
+// ValidateDelivery checks at most one sink is set in the delivery of the
+// Greeter, and the set sink is complete. The template of the runner pod must
+// not be set together with a sink. The host of an HTTP sink must be a Service
+// in the namespace of the Greeter in the cluster of the clusterDomain.
+func ValidateDelivery(spec *v1alpha1.GreeterSpec, namespace, clusterDomain string, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	delivery, deliveryPath := &spec.Delivery, fldPath.Child("delivery")
+
+	sinks := 0
+	if event := delivery.Event; event != nil {
+		sinks++
+		eventPath := deliveryPath.Child("event")
+		allErrs = append(allErrs, validateRequired(event.APIVersion, eventPath.Child("apiVersion"))...)
+		allErrs = append(allErrs, validateRequired(event.Kind, eventPath.Child("kind"))...)
+		allErrs = append(allErrs, validateRequired(event.Name, eventPath.Child("name"))...)
+		if len(event.Type) != 0 && event.Type != corev1.EventTypeNormal && event.Type != corev1.EventTypeWarning {
+			allErrs = append(allErrs, field.NotSupported(eventPath.Child("type"), event.Type,
+				[]string{corev1.EventTypeNormal, corev1.EventTypeWarning}))
+		}
+	}
+	if configMap := delivery.ConfigMap; configMap != nil {
+		sinks++
+		configMapPath := deliveryPath.Child("configMap")
+		if len(configMap.Name) == 0 {
+			allErrs = append(allErrs, field.Required(configMapPath.Child("name"), ""))
+		} else {
+			for _, msg := range validation.IsDNS1123Subdomain(configMap.Name) {
+				allErrs = append(allErrs, field.Invalid(configMapPath.Child("name"), configMap.Name, msg))
+			}
+		}
+		if len(configMap.Key) == 0 {
+			allErrs = append(allErrs, field.Required(configMapPath.Child("key"), ""))
+		} else {
+			for _, msg := range validation.IsConfigMapKey(configMap.Key) {
+				allErrs = append(allErrs, field.Invalid(configMapPath.Child("key"), configMap.Key, msg))
+			}
+		}
+	}
+	if http := delivery.HTTP; http != nil {
+		sinks++
+		httpPath := deliveryPath.Child("http")
+		if len(http.URL) == 0 {
+			allErrs = append(allErrs, field.Required(httpPath.Child("url"), ""))
+		} else if err := validateServiceURL(http.URL, namespace, clusterDomain); err != nil {
+			allErrs = append(allErrs, field.Invalid(httpPath.Child("url"), http.URL, err.Error()))
+		}
+		if header := http.SecretHeader; header != nil {
+			headerPath := httpPath.Child("secretHeader")
+			if len(header.Name) == 0 {
+				allErrs = append(allErrs, field.Required(headerPath.Child("name"), ""))
+			} else {
+				for _, msg := range validation.IsHTTPHeaderName(header.Name) {
+					allErrs = append(allErrs, field.Invalid(headerPath.Child("name"), header.Name, msg))
+				}
+			}
+			allErrs = append(allErrs, validateRequired(header.SecretKeyRef.Name, headerPath.Child("secretKeyRef", "name"))...)
+			allErrs = append(allErrs, validateRequired(header.SecretKeyRef.Key, headerPath.Child("secretKeyRef", "key"))...)
+		}
+	}
+
+	if sinks > 1 {
+		allErrs = append(allErrs, field.Forbidden(deliveryPath, "may not specify more than 1 sink"))
+	}
+	if sinks != 0 && spec.Template != nil {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("template"), "may not be specified together with a delivery sink"))
+	}
+
+	return allErrs
+}
+
+// validateServiceURL checks the URL is an http(s) URL whose host is the DNS
+// name of a Service, e.g. "http://greetings.default.svc/hook". The host may
+// be fully qualified by the clusterDomain, but no other domain may follow the
+// "svc" as it is resolved out of the cluster. The Service must be in the
+// namespace, so a Greeter can't send to the Services of other namespaces.
+func validateServiceURL(rawURL, namespace, clusterDomain string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("scheme must be http or https")
+	}
+	if u.User != nil {
+		return fmt.Errorf("must not contain user information")
+	}
+
+	// The cluster domain (e.g. cluster.local) following the "svc" is optional
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if len(clusterDomain) != 0 {
+		host = strings.TrimSuffix(host, "."+strings.ToLower(strings.Trim(clusterDomain, ".")))
+	}
+
+	labels := strings.Split(host, ".")
+	if len(labels) != 3 || labels[2] != "svc" ||
+		len(validation.IsDNS1035Label(labels[0])) != 0 || len(validation.IsDNS1123Label(labels[1])) != 0 {
+		if len(clusterDomain) == 0 {
+			return fmt.Errorf("host must be the DNS name of a Service, e.g. <service>.<namespace>.svc")
+		}
+		return fmt.Errorf("host must be the DNS name of a Service, e.g. <service>.<namespace>.svc or <service>.<namespace>.svc.%s", clusterDomain)
+	}
+	if labels[1] != namespace {
+		return fmt.Errorf("host must be a Service in the namespace %q of the greeter", namespace)
+	}
+	return nil
+}
+
+// validateRequired rejects the empty value of the required field.
+func validateRequired(value string, fldPath *field.Path) field.ErrorList {
+	if len(value) == 0 {
+		return field.ErrorList{field.Required(fldPath, "")}
+	}
+	return nil
+}
+
+// validateImmutable rejects the change of the field from its old value.
+func validateImmutable(value, old any, fldPath *field.Path) field.ErrorList {
+	if !apiequality.Semantic.DeepEqual(value, old) {
+		return field.ErrorList{field.Forbidden(fldPath, "field is immutable while the greeter is running")}
+	}
+	return nil
+}
@@ -0,0 +1,290 @@
+package greeter
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"github.com/wjiec/programming_k8s/greeter/pkg/apis/greeter/v1alpha1"
+)
+
+var validationNow = time.Date(2023, time.October, 1, 12, 0, 0, 0, time.UTC)
+
+func newValidator() *Validator {
+	return &Validator{DefaultTimeZone: time.UTC, MaxMessageSize: 16, ClusterDomain: DefaultClusterDomain}
+}
+
+// errorFields returns the "<type> <field>" of the errors, e.g.
+// "FieldValueInvalid spec.schedule".
+func errorFields(allErrs field.ErrorList) []string {
+	var fields []string
+	for _, err := range allErrs {
+		fields = append(fields, string(err.Type)+" "+err.Field)
+	}
+	return fields
+}
+
+func expectErrors(t *testing.T, allErrs field.ErrorList, expected ...string) {
+	t.Helper()
+	if fields := errorFields(allErrs); !reflect.DeepEqual(fields, expected) {
+		t.Fatalf("expected errors %v, got %v", expected, allErrs)
+	}
+}
+
+func TestValidateMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		maxSize int
+		err     string
+	}{
+		{name: "plain", message: "hello world", maxSize: 16},
+		{name: "tab and newline", message: "hello\tworld\n", maxSize: 16},
+		{name: "unlimited", message: strings.Repeat("a", 4096)},
+		{name: "too large", message: strings.Repeat("a", 17), maxSize: 16, err: "at most 16 bytes"},
+		// 6 characters of 3 bytes each
+		{name: "multi-byte too large", message: "你好你好你好", maxSize: 16, err: "at most 16 bytes"},
+		{name: "invalid utf-8", message: "hello\xff", maxSize: 16, err: "valid UTF-8"},
+		{name: "control character", message: "hello\x1b[0m", maxSize: 16, err: "control character U+001B"},
+		{name: "c1 control character", message: "hello\u0085", maxSize: 16, err: "control character U+0085"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateMessage(tt.message, tt.maxSize)
+			if len(tt.err) == 0 {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("expected error containing %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestValidateServiceURL(t *testing.T) {
+	tests := []struct {
+		name          string
+		url           string
+		namespace     string
+		clusterDomain string
+		err           string
+	}{
+		{name: "service", url: "http://greetings.default.svc/hook", clusterDomain: DefaultClusterDomain},
+		{name: "https with port", url: "https://greetings.default.svc:8443/hook", clusterDomain: DefaultClusterDomain},
+		{name: "cluster domain", url: "http://greetings.default.svc.cluster.local/hook", clusterDomain: DefaultClusterDomain},
+		{name: "rooted cluster domain", url: "http://greetings.default.svc.cluster.local./hook", clusterDomain: DefaultClusterDomain},
+		{name: "custom cluster domain", url: "http://greetings.default.svc.k8s.internal/hook", clusterDomain: "k8s.internal"},
+		{name: "no cluster domain", url: "http://greetings.default.svc/hook"},
+		{
+			name:          "foreign suffix",
+			url:           "http://hook.default.svc.attacker.example/hook",
+			clusterDomain: DefaultClusterDomain,
+			err:           "DNS name of a Service",
+		},
+		{
+			name:          "cluster domain suffix",
+			url:           "http://hook.default.svc.cluster.local.attacker.example/hook",
+			clusterDomain: DefaultClusterDomain,
+			err:           "DNS name of a Service",
+		},
+		{
+			name:          "other cluster domain",
+			url:           "http://greetings.default.svc.cluster.local/hook",
+			clusterDomain: "k8s.internal",
+			err:           "DNS name of a Service",
+		},
+		{
+			name: "cluster domain not allowed",
+			url:  "http://greetings.default.svc.cluster.local/hook",
+			err:  "DNS name of a Service",
+		},
+		{
+			name:          "other namespace",
+			url:           "http://greetings.kube-system.svc/hook",
+			clusterDomain: DefaultClusterDomain,
+			err:           `namespace "default"`,
+		},
+		{
+			name:          "other namespace with cluster domain",
+			url:           "http://greetings.default.svc.cluster.local/hook",
+			namespace:     "greeter-system",
+			clusterDomain: DefaultClusterDomain,
+			err:           `namespace "greeter-system"`,
+		},
+		{name: "too short", url: "http://greetings.svc/hook", clusterDomain: DefaultClusterDomain, err: "DNS name of a Service"},
+		{name: "not a service", url: "http://greetings.default.pod/hook", clusterDomain: DefaultClusterDomain, err: "DNS name of a Service"},
+		{name: "ip address", url: "http://10.0.0.1/hook", clusterDomain: DefaultClusterDomain, err: "DNS name of a Service"},
+		{name: "scheme", url: "ftp://greetings.default.svc/hook", clusterDomain: DefaultClusterDomain, err: "http or https"},
+		{name: "user information", url: "http://user@greetings.default.svc/hook", clusterDomain: DefaultClusterDomain, err: "user information"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			namespace := tt.namespace
+			if len(namespace) == 0 {
+				namespace = metav1.NamespaceDefault
+			}
+
+			err := validateServiceURL(tt.url, namespace, tt.clusterDomain)
+			if len(tt.err) == 0 {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("expected error containing %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestValidateCreate(t *testing.T) {
+	newYork := "America/New_York"
+	tests := []struct {
+		name     string
+		spec     v1alpha1.GreeterSpec
+		expected []string
+	}{
+		{
+			name: "one-shot",
+			spec: v1alpha1.GreeterSpec{Schedule: "2023-10-01 12:05:00", Message: "hello"},
+		},
+		{
+			name: "cron",
+			spec: v1alpha1.GreeterSpec{Cron: "*/5 * * * *", Message: "hello"},
+		},
+		{
+			// 12:00 in UTC is 08:00 in New York
+			name: "one-shot in time zone",
+			spec: v1alpha1.GreeterSpec{Schedule: "2023-10-01 08:05:00", TimeZone: &newYork, Message: "hello"},
+		},
+		{
+			name:     "past schedule",
+			spec:     v1alpha1.GreeterSpec{Schedule: "2023-10-01 11:59:59", Message: "hello"},
+			expected: []string{"FieldValueInvalid spec.schedule"},
+		},
+		{
+			name:     "past schedule in time zone",
+			spec:     v1alpha1.GreeterSpec{Schedule: "2023-10-01 07:59:00", TimeZone: &newYork, Message: "hello"},
+			expected: []string{"FieldValueInvalid spec.schedule"},
+		},
+		{
+			name:     "malformed schedule",
+			spec:     v1alpha1.GreeterSpec{Schedule: "2023-10-01T12:05:00Z", Message: "hello"},
+			expected: []string{"FieldValueInvalid spec.schedule"},
+		},
+		{
+			name:     "malformed cron",
+			spec:     v1alpha1.GreeterSpec{Cron: "every minute", Message: "hello"},
+			expected: []string{"FieldValueInvalid spec.cron"},
+		},
+		{
+			name:     "no schedule",
+			spec:     v1alpha1.GreeterSpec{Message: "hello"},
+			expected: []string{"FieldValueRequired spec.schedule"},
+		},
+		{
+			name:     "unknown time zone",
+			spec:     v1alpha1.GreeterSpec{Schedule: "2023-10-01 12:05:00", TimeZone: new(string), Message: "hello"},
+			expected: []string{"FieldValueInvalid spec.timeZone"},
+		},
+		{
+			name:     "no message",
+			spec:     v1alpha1.GreeterSpec{Cron: "*/5 * * * *"},
+			expected: []string{"FieldValueRequired spec.message"},
+		},
+		{
+			name:     "message too large",
+			spec:     v1alpha1.GreeterSpec{Cron: "*/5 * * * *", Message: strings.Repeat("a", 17)},
+			expected: []string{"FieldValueInvalid spec.message"},
+		},
+		{
+			name: "more than one sink",
+			spec: v1alpha1.GreeterSpec{Cron: "*/5 * * * *", Message: "hello", Delivery: v1alpha1.GreeterDelivery{
+				ConfigMap: &v1alpha1.ConfigMapDelivery{Name: "greetings", Key: "latest"},
+				HTTP:      &v1alpha1.HTTPDelivery{URL: "http://greetings.default.svc/hook"},
+			}},
+			expected: []string{"FieldValueForbidden spec.delivery"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			greeter := &v1alpha1.Greeter{ObjectMeta: metav1.ObjectMeta{Name: "validate", Namespace: metav1.NamespaceDefault}, Spec: tt.spec}
+			expectErrors(t, newValidator().ValidateCreate(greeter, validationNow), tt.expected...)
+		})
+	}
+}
+
+func TestValidateUpdate(t *testing.T) {
+	past := v1alpha1.GreeterSpec{Schedule: "2023-10-01 11:00:00", Message: "hello"}
+	future := v1alpha1.GreeterSpec{Schedule: "2023-10-01 13:00:00", Message: "hello"}
+
+	tests := []struct {
+		name     string
+		old, new v1alpha1.GreeterSpec
+		phase    string
+		expected []string
+	}{
+		{
+			// The schedule of a fired one-shot Greeter is in the past
+			name: "unchanged past schedule",
+			old:  past,
+			new:  v1alpha1.GreeterSpec{Schedule: past.Schedule, Message: "hello again"},
+		},
+		{
+			name:     "changed to past schedule",
+			old:      future,
+			new:      v1alpha1.GreeterSpec{Schedule: "2023-10-01 11:30:00", Message: "hello"},
+			expected: []string{"FieldValueInvalid spec.schedule"},
+		},
+		{
+			name: "changed to future schedule",
+			old:  past,
+			new:  future,
+		},
+		{
+			name:  "unchanged while running",
+			old:   future,
+			new:   future,
+			phase: v1alpha1.PhaseRunning,
+		},
+		{
+			name:     "schedule and message changed while running",
+			old:      future,
+			new:      v1alpha1.GreeterSpec{Schedule: "2023-10-01 14:00:00", Message: "hello again"},
+			phase:    v1alpha1.PhaseRunning,
+			expected: []string{"FieldValueForbidden spec.schedule", "FieldValueForbidden spec.message"},
+		},
+		{
+			name:  "suspended while running",
+			old:   future,
+			new:   v1alpha1.GreeterSpec{Schedule: future.Schedule, Message: "hello", Suspend: new(bool)},
+			phase: v1alpha1.PhaseRunning,
+		},
+		{
+			name:  "message changed after the run",
+			old:   future,
+			new:   v1alpha1.GreeterSpec{Schedule: future.Schedule, Message: "hello again"},
+			phase: v1alpha1.PhaseSucceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			old := newGreeter("validate", tt.old)
+			old.Status.Phase = tt.phase
+			greeter := newGreeter("validate", tt.new)
+			expectErrors(t, newValidator().ValidateUpdate(greeter, old, validationNow), tt.expected...)
+		})
+	}
+}
@@ -0,0 +1,300 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/cert"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+)
+
+// minCertValidity is the minimum remaining validity of a stored certificate,
+// a certificate expiring sooner is regenerated on startup.
+const minCertValidity = 30 * 24 * time.Hour
+
+// certReloadInterval is the interval the serving certificate is reloaded from
+// the Secret, so that a certificate regenerated by another replica is served
+// soon after it is published.
+const certReloadInterval = time.Minute
+
+// caBundleKey is the key of the CA bundle in the Secret, it holds the
+// certificate and the replaced certificates not expired yet.
+const caBundleKey = "ca.crt"
+
+// CertBootstrap provisions the self-signed serving certificate of the webhook
+// server. The certificate is stored in a Secret, so that all the replicas
+// serve with the same one, and published as the CA bundle of the webhook
+// configuration, so that the API server trusts it. The certificate replaced
+// by a regenerated one stays in the CA bundle until it expires, and the
+// serving certificate is reloaded from the Secret, so that the replicas still
+// serving the replaced certificate are trusted until they switch.
+type CertBootstrap struct {
+	// Client is used to manage the Secret and the webhook configuration.
+	Client kubernetes.Interface
+	// Namespace is the namespace of the Secret and the Service.
+	Namespace string
+	// SecretName is the name of the Secret holding the certificate.
+	SecretName string
+	// ServiceName is the name of the Service in front of the webhook server.
+	ServiceName string
+	// WebhookConfigName is the name of the ValidatingWebhookConfiguration
+	// whose CA bundle is updated.
+	WebhookConfigName string
+
+	// CRDClient is used to manage the CustomResourceDefinition.
+	CRDClient apiextensionsclientset.Interface
+	// CRDName is the name of the CustomResourceDefinition whose conversion
+	// webhook CA bundle is updated, it's skipped if empty.
+	CRDName string
+
+	// mu guards the certificate, which is served and reloaded concurrently.
+	mu          sync.RWMutex
+	certificate *tls.Certificate
+}
+
+// Bootstrap ensures the certificate is stored and trusted, the certificate is
+// served by GetCertificate afterwards.
+func (b *CertBootstrap) Bootstrap(ctx context.Context) error {
+	secret, err := b.ensureSecret(ctx)
+	if err != nil {
+		return err
+	}
+
+	caBundle := getCABundle(secret)
+	if err = b.updateCABundle(ctx, caBundle); err != nil {
+		return err
+	}
+
+	if len(b.CRDName) != 0 {
+		if err = b.updateConversionCABundle(ctx, caBundle); err != nil {
+			return err
+		}
+	}
+
+	return b.setCertificate(secret)
+}
+
+// GetCertificate returns the certificate to serve with, it's used as the
+// GetCertificate of the tls.Config of the server.
+func (b *CertBootstrap) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.certificate == nil {
+		return nil, fmt.Errorf("serving certificate is not bootstrapped")
+	}
+	return b.certificate, nil
+}
+
+// Reload reloads the serving certificate from the Secret periodically until
+// the context is done. The certificate served is kept if the Secret can't be
+// read.
+func (b *CertBootstrap) Reload(ctx context.Context) {
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := b.reload(ctx); err != nil {
+			klog.FromContext(ctx).Error(err, "Error reloading webhook serving certificate",
+				"secret", klog.KRef(b.Namespace, b.SecretName))
+		}
+	}, certReloadInterval)
+}
+
+// reload replaces the serving certificate with the one in the Secret.
+func (b *CertBootstrap) reload(ctx context.Context) error {
+	secret, err := b.Client.CoreV1().Secrets(b.Namespace).Get(ctx, b.SecretName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	return b.setCertificate(secret)
+}
+
+// setCertificate replaces the serving certificate with the one in the Secret.
+func (b *CertBootstrap) setCertificate(secret *corev1.Secret) error {
+	certificate, err := tls.X509KeyPair(secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey])
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.certificate = &certificate
+	return nil
+}
+
+// getCABundle returns the CA bundle of the Secret, it falls back to the
+// certificate for the Secrets stored without a CA bundle.
+func getCABundle(secret *corev1.Secret) []byte {
+	if caBundle := secret.Data[caBundleKey]; len(caBundle) != 0 {
+		return caBundle
+	}
+	return secret.Data[corev1.TLSCertKey]
+}
+
+// ensureSecret returns the Secret holding the certificate and key, they are
+// generated when the Secret doesn't exist or the certificate is expiring.
+func (b *CertBootstrap) ensureSecret(ctx context.Context) (*corev1.Secret, error) {
+	logger := klog.FromContext(ctx)
+	secrets := b.Client.CoreV1().Secrets(b.Namespace)
+
+	secret, err := secrets.Get(ctx, b.SecretName, metav1.GetOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, err
+	}
+
+	exists := err == nil
+	if exists && b.isValid(secret) {
+		return secret, nil
+	}
+
+	// The certificate is valid for the in-cluster DNS names of the Service
+	host := fmt.Sprintf("%s.%s.svc", b.ServiceName, b.Namespace)
+	certPEM, keyPEM, err := cert.GenerateSelfSignedCertKey(host, nil, []string{
+		b.ServiceName,
+		fmt.Sprintf("%s.%s", b.ServiceName, b.Namespace),
+		host + ".cluster.local",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The replaced certificate is still trusted, as it's served by the other
+	// replicas until they reload the Secret
+	caBundle := certPEM
+	if exists {
+		caBundle = append(append([]byte{}, certPEM...), getUnexpiredCerts(getCABundle(secret))...)
+	}
+
+	data := map[string][]byte{
+		corev1.TLSCertKey:       certPEM,
+		corev1.TLSPrivateKeyKey: keyPEM,
+		caBundleKey:             caBundle,
+	}
+	if !exists {
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: b.SecretName, Namespace: b.Namespace},
+			Type:       corev1.SecretTypeTLS,
+			Data:       data,
+		}
+		if secret, err = secrets.Create(ctx, secret, metav1.CreateOptions{}); errors.IsAlreadyExists(err) {
+			// Another replica won the race, serve with its certificate instead
+			return b.ensureSecret(ctx)
+		}
+	} else {
+		secret = secret.DeepCopy()
+		secret.Data = data
+		if secret, err = secrets.Update(ctx, secret, metav1.UpdateOptions{}); errors.IsConflict(err) {
+			return b.ensureSecret(ctx)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("Generated webhook serving certificate", "secret", klog.KObj(secret))
+	return secret, nil
+}
+
+// getUnexpiredCerts returns the PEM encoded certificates of the bundle which
+// are not expired yet.
+func getUnexpiredCerts(caBundle []byte) []byte {
+	certs, err := cert.ParseCertsPEM(caBundle)
+	if err != nil {
+		return nil
+	}
+
+	var unexpired []*x509.Certificate
+	for _, c := range certs {
+		if time.Now().Before(c.NotAfter) {
+			unexpired = append(unexpired, c)
+		}
+	}
+	if len(unexpired) == 0 {
+		return nil
+	}
+
+	data, err := cert.EncodeCertificates(unexpired...)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// isValid returns true if the Secret contains a certificate not expiring soon.
+func (b *CertBootstrap) isValid(secret *corev1.Secret) bool {
+	certs, err := cert.ParseCertsPEM(secret.Data[corev1.TLSCertKey])
+	if err != nil || len(certs) == 0 || len(secret.Data[corev1.TLSPrivateKeyKey]) == 0 {
+		return false
+	}
+
+	for _, c := range certs {
+		if time.Until(c.NotAfter) < minCertValidity {
+			return false
+		}
+	}
+	// The Service may have been renamed since the certificate is generated
+	return certs[0].VerifyHostname(fmt.Sprintf("%s.%s.svc", b.ServiceName, b.Namespace)) == nil
+}
+
+// updateCABundle sets the CA bundle of all the webhooks in
+// the ValidatingWebhookConfiguration.
+func (b *CertBootstrap) updateCABundle(ctx context.Context, caBundle []byte) error {
+	configs := b.Client.AdmissionregistrationV1().ValidatingWebhookConfigurations()
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		config, err := configs.Get(ctx, b.WebhookConfigName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		changed := false
+		for i := range config.Webhooks {
+			if !bytes.Equal(config.Webhooks[i].ClientConfig.CABundle, caBundle) {
+				config.Webhooks[i].ClientConfig.CABundle = caBundle
+				changed = true
+			}
+		}
+		if !changed {
+			return nil
+		}
+
+		_, err = configs.Update(ctx, config, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// updateConversionCABundle sets the CA bundle of the
+// conversion webhook of the CustomResourceDefinition.
+func (b *CertBootstrap) updateConversionCABundle(ctx context.Context, caBundle []byte) error {
+	crds := b.CRDClient.ApiextensionsV1().CustomResourceDefinitions()
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		crd, err := crds.Get(ctx, b.CRDName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		conversion := crd.Spec.Conversion
+		if conversion == nil || conversion.Webhook == nil || conversion.Webhook.ClientConfig == nil {
+			return fmt.Errorf("CustomResourceDefinition %s has no conversion webhook", b.CRDName)
+		}
+		if bytes.Equal(conversion.Webhook.ClientConfig.CABundle, caBundle) {
+			return nil
+		}
+
+		conversion.Webhook.ClientConfig.CABundle = caBundle
+		_, err = crds.Update(ctx, crd, metav1.UpdateOptions{})
+		return err
+	})
+}
@@ -0,0 +1,110 @@
+package webhook
+
+import (
+	"context"
+	"crypto/x509"
+	"testing"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/util/cert"
+)
+
+func newCertBootstrap(client *kubefake.Clientset, serviceName string) *CertBootstrap {
+	return &CertBootstrap{
+		Client:            client,
+		Namespace:         "greeter-system",
+		SecretName:        "greeter-webhook-tls",
+		ServiceName:       serviceName,
+		WebhookConfigName: "greeter-webhook",
+	}
+}
+
+func getServingCert(t *testing.T, b *CertBootstrap) *x509.Certificate {
+	certificate, err := b.GetCertificate(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	leaf, err := x509.ParseCertificate(certificate.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return leaf
+}
+
+// expectTrusted checks the certificate served by each of the replicas is
+// trusted by the CA bundle of the webhook configuration.
+func expectTrusted(t *testing.T, client *kubefake.Clientset, replicas ...*CertBootstrap) {
+	t.Helper()
+
+	config, err := client.AdmissionregistrationV1().ValidatingWebhookConfigurations().
+		Get(context.Background(), "greeter-webhook", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots, err := cert.NewPoolFromBytes(config.Webhooks[0].ClientConfig.CABundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, b := range replicas {
+		opts := x509.VerifyOptions{Roots: roots, DNSName: b.ServiceName + ".greeter-system.svc"}
+		if _, err = getServingCert(t, b).Verify(opts); err != nil {
+			t.Fatalf("expected the certificate of replica %d to be trusted, got %v", i, err)
+		}
+	}
+}
+
+func TestCertBootstrapRotation(t *testing.T) {
+	ctx := context.Background()
+	client := kubefake.NewSimpleClientset(&admissionregistrationv1.ValidatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: "greeter-webhook"},
+		Webhooks:   []admissionregistrationv1.ValidatingWebhook{{Name: "greeters.example.org"}},
+	})
+
+	if _, err := newCertBootstrap(client, "greeter-webhook").GetCertificate(nil); err == nil {
+		t.Fatalf("expected no certificate before the bootstrap")
+	}
+
+	first := newCertBootstrap(client, "greeter-webhook")
+	if err := first.Bootstrap(ctx); err != nil {
+		t.Fatal(err)
+	}
+	firstCert := getServingCert(t, first)
+	expectTrusted(t, client, first)
+
+	// The certificate is regenerated by the new replica as the Service is renamed
+	second := newCertBootstrap(client, "greeter-webhook-v2")
+	if err := second.Bootstrap(ctx); err != nil {
+		t.Fatal(err)
+	}
+	secondCert := getServingCert(t, second)
+	if firstCert.Equal(secondCert) {
+		t.Fatalf("expected the certificate to be regenerated")
+	}
+	// The first replica still serves the replaced certificate, which is trusted
+	if !getServingCert(t, first).Equal(firstCert) {
+		t.Fatalf("expected the first replica to serve its certificate until reloaded")
+	}
+	expectTrusted(t, client, first, second)
+
+	// The valid certificate is served as is by a restarted replica, the CA
+	// bundle is kept
+	third := newCertBootstrap(client, "greeter-webhook-v2")
+	if err := third.Bootstrap(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if !getServingCert(t, third).Equal(secondCert) {
+		t.Fatalf("expected the stored certificate to be served")
+	}
+	expectTrusted(t, client, first, second, third)
+
+	if err := first.reload(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if !getServingCert(t, first).Equal(secondCert) {
+		t.Fatalf("expected the first replica to serve the regenerated certificate after reloading")
+	}
+}
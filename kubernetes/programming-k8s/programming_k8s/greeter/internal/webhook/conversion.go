@@ -0,0 +1,118 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/klog/v2"
+
+	"github.com/wjiec/programming_k8s/greeter/pkg/apis/greeter/v1alpha1"
+	"github.com/wjiec/programming_k8s/greeter/pkg/apis/greeter/v1beta1"
+)
+
+// ConvertPath is the path of the conversion webhook for Greeters.
+const ConvertPath = "/convert"
+
+var (
+	// scheme knows all the served versions of Greeter and the conversions
+	// between them.
+	scheme = runtime.NewScheme()
+	codecs = serializer.NewCodecFactory(scheme)
+)
+
+func init() {
+	utilruntime.Must(v1alpha1.AddToScheme(scheme))
+	utilruntime.Must(v1beta1.AddToScheme(scheme))
+}
+
+// ConversionHandler serves the ConversionReview requests of Greeters.
+type ConversionHandler struct{}
+
+// ServeHTTP implements the http.Handler interface.
+func (h *ConversionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := klog.FromContext(r.Context())
+	if r.Method != http.MethodPost {
+		http.Error(w, "only POST is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestBytes))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var review apiextensionsv1.ConversionReview
+	if err := json.Unmarshal(body, &review); err != nil {
+		http.Error(w, fmt.Sprintf("unable to decode ConversionReview: %v", err), http.StatusBadRequest)
+		return
+	}
+	if review.Request == nil {
+		http.Error(w, "ConversionReview without request", http.StatusBadRequest)
+		return
+	}
+
+	review.Response = convert(review.Request)
+	review.Response.UID = review.Request.UID
+	review.Request = nil
+	logger.V(4).Info("Greeters converted", "uid", review.Response.UID, "result", review.Response.Result.Status)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(&review); err != nil {
+		logger.Error(err, "Error writing ConversionReview response")
+	}
+}
+
+// convert converts all the objects in the request to the desired version,
+// the whole request fails if any of them can't be converted.
+func convert(request *apiextensionsv1.ConversionRequest) *apiextensionsv1.ConversionResponse {
+	gv, err := schema.ParseGroupVersion(request.DesiredAPIVersion)
+	if err != nil {
+		return failedResponse(err)
+	}
+
+	decoder := codecs.UniversalDeserializer()
+
+	converted := make([]runtime.RawExtension, 0, len(request.Objects))
+	for _, object := range request.Objects {
+		in, _, err := decoder.Decode(object.Raw, nil, nil)
+		if err != nil {
+			return failedResponse(err)
+		}
+
+		out, err := scheme.ConvertToVersion(in, gv)
+		if err != nil {
+			return failedResponse(err)
+		}
+
+		// The type meta of the object is set to the desired version
+		raw, err := json.Marshal(out)
+		if err != nil {
+			return failedResponse(err)
+		}
+		converted = append(converted, runtime.RawExtension{Raw: raw})
+	}
+
+	return &apiextensionsv1.ConversionResponse{
+		ConvertedObjects: converted,
+		Result:           metav1.Status{Status: metav1.StatusSuccess},
+	}
+}
+
+// failedResponse returns a response failing the conversion with the error.
+func failedResponse(err error) *apiextensionsv1.ConversionResponse {
+	return &apiextensionsv1.ConversionResponse{
+		Result: metav1.Status{
+			Status:  metav1.StatusFailure,
+			Message: err.Error(),
+		},
+	}
+}
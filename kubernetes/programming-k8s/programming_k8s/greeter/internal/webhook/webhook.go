@@ -0,0 +1,112 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/klog/v2"
+
+	"github.com/wjiec/programming_k8s/greeter/internal/greeter"
+	"github.com/wjiec/programming_k8s/greeter/pkg/apis/greeter/v1alpha1"
+)
+
+const (
+	// ValidatePath is the path of the validating webhook for v1alpha1 Greeters.
+	ValidatePath = "/validate-example-org-v1alpha1-greeter"
+
+	// maxRequestBytes limits the size of the AdmissionReview read from the
+	// API server, which is far larger than any reasonable Greeter.
+	maxRequestBytes = 3 * 1024 * 1024
+)
+
+// ValidatingHandler serves the AdmissionReview requests of Greeters and
+// rejects the ones the controller would never be able to fire.
+type ValidatingHandler struct {
+	// Validator validates the Greeters in the requests.
+	Validator *greeter.Validator
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (h *ValidatingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := klog.FromContext(r.Context())
+	if r.Method != http.MethodPost {
+		http.Error(w, "only POST is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestBytes))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var review admissionv1.AdmissionReview
+	if err := json.Unmarshal(body, &review); err != nil {
+		http.Error(w, fmt.Sprintf("unable to decode AdmissionReview: %v", err), http.StatusBadRequest)
+		return
+	}
+	if review.Request == nil {
+		http.Error(w, "AdmissionReview without request", http.StatusBadRequest)
+		return
+	}
+
+	review.Response = h.review(review.Request)
+	review.Response.UID = review.Request.UID
+	review.Request = nil
+	logger.V(4).Info("Greeter reviewed", "uid", review.Response.UID, "allowed", review.Response.Allowed)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(&review); err != nil {
+		logger.Error(err, "Error writing AdmissionReview response")
+	}
+}
+
+// review validates the Greeter in the request against its old version.
+func (h *ValidatingHandler) review(request *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	var instance, old v1alpha1.Greeter
+	if err := json.Unmarshal(request.Object.Raw, &instance); err != nil {
+		return deniedResponse(errors.NewBadRequest(fmt.Sprintf("unable to decode Greeter: %v", err)))
+	}
+	// The namespace of the object may be omitted in the request
+	if len(instance.Namespace) == 0 {
+		instance.Namespace = request.Namespace
+	}
+
+	now := time.Now()
+	var allErrs field.ErrorList
+	switch request.Operation {
+	case admissionv1.Create:
+		allErrs = h.Validator.ValidateCreate(&instance, now)
+	case admissionv1.Update:
+		if err := json.Unmarshal(request.OldObject.Raw, &old); err != nil {
+			return deniedResponse(errors.NewBadRequest(fmt.Sprintf("unable to decode old Greeter: %v", err)))
+		}
+		if len(old.Namespace) == 0 {
+			old.Namespace = request.Namespace
+		}
+		allErrs = h.Validator.ValidateUpdate(&instance, &old, now)
+	default:
+		return &admissionv1.AdmissionResponse{Allowed: true}
+	}
+
+	if len(allErrs) != 0 {
+		gk := v1alpha1.SchemeGroupVersion.WithKind("Greeter").GroupKind()
+		return deniedResponse(errors.NewInvalid(gk, instance.Name, allErrs))
+	}
+	return &admissionv1.AdmissionResponse{Allowed: true}
+}
+
+// deniedResponse returns a response rejecting the request with the error.
+func deniedResponse(err *errors.StatusError) *admissionv1.AdmissionResponse {
+	status := err.Status()
+	return &admissionv1.AdmissionResponse{
+		Allowed: false,
+		Result:  &status,
+	}
+}
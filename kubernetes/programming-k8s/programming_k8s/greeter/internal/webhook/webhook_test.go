@@ -0,0 +1,181 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/wjiec/programming_k8s/greeter/internal/greeter"
+	"github.com/wjiec/programming_k8s/greeter/pkg/apis/greeter/v1alpha1"
+)
+
+const (
+	pastSchedule   = "2000-01-01 00:00:00"
+	futureSchedule = "2999-01-01 00:00:00"
+)
+
+func newServer(t *testing.T) *httptest.Server {
+	handler := &ValidatingHandler{Validator: &greeter.Validator{DefaultTimeZone: time.UTC}}
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func rawGreeter(t *testing.T, spec v1alpha1.GreeterSpec, phase string) runtime.RawExtension {
+	raw, err := json.Marshal(&v1alpha1.Greeter{
+		TypeMeta:   metav1.TypeMeta{APIVersion: v1alpha1.SchemeGroupVersion.String(), Kind: "Greeter"},
+		ObjectMeta: metav1.ObjectMeta{Name: "hello", Namespace: "default"},
+		Spec:       spec,
+		Status:     v1alpha1.GreeterStatus{Phase: phase},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return runtime.RawExtension{Raw: raw}
+}
+
+func postReview(t *testing.T, server *httptest.Server, body []byte) *http.Response {
+	t.Helper()
+	resp, err := http.Post(server.URL+ValidatePath, "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func review(t *testing.T, server *httptest.Server, request *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	t.Helper()
+	body, err := json.Marshal(&admissionv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{APIVersion: admissionv1.SchemeGroupVersion.String(), Kind: "AdmissionReview"},
+		Request:  request,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp := postReview(t, server, body)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+	var result admissionv1.AdmissionReview
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Request != nil {
+		t.Fatalf("expected the request to be dropped from the response, got %v", result.Request)
+	}
+	if result.Response == nil || result.Response.UID != request.UID {
+		t.Fatalf("expected a response to %q, got %v", request.UID, result.Response)
+	}
+	return result.Response
+}
+
+func TestValidatingHandler(t *testing.T) {
+	server := newServer(t)
+	future := v1alpha1.GreeterSpec{Schedule: futureSchedule, Message: "hello"}
+	past := v1alpha1.GreeterSpec{Schedule: pastSchedule, Message: "hello"}
+
+	tests := []struct {
+		name      string
+		operation admissionv1.Operation
+		object    v1alpha1.GreeterSpec
+		old       *v1alpha1.GreeterSpec
+		oldPhase  string
+		allowed   bool
+	}{
+		{name: "create", operation: admissionv1.Create, object: future, allowed: true},
+		{name: "create in the past", operation: admissionv1.Create, object: past},
+		{name: "create without message", operation: admissionv1.Create, object: v1alpha1.GreeterSpec{Cron: "* * * * *"}},
+		{
+			name:      "update with unchanged past schedule",
+			operation: admissionv1.Update,
+			object:    v1alpha1.GreeterSpec{Schedule: pastSchedule, Message: "hello again"},
+			old:       &past,
+			allowed:   true,
+		},
+		{name: "update to past schedule", operation: admissionv1.Update, object: past, old: &future},
+		{
+			name:      "update while running",
+			operation: admissionv1.Update,
+			object:    v1alpha1.GreeterSpec{Schedule: futureSchedule, Message: "hello again"},
+			old:       &future,
+			oldPhase:  v1alpha1.PhaseRunning,
+		},
+		{name: "delete", operation: admissionv1.Delete, object: past, allowed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := &admissionv1.AdmissionRequest{
+				UID:       types.UID("uid-" + tt.name),
+				Operation: tt.operation,
+				Object:    rawGreeter(t, tt.object, ""),
+			}
+			if tt.old != nil {
+				request.OldObject = rawGreeter(t, *tt.old, tt.oldPhase)
+			}
+
+			response := review(t, server, request)
+			if response.Allowed != tt.allowed {
+				t.Fatalf("expected allowed to be %v, got %v (%v)", tt.allowed, response.Allowed, response.Result)
+			}
+			if !tt.allowed && (response.Result == nil || response.Result.Code != http.StatusUnprocessableEntity) {
+				t.Fatalf("expected an invalid result, got %v", response.Result)
+			}
+		})
+	}
+}
+
+func TestValidatingHandlerMalformedObject(t *testing.T) {
+	server := newServer(t)
+
+	response := review(t, server, &admissionv1.AdmissionRequest{
+		UID:       "malformed",
+		Operation: admissionv1.Create,
+		Object:    runtime.RawExtension{Raw: []byte(`{"spec":"not an object"}`)},
+	})
+	if response.Allowed || response.Result == nil || response.Result.Code != http.StatusBadRequest {
+		t.Fatalf("expected a bad request, got %v", response)
+	}
+}
+
+func TestValidatingHandlerMalformedReview(t *testing.T) {
+	server := newServer(t)
+
+	tests := []struct {
+		name   string
+		method string
+		body   string
+		status int
+	}{
+		{name: "not a post", method: http.MethodGet, status: http.StatusMethodNotAllowed},
+		{name: "not json", method: http.MethodPost, body: "not json", status: http.StatusBadRequest},
+		{name: "no request", method: http.MethodPost, body: `{"kind":"AdmissionReview"}`, status: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, server.URL+ValidatePath, bytes.NewBufferString(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.status {
+				t.Fatalf("expected status %d, got %d", tt.status, resp.StatusCode)
+			}
+		})
+	}
+}
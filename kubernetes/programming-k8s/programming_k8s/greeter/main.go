@@ -1,25 +1,66 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
+	"net/http"
+	"os"
 	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"time"
+	// Embed the IANA time zone database, so that the time zone of greeters
+	// doesn't depend on the zoneinfo files installed on the host.
+	_ "time/tzdata"
 
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/uuid"
 	kubeinformers "k8s.io/client-go/informers"
+	coreinformers "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
 	"k8s.io/client-go/util/homedir"
 	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/manager/signals"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 
 	"github.com/wjiec/programming_k8s/greeter/internal/greeter"
 	clientset "github.com/wjiec/programming_k8s/greeter/pkg/generated/clientset/versioned"
 	informers "github.com/wjiec/programming_k8s/greeter/pkg/generated/informers/externalversions"
+	greeterinformers "github.com/wjiec/programming_k8s/greeter/pkg/generated/informers/externalversions/greeter/v1alpha1"
 )
 
 var (
-	masterURL  string
-	kubeconfig string
+	masterURL       string
+	kubeconfig      string
+	defaultTimeZone string
+	maxMessageSize  int
+	clusterDomain   string
+	maxOutputBytes  int64
+	metricsAddr     string
+	probeAddr       string
+	workers         int
+	resync          time.Duration
+	namespaces      string
+	greeterSelector string
+
+	maxRunning             int
+	maxRunningPerNamespace int
+	launchQPS              float64
+	launchBurst            int
+
+	leaderElect                 bool
+	leaderElectionID            string
+	leaderElectionNamespace     string
+	leaderElectionLeaseDuration time.Duration
+	leaderElectionRenewDeadline time.Duration
+	leaderElectionRetryPeriod   time.Duration
 )
 
 func init() {
@@ -30,6 +71,27 @@ func init() {
 
 	flag.StringVar(&kubeconfig, "kubeconfig", defaultKubeConfig, "Path to a kubeconfig. Only required if out-of-cluster.")
 	flag.StringVar(&masterURL, "master", "", "The address of the Kubernetes API server. Overrides any value in kubeconfig. Only required if out-of-cluster.")
+	flag.StringVar(&defaultTimeZone, "default-timezone", "UTC", "The IANA time zone name used for greeters which don't specify one.")
+	flag.IntVar(&maxMessageSize, "max-message-size", 0, "The maximum size in bytes of the message of greeters, greeters with a larger message are never fired. Zero means unlimited.")
+	flag.StringVar(&clusterDomain, "cluster-domain", greeter.DefaultClusterDomain, "The DNS domain of the cluster, the host of an HTTP sink must be a Service optionally qualified by it.")
+	flag.Int64Var(&maxOutputBytes, "max-output-bytes", 1024, "The maximum size in bytes of the output captured from the runner pod into the greeter status. Zero disables the capture.")
+	flag.IntVar(&workers, "workers", 2, "The number of workers syncing greeters concurrently.")
+	flag.DurationVar(&resync, "resync", 30*time.Second, "The resync period of the informers. Zero disables the resync.")
+	flag.StringVar(&namespaces, "namespaces", "", "A comma-separated allow-list of namespaces the greeters are watched in, each of them is watched by its own informers. Empty means all namespaces.")
+	flag.StringVar(&greeterSelector, "greeter-selector", "", "A label selector restricting the greeters handled by the controller. Empty means all greeters.")
+	flag.IntVar(&maxRunning, "max-running", 0, "The maximum number of greeter runs active at the same time cluster-wide, the due greeters beyond it are throttled. Zero means unlimited.")
+	flag.IntVar(&maxRunningPerNamespace, "max-running-per-namespace", 0, "The maximum number of greeter runs active at the same time in each namespace. Zero means unlimited.")
+	flag.Float64Var(&launchQPS, "launch-qps", 0, "The maximum rate of launching greeter runs per second. Zero means unlimited.")
+	flag.IntVar(&launchBurst, "launch-burst", 10, "The maximum burst of launching greeter runs, only used with --launch-qps.")
+	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to. Set to 0 to disable the metrics server.")
+	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to. Set to 0 to disable the probe server.")
+
+	flag.BoolVar(&leaderElect, "leader-elect", false, "Enable leader election for the controller. Enabling this will ensure there is only one active controller.")
+	flag.StringVar(&leaderElectionID, "leader-elect-id", "greeter-controller", "The name of the Lease object used for leader election.")
+	flag.StringVar(&leaderElectionNamespace, "leader-elect-namespace", "", "The namespace of the Lease object used for leader election. Defaults to the namespace of the pod (POD_NAMESPACE), or default.")
+	flag.DurationVar(&leaderElectionLeaseDuration, "leader-elect-lease-duration", 15*time.Second, "The duration that non-leader candidates will wait after observing a leadership renewal before attempting to acquire leadership.")
+	flag.DurationVar(&leaderElectionRenewDeadline, "leader-elect-renew-deadline", 10*time.Second, "The duration that the leader will retry refreshing leadership before giving up.")
+	flag.DurationVar(&leaderElectionRetryPeriod, "leader-elect-retry-period", 2*time.Second, "The duration the candidates should wait between tries of actions.")
 }
 
 func main() {
@@ -46,6 +108,12 @@ func main() {
 		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
 	}
 
+	timeZone, err := greeter.LoadTimeZone(defaultTimeZone)
+	if err != nil {
+		logger.Error(err, "Error loading default time zone")
+		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+	}
+
 	kubeClientset, err := kubernetes.NewForConfig(cfg)
 	if err != nil {
 		logger.Error(err, "Error building kubernetes clientset")
@@ -58,22 +126,191 @@ func main() {
 		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
 	}
 
-	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeClientset, time.Second*30)
-	greeterInformerFactory := informers.NewSharedInformerFactory(greeterClientset, time.Second*30)
+	if _, err = labels.Parse(greeterSelector); err != nil {
+		logger.Error(err, "Error parsing greeter selector")
+		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+	}
+
+	// each namespace in the allow-list is watched by its own informers, so
+	// that only the objects in the namespaces are cached, and the controller
+	// only needs the permissions in them.
+	allowedNamespaces := splitNamespaces(namespaces)
+	watchedNamespaces := allowedNamespaces
+	if len(watchedNamespaces) == 0 {
+		watchedNamespaces = []string{metav1.NamespaceAll}
+	}
+
+	var kubeInformerFactories []kubeinformers.SharedInformerFactory
+	var greeterInformerFactories []informers.SharedInformerFactory
+	var podInformers []coreinformers.PodInformer
+	var greeterInformers []greeterinformers.GreeterInformer
+	for _, namespace := range watchedNamespaces {
+		// only the runner pods are cached, instead of all the pods in the cluster
+		kubeInformerFactory := kubeinformers.NewSharedInformerFactoryWithOptions(kubeClientset, resync,
+			kubeinformers.WithNamespace(namespace),
+			kubeinformers.WithTweakListOptions(greeter.TweakPodListOptions))
+		greeterInformerFactory := informers.NewSharedInformerFactoryWithOptions(greeterClientset, resync,
+			informers.WithNamespace(namespace),
+			informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+				options.LabelSelector = greeterSelector
+			}))
+
+		kubeInformerFactories = append(kubeInformerFactories, kubeInformerFactory)
+		greeterInformerFactories = append(greeterInformerFactories, greeterInformerFactory)
+		podInformers = append(podInformers, kubeInformerFactory.Core().V1().Pods())
+		greeterInformers = append(greeterInformers, greeterInformerFactory.Greeter().V1alpha1().Greeters())
+	}
 
 	// create controller
 	controller := greeter.NewController(ctx,
 		kubeClientset, greeterClientset,
-		kubeInformerFactory.Core().V1().Pods(),
-		greeterInformerFactory.Greeter().V1alpha1().Greeters())
+		podInformers,
+		greeterInformers,
+		greeter.WithDefaultTimeZone(timeZone),
+		greeter.WithMaxMessageSize(maxMessageSize),
+		greeter.WithClusterDomain(clusterDomain),
+		greeter.WithMaxOutputBytes(maxOutputBytes),
+		greeter.WithNamespaces(allowedNamespaces...),
+		greeter.WithMaxRunning(maxRunning, maxRunningPerNamespace),
+		greeter.WithLaunchRate(launchQPS, launchBurst))
+
+	// leading is set once the controller starts, a standby replica has no
+	// caches to sync, so it's always ready to take over the leadership.
+	var leading atomic.Bool
+	metrics.Registry.MustRegister(controller.Collector())
+	serve(ctx, "metrics", metricsAddr, promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{}))
+
+	probeMux := http.NewServeMux()
+	probeMux.Handle("/healthz", http.StripPrefix("/healthz", &healthz.Handler{Checks: map[string]healthz.Checker{
+		"ping": healthz.Ping,
+	}}))
+	probeMux.Handle("/readyz", http.StripPrefix("/readyz", &healthz.Handler{Checks: map[string]healthz.Checker{
+		"informer-sync": func(req *http.Request) error {
+			if !leading.Load() {
+				return nil
+			}
+			return controller.ReadyzCheck(req)
+		},
+	}}))
+	serve(ctx, "probe", probeAddr, probeMux)
+
+	run := func(ctx context.Context) {
+		leading.Store(true)
+
+		// notice that there is no need to run Start methods in a separate goroutine. (i.e. go kubeInformerFactory.Start(ctx.done())
+		// Start method is non-blocking and runs all registered informers in a dedicated goroutine.
+		for _, kubeInformerFactory := range kubeInformerFactories {
+			kubeInformerFactory.Start(ctx.Done())
+		}
+		for _, greeterInformerFactory := range greeterInformerFactories {
+			greeterInformerFactory.Start(ctx.Done())
+		}
 
-	// notice that there is no need to run Start methods in a separate goroutine. (i.e. go kubeInformerFactory.Start(ctx.done())
-	// Start method is non-blocking and runs all registered informers in a dedicated goroutine.
-	kubeInformerFactory.Start(ctx.Done())
-	greeterInformerFactory.Start(ctx.Done())
+		if err := controller.Run(ctx, workers); err != nil {
+			logger.Error(err, "Error running controller")
+			klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+		}
+	}
+
+	if !leaderElect {
+		run(ctx)
+		return
+	}
 
-	if err = controller.Run(ctx, 2); err != nil {
-		logger.Error(err, "Error running controller")
+	// the identity of the candidate must be unique, even for the pods with the same hostname
+	id, err := os.Hostname()
+	if err != nil {
+		logger.Error(err, "Error getting hostname")
 		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
 	}
+	id = id + "_" + string(uuid.NewUUID())
+
+	namespace := leaderElectionNamespace
+	if len(namespace) == 0 {
+		if namespace = os.Getenv("POD_NAMESPACE"); len(namespace) == 0 {
+			namespace = metav1.NamespaceDefault
+		}
+	}
+
+	// only the leader starts the informers and workers, so that the replicas
+	// never create the same pods or race on updating the status.
+	leaderelection.RunOrDie(ctx, leaderelection.LeaderElectionConfig{
+		Lock: &resourcelock.LeaseLock{
+			LeaseMeta: metav1.ObjectMeta{
+				Name:      leaderElectionID,
+				Namespace: namespace,
+			},
+			Client: kubeClientset.CoordinationV1(),
+			LockConfig: resourcelock.ResourceLockConfig{
+				Identity: id,
+			},
+		},
+		// the workers are stopped as soon as the context is cancelled, so it's
+		// safe to release the lease on shutdown for a faster failover.
+		ReleaseOnCancel: true,
+		LeaseDuration:   leaderElectionLeaseDuration,
+		RenewDeadline:   leaderElectionRenewDeadline,
+		RetryPeriod:     leaderElectionRetryPeriod,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: run,
+			OnStoppedLeading: func() {
+				// we can't continue as the caches and work queue are already
+				// shut down, exit and let the replica start over as a candidate.
+				if ctx.Err() == nil {
+					logger.Info("Leader election lost", "identity", id)
+				}
+				klog.FlushAndExit(klog.ExitFlushTimeout, 0)
+			},
+			OnNewLeader: func(identity string) {
+				if identity != id {
+					logger.Info("New leader elected", "identity", identity)
+				}
+			},
+		},
+		Name: leaderElectionID,
+	})
+}
+
+// splitNamespaces returns the non-empty namespaces in the comma-separated list.
+func splitNamespaces(list string) []string {
+	var namespaces []string
+	for _, namespace := range strings.Split(list, ",") {
+		if namespace = strings.TrimSpace(namespace); len(namespace) != 0 {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	return namespaces
+}
+
+// serve runs a http server with the handler on addr in the background until
+// the ctx is done, an addr of "0" disables the server.
+func serve(ctx context.Context, name, addr string, handler http.Handler) {
+	if len(addr) == 0 || addr == "0" {
+		return
+	}
+
+	logger := klog.FromContext(ctx).WithValues("server", name, "addr", addr)
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		logger.Info("Starting server")
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error(err, "Error running server")
+			klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+		}
+	}()
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			logger.Error(err, "Error shutting down server")
+		}
+	}()
 }
@@ -0,0 +1,63 @@
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/conversion"
+
+	"github.com/wjiec/programming_k8s/greeter/pkg/apis/greeter/v1beta1"
+)
+
+// Convert_v1alpha1_GreeterSpec_To_v1beta1_GreeterSpec converts the free-form
+// schedule into the typed schedule, and the message and template into the
+// delivery block. The cron schedule takes precedence over the one-shot time,
+// which is kept anyway, so that the conversion back is lossless.
+func Convert_v1alpha1_GreeterSpec_To_v1beta1_GreeterSpec(in *GreeterSpec, out *v1beta1.GreeterSpec, s conversion.Scope) error {
+	if err := autoConvert_v1alpha1_GreeterSpec_To_v1beta1_GreeterSpec(in, out, s); err != nil {
+		return err
+	}
+
+	out.Schedule.Type = v1beta1.ScheduleTypeOnce
+	if len(in.Cron) != 0 {
+		out.Schedule.Type = v1beta1.ScheduleTypeCron
+	}
+	out.Schedule.At = in.Schedule
+	out.Schedule.Cron = in.Cron
+	out.Schedule.TimeZone = in.TimeZone
+
+	out.Delivery.Message = in.Message
+	out.Delivery.Pod = nil
+	if in.Template != nil {
+		out.Delivery.Pod = &v1beta1.PodDelivery{Template: *in.Template}
+	}
+	return nil
+}
+
+// Convert_v1beta1_GreeterSpec_To_v1alpha1_GreeterSpec converts the typed
+// schedule and the delivery block back into the flat fields.
+func Convert_v1beta1_GreeterSpec_To_v1alpha1_GreeterSpec(in *v1beta1.GreeterSpec, out *GreeterSpec, s conversion.Scope) error {
+	if err := autoConvert_v1beta1_GreeterSpec_To_v1alpha1_GreeterSpec(in, out, s); err != nil {
+		return err
+	}
+
+	// The type of the schedule is implied by whether the cron is set, so the
+	// cron of a one-shot schedule is dropped instead of making it recurring.
+	out.Schedule = in.Schedule.At
+	out.Cron = in.Schedule.Cron
+	if in.Schedule.Type == v1beta1.ScheduleTypeOnce {
+		out.Cron = ""
+	}
+	out.TimeZone = in.Schedule.TimeZone
+
+	out.Message = in.Delivery.Message
+	out.Template = nil
+	if in.Delivery.Pod != nil {
+		out.Template = &in.Delivery.Pod.Template
+	}
+	return nil
+}
+
+// Convert_v1beta1_GreeterDelivery_To_v1alpha1_GreeterDelivery converts the
+// sinks of the delivery block, the message and the pod are converted along
+// with the spec as they are flat fields in v1alpha1.
+func Convert_v1beta1_GreeterDelivery_To_v1alpha1_GreeterDelivery(in *v1beta1.GreeterDelivery, out *GreeterDelivery, s conversion.Scope) error {
+	return autoConvert_v1beta1_GreeterDelivery_To_v1alpha1_GreeterDelivery(in, out, s)
+}
@@ -0,0 +1,147 @@
+package v1alpha1_test
+
+import (
+	"math/rand"
+	"testing"
+
+	fuzz "github.com/google/gofuzz"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/diff"
+
+	"github.com/wjiec/programming_k8s/greeter/pkg/apis/greeter/v1alpha1"
+	"github.com/wjiec/programming_k8s/greeter/pkg/apis/greeter/v1beta1"
+)
+
+const fuzzIterations = 500
+
+func newScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := v1beta1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	return scheme
+}
+
+// newFuzzer returns a fuzzer with a random seed, the seed is logged so a
+// failure can be reproduced.
+func newFuzzer(t *testing.T) *fuzz.Fuzzer {
+	seed := rand.Int63()
+	t.Logf("fuzzer seed: %d", seed)
+
+	return fuzz.New().NilChance(0.3).NumElements(0, 2).MaxDepth(8).
+		RandSource(rand.NewSource(seed)).
+		Funcs(
+			// The conversion never touches the type meta, which is set by
+			// the scheme when converting to a version.
+			func(tm *metav1.TypeMeta, c fuzz.Continue) {
+				*tm = metav1.TypeMeta{}
+			},
+			// The type of the schedule is implied by the cron in v1alpha1, so
+			// only the valid combinations round-trip.
+			func(schedule *v1beta1.GreeterSchedule, c fuzz.Continue) {
+				c.FuzzNoCustom(schedule)
+				schedule.Type = v1beta1.ScheduleTypeOnce
+				if len(schedule.Cron) != 0 {
+					schedule.Type = v1beta1.ScheduleTypeCron
+				}
+			},
+		)
+}
+
+func TestRoundTripV1alpha1(t *testing.T) {
+	scheme := newScheme(t)
+	fuzzer := newFuzzer(t)
+
+	for i := 0; i < fuzzIterations; i++ {
+		var original v1alpha1.Greeter
+		fuzzer.Fuzz(&original)
+
+		var hub v1beta1.Greeter
+		if err := scheme.Convert(original.DeepCopy(), &hub, nil); err != nil {
+			t.Fatalf("v1alpha1 -> v1beta1: %v", err)
+		}
+
+		var converted v1alpha1.Greeter
+		if err := scheme.Convert(&hub, &converted, nil); err != nil {
+			t.Fatalf("v1beta1 -> v1alpha1: %v", err)
+		}
+
+		if !apiequality.Semantic.DeepEqual(&original, &converted) {
+			t.Fatalf("v1alpha1 round-trip mismatch: %s", diff.ObjectReflectDiff(&original, &converted))
+		}
+	}
+}
+
+func TestRoundTripV1beta1(t *testing.T) {
+	scheme := newScheme(t)
+	fuzzer := newFuzzer(t)
+
+	for i := 0; i < fuzzIterations; i++ {
+		var original v1beta1.Greeter
+		fuzzer.Fuzz(&original)
+
+		var spoke v1alpha1.Greeter
+		if err := scheme.Convert(original.DeepCopy(), &spoke, nil); err != nil {
+			t.Fatalf("v1beta1 -> v1alpha1: %v", err)
+		}
+
+		var converted v1beta1.Greeter
+		if err := scheme.Convert(&spoke, &converted, nil); err != nil {
+			t.Fatalf("v1alpha1 -> v1beta1: %v", err)
+		}
+
+		if !apiequality.Semantic.DeepEqual(&original, &converted) {
+			t.Fatalf("v1beta1 round-trip mismatch: %s", diff.ObjectReflectDiff(&original, &converted))
+		}
+	}
+}
+
+func TestConvertOnceIgnoresCron(t *testing.T) {
+	scheme := newScheme(t)
+
+	// Rejected by the CRD, but must never turn into a recurring greeter
+	in := &v1beta1.Greeter{Spec: v1beta1.GreeterSpec{
+		Schedule: v1beta1.GreeterSchedule{
+			Type: v1beta1.ScheduleTypeOnce,
+			At:   "2024-01-01 00:00:00",
+			Cron: "*/5 * * * *",
+		},
+		Delivery: v1beta1.GreeterDelivery{Message: "hello"},
+	}}
+
+	var out v1alpha1.Greeter
+	if err := scheme.Convert(in, &out, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if out.Spec.Schedule != "2024-01-01 00:00:00" || len(out.Spec.Cron) != 0 {
+		t.Errorf("expected a one-shot schedule, got schedule %q and cron %q", out.Spec.Schedule, out.Spec.Cron)
+	}
+}
+
+func TestConvertCronTakesPrecedence(t *testing.T) {
+	scheme := newScheme(t)
+
+	in := &v1alpha1.Greeter{Spec: v1alpha1.GreeterSpec{
+		Schedule: "2024-01-01 00:00:00",
+		Cron:     "*/5 * * * *",
+		Message:  "hello",
+	}}
+
+	var out v1beta1.Greeter
+	if err := scheme.Convert(in, &out, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if out.Spec.Schedule.Type != v1beta1.ScheduleTypeCron {
+		t.Errorf("expected schedule type %q, got %q", v1beta1.ScheduleTypeCron, out.Spec.Schedule.Type)
+	}
+	if out.Spec.Delivery.Message != "hello" || out.Spec.Delivery.Pod != nil {
+		t.Errorf("unexpected delivery %#v", out.Spec.Delivery)
+	}
+}
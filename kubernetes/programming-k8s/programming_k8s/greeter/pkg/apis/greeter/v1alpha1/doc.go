@@ -1,4 +1,5 @@
 // +k8s:deepcopy-gen=package,register
+// +k8s:conversion-gen=github.com/wjiec/programming_k8s/greeter/pkg/apis/greeter/v1beta1
 // +groupName=example.org
 // +groupGoName=Greeter
 
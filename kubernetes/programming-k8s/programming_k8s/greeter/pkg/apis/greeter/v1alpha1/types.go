@@ -6,3 +6,76 @@ const (
 	PhaseSucceeded = "Succeeded"
 	PhaseFailed    = "Failed"
 )
+
+// These are valid conditions of a Greeter.
+const (
+	// ConditionScheduled means the schedule of the Greeter is reached and a run is fired.
+	ConditionScheduled = "Scheduled"
+	// ConditionPodCreated means the runner pod of the current run is created.
+	ConditionPodCreated = "PodCreated"
+	// ConditionCompleted means the last run has completed successfully.
+	ConditionCompleted = "Completed"
+	// ConditionFailed means the last run has failed.
+	ConditionFailed = "Failed"
+	// ConditionDeadlineExceeded means the last run was active longer than its deadline.
+	ConditionDeadlineExceeded = "DeadlineExceeded"
+	// ConditionThrottled means the due run is waiting for the capacity of the controller.
+	ConditionThrottled = "Throttled"
+)
+
+// These are the reasons of the conditions of a Greeter.
+const (
+	ReasonWaitingForSchedule     = "WaitingForSchedule"
+	ReasonScheduleReached        = "ScheduleReached"
+	ReasonInvalidSchedule        = "InvalidSchedule"
+	ReasonInvalidTimeZone        = "InvalidTimeZone"
+	ReasonInvalidMessage         = "InvalidMessage"
+	ReasonPodPending             = "PodPending"
+	ReasonPodCreated             = "PodCreated"
+	ReasonPodSucceeded           = "PodSucceeded"
+	ReasonPodFailed              = "PodFailed"
+	ReasonRetrying               = "Retrying"
+	ReasonBackoffLimitExceeded   = "BackoffLimitExceeded"
+	ReasonDeadlineExceeded       = "DeadlineExceeded"
+	ReasonPodDeleted             = "PodDeleted"
+	ReasonSuspended              = "Suspended"
+	ReasonInvalidDelivery        = "InvalidDelivery"
+	ReasonDelivered              = "Delivered"
+	ReasonDeliveryFailed         = "DeliveryFailed"
+	ReasonMaxRunning             = "MaxRunning"
+	ReasonMaxRunningPerNamespace = "MaxRunningPerNamespace"
+	ReasonLaunchRateLimited      = "LaunchRateLimited"
+)
+
+// These are valid policies of what is deleted once the time to live of a
+// finished Greeter is expired.
+const (
+	// TTLPolicyDeletePod deletes the runner pods only.
+	TTLPolicyDeletePod = "DeletePod"
+	// TTLPolicyDeleteGreeter deletes the Greeter and its runner pods.
+	TTLPolicyDeleteGreeter = "DeleteGreeter"
+)
+
+// These are valid policies of how the runner pod of a running Greeter being
+// deleted is handled.
+const (
+	// PodDeletionPolicyRecreate creates the runner pod again.
+	PodDeletionPolicyRecreate = "Recreate"
+	// PodDeletionPolicyFail marks the run failed.
+	PodDeletionPolicyFail = "Fail"
+)
+
+// AnnotationRunNow is the annotation to fire a Greeter immediately, its value
+// is an arbitrary token and each new token fires exactly one run. A finished
+// Greeter is fired again, the run of a running Greeter is fired once the
+// current run is finished.
+const AnnotationRunNow = "example.org/run-now"
+
+// LabelDelivery is the label which allows the Greeters in the namespace of a
+// Secret or ConfigMap to use it for their delivery, its value must be
+// LabelDeliveryAllowed. The values of the Secrets without the label are never
+// sent, and the ConfigMaps without the label are never overwritten.
+const (
+	LabelDelivery        = "greeter.example.org/delivery"
+	LabelDeliveryAllowed = "allowed"
+)
@@ -1,6 +1,9 @@
 package v1alpha1
 
-import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
 
 // +genclient
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -29,10 +32,209 @@ type GreeterList struct {
 }
 
 type GreeterSpec struct {
-	Schedule string `json:"schedule"`
-	Message  string `json:"message"`
+	// The one-shot time of the greeting, in "2006-01-02 15:04:05" format.
+	// Ignored when Cron is set.
+	// +optional
+	Schedule string `json:"schedule,omitempty"`
+
+	// The recurring schedule in Cron format, see https://en.wikipedia.org/wiki/Cron.
+	// When set, the Greeter fires repeatedly instead of once.
+	// +optional
+	Cron string `json:"cron,omitempty"`
+
+	// The time zone name for the given schedule, see https://en.wikipedia.org/wiki/List_of_tz_database_time_zones.
+	// If not specified, this will default to the time zone of the greeter-controller.
+	// +optional
+	TimeZone *string `json:"timeZone,omitempty"`
+
+	Message string `json:"message"`
+
+	// Specifies the pod that will be created when the greeter is fired. The
+	// message is available to its containers in the GREETER_MESSAGE environment
+	// variable. If not specified, a busybox pod echoing the message is used.
+	// +optional
+	Template *corev1.PodTemplateSpec `json:"template,omitempty"`
+
+	// Specifies how the greeting is delivered instead of a pod. At most one
+	// sink may be set, and the template must not be set together with it.
+	// +optional
+	Delivery GreeterDelivery `json:"delivery,omitempty"`
+
+	// Specifies the number of retries before marking the greeter failed.
+	// Defaults to 0, which means a failed run is never retried.
+	// +optional
+	BackoffLimit *int32 `json:"backoffLimit,omitempty"`
+
+	// The delay before the first retry of a failed run, the delay is doubled
+	// for each subsequent retry (capped at 6 minutes). Defaults to 10s.
+	// +optional
+	RetryBackoff *metav1.Duration `json:"retryBackoff,omitempty"`
+
+	// Specifies the duration in seconds relative to the start time that a run
+	// may be active (including retries) before the controller stops it.
+	// +optional
+	ActiveDeadlineSeconds *int64 `json:"activeDeadlineSeconds,omitempty"`
+
+	// Limits the lifetime of a run that has finished execution. When set, the
+	// runner pod is deleted after the run is finished for the duration.
+	// +optional
+	TTLSecondsAfterFinished *int32 `json:"ttlSecondsAfterFinished,omitempty"`
+
+	// Specifies what is deleted once the time to live is expired. Valid values are:
+	// - "DeletePod" (default): only the runner pods are deleted;
+	// - "DeleteGreeter": the greeter is deleted as well once it's finished.
+	// +optional
+	TTLAfterFinishedPolicy string `json:"ttlAfterFinishedPolicy,omitempty"`
+
+	// Specifies how to treat the runner pod being deleted while running.
+	// Valid values are:
+	// - "Recreate" (default): creates the runner pod again;
+	// - "Fail": marks the run failed with the PodDeleted reason.
+	// +optional
+	PodDeletionPolicy string `json:"podDeletionPolicy,omitempty"`
+
+	// This flag tells the controller to suspend subsequent runs, it does not
+	// apply to the run already started. A run can still be fired manually
+	// with the example.org/run-now annotation. The runs due while suspended
+	// are missed, a recurring greeter restarts from the time it's resumed.
+	// Defaults to false.
+	// +optional
+	Suspend *bool `json:"suspend,omitempty"`
+}
+
+// GreeterDelivery describes the sink the greeting is delivered to, the
+// greeting is delivered by a pod if none of them is set.
+type GreeterDelivery struct {
+	// Delivers the greeting as an Event on an object.
+	// +optional
+	Event *EventDelivery `json:"event,omitempty"`
+
+	// Delivers the greeting into a key of a ConfigMap.
+	// +optional
+	ConfigMap *ConfigMapDelivery `json:"configMap,omitempty"`
+
+	// Delivers the greeting by an HTTP POST request.
+	// +optional
+	HTTP *HTTPDelivery `json:"http,omitempty"`
+}
+
+// EventDelivery delivers the greeting as an Event on an object in the
+// namespace of the greeter.
+type EventDelivery struct {
+	// API version of the referenced object, e.g. "v1".
+	APIVersion string `json:"apiVersion"`
+	// Kind of the referenced object, e.g. "Service".
+	Kind string `json:"kind"`
+	// Name of the referenced object.
+	Name string `json:"name"`
+
+	// The type of the Event, either "Normal" or "Warning". Defaults to Normal.
+	// +optional
+	Type string `json:"type,omitempty"`
+	// The reason of the Event. Defaults to "Greeting".
+	// +optional
+	Reason string `json:"reason,omitempty"`
+}
+
+// ConfigMapDelivery delivers the greeting into a key of a ConfigMap in the
+// namespace of the greeter, the ConfigMap is created if it doesn't exist. An
+// existing ConfigMap must be labeled with greeter.example.org/delivery=allowed.
+type ConfigMapDelivery struct {
+	// Name of the ConfigMap.
+	Name string `json:"name"`
+	// The key the greeting is written to.
+	Key string `json:"key"`
+}
+
+// HTTPDelivery delivers the greeting by an HTTP POST request to an in-cluster
+// URL, the request body is a JSON object with the namespace, name, run and
+// message of the greeter.
+type HTTPDelivery struct {
+	// The URL the greeting is posted to, the host must be the DNS name of a
+	// Service in the namespace of the greeter, e.g. "http://greetings.default.svc/hook".
+	URL string `json:"url"`
+
+	// An optional header whose value is read from a Secret in the namespace
+	// of the greeter, e.g. for the authorization of the request. The Secret
+	// must be labeled with greeter.example.org/delivery=allowed.
+	// +optional
+	SecretHeader *HTTPSecretHeader `json:"secretHeader,omitempty"`
+}
+
+// HTTPSecretHeader is a header of the request whose value is read from a Secret.
+type HTTPSecretHeader struct {
+	// Name of the header.
+	Name string `json:"name"`
+	// Selects the key of the Secret holding the value of the header.
+	SecretKeyRef corev1.SecretKeySelector `json:"secretKeyRef"`
 }
 
 type GreeterStatus struct {
 	Phase string `json:"phase"`
+
+	// The generation observed by the greeter-controller.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// The latest available observations of the greeter's current state.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// The name of the runner pod of the current (or last) run.
+	// +optional
+	PodName string `json:"podName,omitempty"`
+
+	// Information when was the last time the greeter was fired.
+	// +optional
+	LastScheduleTime *metav1.Time `json:"lastScheduleTime,omitempty"`
+
+	// Information when the greeter will be fired next time, resolved
+	// to an absolute time in the time zone of the greeter.
+	// +optional
+	NextScheduleTime *metav1.Time `json:"nextScheduleTime,omitempty"`
+
+	// The number of the runs of a recurring greeter which are not fired as
+	// they're due, e.g. while the controller is down, only the latest due run
+	// is fired.
+	// +optional
+	MissedRuns int64 `json:"missedRuns,omitempty"`
+
+	// Information when the last missed run was scheduled.
+	// +optional
+	LastMissedTime *metav1.Time `json:"lastMissedTime,omitempty"`
+
+	// Represents time when the runner pod of the current (or last) run was created.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// Represents time when the runner pod of the last run was completed.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// The exit code of the terminated container of the last run.
+	// +optional
+	ExitCode *int32 `json:"exitCode,omitempty"`
+
+	// The termination message of the terminated container of the last run.
+	// +optional
+	TerminationMessage string `json:"terminationMessage,omitempty"`
+
+	// The number of failed attempts of the current (or last) run.
+	// +optional
+	Attempts int32 `json:"attempts,omitempty"`
+
+	// Information when the failed attempt of the current run will be retried.
+	// +optional
+	NextRetryTime *metav1.Time `json:"nextRetryTime,omitempty"`
+
+	// The bounded tail of the output of the runner pod of the last run.
+	// +optional
+	Output string `json:"output,omitempty"`
+
+	// The last token of the example.org/run-now annotation which fired a
+	// run, the same token never fires again.
+	// +optional
+	LastRunNowToken string `json:"lastRunNowToken,omitempty"`
 }
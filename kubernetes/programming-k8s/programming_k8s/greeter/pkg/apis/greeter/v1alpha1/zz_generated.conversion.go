@@ -0,0 +1,403 @@
+//go:build !ignore_autogenerated
+// +build !ignore_autogenerated
+
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Code generated by conversion-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	unsafe "unsafe"
+
+	v1beta1 "github.com/wjiec/programming_k8s/greeter/pkg/apis/greeter/v1beta1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	conversion "k8s.io/apimachinery/pkg/conversion"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+)
+
+func init() {
+	localSchemeBuilder.Register(RegisterConversions)
+}
+
+// RegisterConversions adds conversion functions to the given scheme.
+// Public to allow building arbitrary schemes.
+func RegisterConversions(s *runtime.Scheme) error {
+	if err := s.AddGeneratedConversionFunc((*ConfigMapDelivery)(nil), (*v1beta1.ConfigMapDelivery)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_ConfigMapDelivery_To_v1beta1_ConfigMapDelivery(a.(*ConfigMapDelivery), b.(*v1beta1.ConfigMapDelivery), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.ConfigMapDelivery)(nil), (*ConfigMapDelivery)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_ConfigMapDelivery_To_v1alpha1_ConfigMapDelivery(a.(*v1beta1.ConfigMapDelivery), b.(*ConfigMapDelivery), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*EventDelivery)(nil), (*v1beta1.EventDelivery)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_EventDelivery_To_v1beta1_EventDelivery(a.(*EventDelivery), b.(*v1beta1.EventDelivery), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.EventDelivery)(nil), (*EventDelivery)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_EventDelivery_To_v1alpha1_EventDelivery(a.(*v1beta1.EventDelivery), b.(*EventDelivery), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*Greeter)(nil), (*v1beta1.Greeter)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_Greeter_To_v1beta1_Greeter(a.(*Greeter), b.(*v1beta1.Greeter), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.Greeter)(nil), (*Greeter)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_Greeter_To_v1alpha1_Greeter(a.(*v1beta1.Greeter), b.(*Greeter), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*GreeterDelivery)(nil), (*v1beta1.GreeterDelivery)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_GreeterDelivery_To_v1beta1_GreeterDelivery(a.(*GreeterDelivery), b.(*v1beta1.GreeterDelivery), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*GreeterList)(nil), (*v1beta1.GreeterList)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_GreeterList_To_v1beta1_GreeterList(a.(*GreeterList), b.(*v1beta1.GreeterList), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.GreeterList)(nil), (*GreeterList)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_GreeterList_To_v1alpha1_GreeterList(a.(*v1beta1.GreeterList), b.(*GreeterList), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*GreeterStatus)(nil), (*v1beta1.GreeterStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_GreeterStatus_To_v1beta1_GreeterStatus(a.(*GreeterStatus), b.(*v1beta1.GreeterStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.GreeterStatus)(nil), (*GreeterStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_GreeterStatus_To_v1alpha1_GreeterStatus(a.(*v1beta1.GreeterStatus), b.(*GreeterStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*HTTPDelivery)(nil), (*v1beta1.HTTPDelivery)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_HTTPDelivery_To_v1beta1_HTTPDelivery(a.(*HTTPDelivery), b.(*v1beta1.HTTPDelivery), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.HTTPDelivery)(nil), (*HTTPDelivery)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_HTTPDelivery_To_v1alpha1_HTTPDelivery(a.(*v1beta1.HTTPDelivery), b.(*HTTPDelivery), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*HTTPSecretHeader)(nil), (*v1beta1.HTTPSecretHeader)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_HTTPSecretHeader_To_v1beta1_HTTPSecretHeader(a.(*HTTPSecretHeader), b.(*v1beta1.HTTPSecretHeader), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1beta1.HTTPSecretHeader)(nil), (*HTTPSecretHeader)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_HTTPSecretHeader_To_v1alpha1_HTTPSecretHeader(a.(*v1beta1.HTTPSecretHeader), b.(*HTTPSecretHeader), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddConversionFunc((*GreeterSpec)(nil), (*v1beta1.GreeterSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_GreeterSpec_To_v1beta1_GreeterSpec(a.(*GreeterSpec), b.(*v1beta1.GreeterSpec), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddConversionFunc((*v1beta1.GreeterDelivery)(nil), (*GreeterDelivery)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_GreeterDelivery_To_v1alpha1_GreeterDelivery(a.(*v1beta1.GreeterDelivery), b.(*GreeterDelivery), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddConversionFunc((*v1beta1.GreeterSpec)(nil), (*GreeterSpec)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_GreeterSpec_To_v1alpha1_GreeterSpec(a.(*v1beta1.GreeterSpec), b.(*GreeterSpec), scope)
+	}); err != nil {
+		return err
+	}
+	return nil
+}
+
+func autoConvert_v1alpha1_ConfigMapDelivery_To_v1beta1_ConfigMapDelivery(in *ConfigMapDelivery, out *v1beta1.ConfigMapDelivery, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Key = in.Key
+	return nil
+}
+
+// Convert_v1alpha1_ConfigMapDelivery_To_v1beta1_ConfigMapDelivery is an autogenerated conversion function.
+func Convert_v1alpha1_ConfigMapDelivery_To_v1beta1_ConfigMapDelivery(in *ConfigMapDelivery, out *v1beta1.ConfigMapDelivery, s conversion.Scope) error {
+	return autoConvert_v1alpha1_ConfigMapDelivery_To_v1beta1_ConfigMapDelivery(in, out, s)
+}
+
+func autoConvert_v1beta1_ConfigMapDelivery_To_v1alpha1_ConfigMapDelivery(in *v1beta1.ConfigMapDelivery, out *ConfigMapDelivery, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Key = in.Key
+	return nil
+}
+
+// Convert_v1beta1_ConfigMapDelivery_To_v1alpha1_ConfigMapDelivery is an autogenerated conversion function.
+func Convert_v1beta1_ConfigMapDelivery_To_v1alpha1_ConfigMapDelivery(in *v1beta1.ConfigMapDelivery, out *ConfigMapDelivery, s conversion.Scope) error {
+	return autoConvert_v1beta1_ConfigMapDelivery_To_v1alpha1_ConfigMapDelivery(in, out, s)
+}
+
+func autoConvert_v1alpha1_EventDelivery_To_v1beta1_EventDelivery(in *EventDelivery, out *v1beta1.EventDelivery, s conversion.Scope) error {
+	out.APIVersion = in.APIVersion
+	out.Kind = in.Kind
+	out.Name = in.Name
+	out.Type = in.Type
+	out.Reason = in.Reason
+	return nil
+}
+
+// Convert_v1alpha1_EventDelivery_To_v1beta1_EventDelivery is an autogenerated conversion function.
+func Convert_v1alpha1_EventDelivery_To_v1beta1_EventDelivery(in *EventDelivery, out *v1beta1.EventDelivery, s conversion.Scope) error {
+	return autoConvert_v1alpha1_EventDelivery_To_v1beta1_EventDelivery(in, out, s)
+}
+
+func autoConvert_v1beta1_EventDelivery_To_v1alpha1_EventDelivery(in *v1beta1.EventDelivery, out *EventDelivery, s conversion.Scope) error {
+	out.APIVersion = in.APIVersion
+	out.Kind = in.Kind
+	out.Name = in.Name
+	out.Type = in.Type
+	out.Reason = in.Reason
+	return nil
+}
+
+// Convert_v1beta1_EventDelivery_To_v1alpha1_EventDelivery is an autogenerated conversion function.
+func Convert_v1beta1_EventDelivery_To_v1alpha1_EventDelivery(in *v1beta1.EventDelivery, out *EventDelivery, s conversion.Scope) error {
+	return autoConvert_v1beta1_EventDelivery_To_v1alpha1_EventDelivery(in, out, s)
+}
+
+func autoConvert_v1alpha1_Greeter_To_v1beta1_Greeter(in *Greeter, out *v1beta1.Greeter, s conversion.Scope) error {
+	out.ObjectMeta = in.ObjectMeta
+	if err := Convert_v1alpha1_GreeterSpec_To_v1beta1_GreeterSpec(&in.Spec, &out.Spec, s); err != nil {
+		return err
+	}
+	if err := Convert_v1alpha1_GreeterStatus_To_v1beta1_GreeterStatus(&in.Status, &out.Status, s); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Convert_v1alpha1_Greeter_To_v1beta1_Greeter is an autogenerated conversion function.
+func Convert_v1alpha1_Greeter_To_v1beta1_Greeter(in *Greeter, out *v1beta1.Greeter, s conversion.Scope) error {
+	return autoConvert_v1alpha1_Greeter_To_v1beta1_Greeter(in, out, s)
+}
+
+func autoConvert_v1beta1_Greeter_To_v1alpha1_Greeter(in *v1beta1.Greeter, out *Greeter, s conversion.Scope) error {
+	out.ObjectMeta = in.ObjectMeta
+	if err := Convert_v1beta1_GreeterSpec_To_v1alpha1_GreeterSpec(&in.Spec, &out.Spec, s); err != nil {
+		return err
+	}
+	if err := Convert_v1beta1_GreeterStatus_To_v1alpha1_GreeterStatus(&in.Status, &out.Status, s); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Convert_v1beta1_Greeter_To_v1alpha1_Greeter is an autogenerated conversion function.
+func Convert_v1beta1_Greeter_To_v1alpha1_Greeter(in *v1beta1.Greeter, out *Greeter, s conversion.Scope) error {
+	return autoConvert_v1beta1_Greeter_To_v1alpha1_Greeter(in, out, s)
+}
+
+func autoConvert_v1alpha1_GreeterDelivery_To_v1beta1_GreeterDelivery(in *GreeterDelivery, out *v1beta1.GreeterDelivery, s conversion.Scope) error {
+	out.Event = (*v1beta1.EventDelivery)(unsafe.Pointer(in.Event))
+	out.ConfigMap = (*v1beta1.ConfigMapDelivery)(unsafe.Pointer(in.ConfigMap))
+	out.HTTP = (*v1beta1.HTTPDelivery)(unsafe.Pointer(in.HTTP))
+	return nil
+}
+
+// Convert_v1alpha1_GreeterDelivery_To_v1beta1_GreeterDelivery is an autogenerated conversion function.
+func Convert_v1alpha1_GreeterDelivery_To_v1beta1_GreeterDelivery(in *GreeterDelivery, out *v1beta1.GreeterDelivery, s conversion.Scope) error {
+	return autoConvert_v1alpha1_GreeterDelivery_To_v1beta1_GreeterDelivery(in, out, s)
+}
+
+func autoConvert_v1beta1_GreeterDelivery_To_v1alpha1_GreeterDelivery(in *v1beta1.GreeterDelivery, out *GreeterDelivery, s conversion.Scope) error {
+	// WARNING: in.Message requires manual conversion: does not exist in peer-type
+	// WARNING: in.Pod requires manual conversion: does not exist in peer-type
+	out.Event = (*EventDelivery)(unsafe.Pointer(in.Event))
+	out.ConfigMap = (*ConfigMapDelivery)(unsafe.Pointer(in.ConfigMap))
+	out.HTTP = (*HTTPDelivery)(unsafe.Pointer(in.HTTP))
+	return nil
+}
+
+func autoConvert_v1alpha1_GreeterList_To_v1beta1_GreeterList(in *GreeterList, out *v1beta1.GreeterList, s conversion.Scope) error {
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]v1beta1.Greeter, len(*in))
+		for i := range *in {
+			if err := Convert_v1alpha1_Greeter_To_v1beta1_Greeter(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.Items = nil
+	}
+	return nil
+}
+
+// Convert_v1alpha1_GreeterList_To_v1beta1_GreeterList is an autogenerated conversion function.
+func Convert_v1alpha1_GreeterList_To_v1beta1_GreeterList(in *GreeterList, out *v1beta1.GreeterList, s conversion.Scope) error {
+	return autoConvert_v1alpha1_GreeterList_To_v1beta1_GreeterList(in, out, s)
+}
+
+func autoConvert_v1beta1_GreeterList_To_v1alpha1_GreeterList(in *v1beta1.GreeterList, out *GreeterList, s conversion.Scope) error {
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Greeter, len(*in))
+		for i := range *in {
+			if err := Convert_v1beta1_Greeter_To_v1alpha1_Greeter(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.Items = nil
+	}
+	return nil
+}
+
+// Convert_v1beta1_GreeterList_To_v1alpha1_GreeterList is an autogenerated conversion function.
+func Convert_v1beta1_GreeterList_To_v1alpha1_GreeterList(in *v1beta1.GreeterList, out *GreeterList, s conversion.Scope) error {
+	return autoConvert_v1beta1_GreeterList_To_v1alpha1_GreeterList(in, out, s)
+}
+
+func autoConvert_v1alpha1_GreeterSpec_To_v1beta1_GreeterSpec(in *GreeterSpec, out *v1beta1.GreeterSpec, s conversion.Scope) error {
+	// WARNING: in.Schedule requires manual conversion: inconvertible types (string vs github.com/wjiec/programming_k8s/greeter/pkg/apis/greeter/v1beta1.GreeterSchedule)
+	// WARNING: in.Cron requires manual conversion: does not exist in peer-type
+	// WARNING: in.TimeZone requires manual conversion: does not exist in peer-type
+	// WARNING: in.Message requires manual conversion: does not exist in peer-type
+	// WARNING: in.Template requires manual conversion: does not exist in peer-type
+	if err := Convert_v1alpha1_GreeterDelivery_To_v1beta1_GreeterDelivery(&in.Delivery, &out.Delivery, s); err != nil {
+		return err
+	}
+	out.BackoffLimit = (*int32)(unsafe.Pointer(in.BackoffLimit))
+	out.RetryBackoff = (*v1.Duration)(unsafe.Pointer(in.RetryBackoff))
+	out.ActiveDeadlineSeconds = (*int64)(unsafe.Pointer(in.ActiveDeadlineSeconds))
+	out.TTLSecondsAfterFinished = (*int32)(unsafe.Pointer(in.TTLSecondsAfterFinished))
+	out.TTLAfterFinishedPolicy = v1beta1.TTLAfterFinishedPolicy(in.TTLAfterFinishedPolicy)
+	out.PodDeletionPolicy = v1beta1.PodDeletionPolicy(in.PodDeletionPolicy)
+	out.Suspend = (*bool)(unsafe.Pointer(in.Suspend))
+	return nil
+}
+
+func autoConvert_v1beta1_GreeterSpec_To_v1alpha1_GreeterSpec(in *v1beta1.GreeterSpec, out *GreeterSpec, s conversion.Scope) error {
+	// WARNING: in.Schedule requires manual conversion: inconvertible types (github.com/wjiec/programming_k8s/greeter/pkg/apis/greeter/v1beta1.GreeterSchedule vs string)
+	if err := Convert_v1beta1_GreeterDelivery_To_v1alpha1_GreeterDelivery(&in.Delivery, &out.Delivery, s); err != nil {
+		return err
+	}
+	out.BackoffLimit = (*int32)(unsafe.Pointer(in.BackoffLimit))
+	out.RetryBackoff = (*v1.Duration)(unsafe.Pointer(in.RetryBackoff))
+	out.ActiveDeadlineSeconds = (*int64)(unsafe.Pointer(in.ActiveDeadlineSeconds))
+	out.TTLSecondsAfterFinished = (*int32)(unsafe.Pointer(in.TTLSecondsAfterFinished))
+	out.TTLAfterFinishedPolicy = string(in.TTLAfterFinishedPolicy)
+	out.PodDeletionPolicy = string(in.PodDeletionPolicy)
+	out.Suspend = (*bool)(unsafe.Pointer(in.Suspend))
+	return nil
+}
+
+func autoConvert_v1alpha1_GreeterStatus_To_v1beta1_GreeterStatus(in *GreeterStatus, out *v1beta1.GreeterStatus, s conversion.Scope) error {
+	out.Phase = v1beta1.GreeterPhase(in.Phase)
+	out.ObservedGeneration = in.ObservedGeneration
+	out.Conditions = *(*[]v1.Condition)(unsafe.Pointer(&in.Conditions))
+	out.PodName = in.PodName
+	out.LastScheduleTime = (*v1.Time)(unsafe.Pointer(in.LastScheduleTime))
+	out.NextScheduleTime = (*v1.Time)(unsafe.Pointer(in.NextScheduleTime))
+	out.MissedRuns = in.MissedRuns
+	out.LastMissedTime = (*v1.Time)(unsafe.Pointer(in.LastMissedTime))
+	out.StartTime = (*v1.Time)(unsafe.Pointer(in.StartTime))
+	out.CompletionTime = (*v1.Time)(unsafe.Pointer(in.CompletionTime))
+	out.ExitCode = (*int32)(unsafe.Pointer(in.ExitCode))
+	out.TerminationMessage = in.TerminationMessage
+	out.Attempts = in.Attempts
+	out.NextRetryTime = (*v1.Time)(unsafe.Pointer(in.NextRetryTime))
+	out.Output = in.Output
+	out.LastRunNowToken = in.LastRunNowToken
+	return nil
+}
+
+// Convert_v1alpha1_GreeterStatus_To_v1beta1_GreeterStatus is an autogenerated conversion function.
+func Convert_v1alpha1_GreeterStatus_To_v1beta1_GreeterStatus(in *GreeterStatus, out *v1beta1.GreeterStatus, s conversion.Scope) error {
+	return autoConvert_v1alpha1_GreeterStatus_To_v1beta1_GreeterStatus(in, out, s)
+}
+
+func autoConvert_v1beta1_GreeterStatus_To_v1alpha1_GreeterStatus(in *v1beta1.GreeterStatus, out *GreeterStatus, s conversion.Scope) error {
+	out.Phase = string(in.Phase)
+	out.ObservedGeneration = in.ObservedGeneration
+	out.Conditions = *(*[]v1.Condition)(unsafe.Pointer(&in.Conditions))
+	out.PodName = in.PodName
+	out.LastScheduleTime = (*v1.Time)(unsafe.Pointer(in.LastScheduleTime))
+	out.NextScheduleTime = (*v1.Time)(unsafe.Pointer(in.NextScheduleTime))
+	out.MissedRuns = in.MissedRuns
+	out.LastMissedTime = (*v1.Time)(unsafe.Pointer(in.LastMissedTime))
+	out.StartTime = (*v1.Time)(unsafe.Pointer(in.StartTime))
+	out.CompletionTime = (*v1.Time)(unsafe.Pointer(in.CompletionTime))
+	out.ExitCode = (*int32)(unsafe.Pointer(in.ExitCode))
+	out.TerminationMessage = in.TerminationMessage
+	out.Attempts = in.Attempts
+	out.NextRetryTime = (*v1.Time)(unsafe.Pointer(in.NextRetryTime))
+	out.Output = in.Output
+	out.LastRunNowToken = in.LastRunNowToken
+	return nil
+}
+
+// Convert_v1beta1_GreeterStatus_To_v1alpha1_GreeterStatus is an autogenerated conversion function.
+func Convert_v1beta1_GreeterStatus_To_v1alpha1_GreeterStatus(in *v1beta1.GreeterStatus, out *GreeterStatus, s conversion.Scope) error {
+	return autoConvert_v1beta1_GreeterStatus_To_v1alpha1_GreeterStatus(in, out, s)
+}
+
+func autoConvert_v1alpha1_HTTPDelivery_To_v1beta1_HTTPDelivery(in *HTTPDelivery, out *v1beta1.HTTPDelivery, s conversion.Scope) error {
+	out.URL = in.URL
+	out.SecretHeader = (*v1beta1.HTTPSecretHeader)(unsafe.Pointer(in.SecretHeader))
+	return nil
+}
+
+// Convert_v1alpha1_HTTPDelivery_To_v1beta1_HTTPDelivery is an autogenerated conversion function.
+func Convert_v1alpha1_HTTPDelivery_To_v1beta1_HTTPDelivery(in *HTTPDelivery, out *v1beta1.HTTPDelivery, s conversion.Scope) error {
+	return autoConvert_v1alpha1_HTTPDelivery_To_v1beta1_HTTPDelivery(in, out, s)
+}
+
+func autoConvert_v1beta1_HTTPDelivery_To_v1alpha1_HTTPDelivery(in *v1beta1.HTTPDelivery, out *HTTPDelivery, s conversion.Scope) error {
+	out.URL = in.URL
+	out.SecretHeader = (*HTTPSecretHeader)(unsafe.Pointer(in.SecretHeader))
+	return nil
+}
+
+// Convert_v1beta1_HTTPDelivery_To_v1alpha1_HTTPDelivery is an autogenerated conversion function.
+func Convert_v1beta1_HTTPDelivery_To_v1alpha1_HTTPDelivery(in *v1beta1.HTTPDelivery, out *HTTPDelivery, s conversion.Scope) error {
+	return autoConvert_v1beta1_HTTPDelivery_To_v1alpha1_HTTPDelivery(in, out, s)
+}
+
+func autoConvert_v1alpha1_HTTPSecretHeader_To_v1beta1_HTTPSecretHeader(in *HTTPSecretHeader, out *v1beta1.HTTPSecretHeader, s conversion.Scope) error {
+	out.Name = in.Name
+	out.SecretKeyRef = in.SecretKeyRef
+	return nil
+}
+
+// Convert_v1alpha1_HTTPSecretHeader_To_v1beta1_HTTPSecretHeader is an autogenerated conversion function.
+func Convert_v1alpha1_HTTPSecretHeader_To_v1beta1_HTTPSecretHeader(in *HTTPSecretHeader, out *v1beta1.HTTPSecretHeader, s conversion.Scope) error {
+	return autoConvert_v1alpha1_HTTPSecretHeader_To_v1beta1_HTTPSecretHeader(in, out, s)
+}
+
+func autoConvert_v1beta1_HTTPSecretHeader_To_v1alpha1_HTTPSecretHeader(in *v1beta1.HTTPSecretHeader, out *HTTPSecretHeader, s conversion.Scope) error {
+	out.Name = in.Name
+	out.SecretKeyRef = in.SecretKeyRef
+	return nil
+}
+
+// Convert_v1beta1_HTTPSecretHeader_To_v1alpha1_HTTPSecretHeader is an autogenerated conversion function.
+func Convert_v1beta1_HTTPSecretHeader_To_v1alpha1_HTTPSecretHeader(in *v1beta1.HTTPSecretHeader, out *HTTPSecretHeader, s conversion.Scope) error {
+	return autoConvert_v1beta1_HTTPSecretHeader_To_v1alpha1_HTTPSecretHeader(in, out, s)
+}
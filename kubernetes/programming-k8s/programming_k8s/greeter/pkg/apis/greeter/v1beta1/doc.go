@@ -0,0 +1,5 @@
+// +k8s:deepcopy-gen=package,register
+// +groupName=example.org
+// +groupGoName=Greeter
+
+package v1beta1
@@ -0,0 +1,53 @@
+package v1beta1
+
+// GreeterPhase is the phase of a Greeter in its lifecycle.
+type GreeterPhase string
+
+// These are valid phases of a Greeter.
+const (
+	// PhasePending means the Greeter is waiting for its schedule.
+	PhasePending GreeterPhase = "Pending"
+	// PhaseRunning means the Greeter is fired and its run is not finished.
+	PhaseRunning GreeterPhase = "Running"
+	// PhaseSucceeded means the last run of a one-shot Greeter has succeeded.
+	PhaseSucceeded GreeterPhase = "Succeeded"
+	// PhaseFailed means the last run of a one-shot Greeter has failed.
+	PhaseFailed GreeterPhase = "Failed"
+)
+
+// ScheduleType is the type of the schedule of a Greeter.
+type ScheduleType string
+
+// These are valid types of the schedule of a Greeter.
+const (
+	// ScheduleTypeOnce fires the Greeter once at an absolute time.
+	ScheduleTypeOnce ScheduleType = "Once"
+	// ScheduleTypeCron fires the Greeter repeatedly on a cron schedule.
+	ScheduleTypeCron ScheduleType = "Cron"
+)
+
+// TTLAfterFinishedPolicy describes what is deleted once the time to live of a
+// finished Greeter is expired.
+type TTLAfterFinishedPolicy string
+
+// These are valid policies of what is deleted once the time to live of a
+// finished Greeter is expired.
+const (
+	// TTLPolicyDeletePod deletes the runner pods only.
+	TTLPolicyDeletePod TTLAfterFinishedPolicy = "DeletePod"
+	// TTLPolicyDeleteGreeter deletes the Greeter and its runner pods.
+	TTLPolicyDeleteGreeter TTLAfterFinishedPolicy = "DeleteGreeter"
+)
+
+// PodDeletionPolicy describes how the runner pod of a running Greeter being
+// deleted is handled.
+type PodDeletionPolicy string
+
+// These are valid policies of how the runner pod of a running Greeter being
+// deleted is handled.
+const (
+	// PodDeletionPolicyRecreate creates the runner pod again.
+	PodDeletionPolicyRecreate PodDeletionPolicy = "Recreate"
+	// PodDeletionPolicyFail marks the run failed.
+	PodDeletionPolicyFail PodDeletionPolicy = "Fail"
+)
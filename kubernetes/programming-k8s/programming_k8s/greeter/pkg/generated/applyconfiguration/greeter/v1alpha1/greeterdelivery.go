@@ -0,0 +1,56 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Code generated by applyconfiguration-gen. DO NOT EDIT.
+
+package v1alpha1
+
+// GreeterDeliveryApplyConfiguration represents an declarative configuration of the GreeterDelivery type for use
+// with apply.
+type GreeterDeliveryApplyConfiguration struct {
+	Event     *EventDeliveryApplyConfiguration     `json:"event,omitempty"`
+	ConfigMap *ConfigMapDeliveryApplyConfiguration `json:"configMap,omitempty"`
+	HTTP      *HTTPDeliveryApplyConfiguration      `json:"http,omitempty"`
+}
+
+// GreeterDeliveryApplyConfiguration constructs an declarative configuration of the GreeterDelivery type for use with
+// apply.
+func GreeterDelivery() *GreeterDeliveryApplyConfiguration {
+	return &GreeterDeliveryApplyConfiguration{}
+}
+
+// WithEvent sets the Event field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Event field is set to the value of the last call.
+func (b *GreeterDeliveryApplyConfiguration) WithEvent(value *EventDeliveryApplyConfiguration) *GreeterDeliveryApplyConfiguration {
+	b.Event = value
+	return b
+}
+
+// WithConfigMap sets the ConfigMap field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the ConfigMap field is set to the value of the last call.
+func (b *GreeterDeliveryApplyConfiguration) WithConfigMap(value *ConfigMapDeliveryApplyConfiguration) *GreeterDeliveryApplyConfiguration {
+	b.ConfigMap = value
+	return b
+}
+
+// WithHTTP sets the HTTP field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the HTTP field is set to the value of the last call.
+func (b *GreeterDeliveryApplyConfiguration) WithHTTP(value *HTTPDeliveryApplyConfiguration) *GreeterDeliveryApplyConfiguration {
+	b.HTTP = value
+	return b
+}
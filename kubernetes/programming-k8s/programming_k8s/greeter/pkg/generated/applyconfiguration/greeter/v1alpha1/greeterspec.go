@@ -17,11 +17,27 @@ limitations under the License.
 
 package v1alpha1
 
+import (
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
 // GreeterSpecApplyConfiguration represents an declarative configuration of the GreeterSpec type for use
 // with apply.
 type GreeterSpecApplyConfiguration struct {
-	Schedule *string `json:"schedule,omitempty"`
-	Message  *string `json:"message,omitempty"`
+	Schedule                *string                            `json:"schedule,omitempty"`
+	Cron                    *string                            `json:"cron,omitempty"`
+	TimeZone                *string                            `json:"timeZone,omitempty"`
+	Message                 *string                            `json:"message,omitempty"`
+	Template                *v1.PodTemplateSpec                `json:"template,omitempty"`
+	Delivery                *GreeterDeliveryApplyConfiguration `json:"delivery,omitempty"`
+	BackoffLimit            *int32                             `json:"backoffLimit,omitempty"`
+	RetryBackoff            *metav1.Duration                   `json:"retryBackoff,omitempty"`
+	ActiveDeadlineSeconds   *int64                             `json:"activeDeadlineSeconds,omitempty"`
+	TTLSecondsAfterFinished *int32                             `json:"ttlSecondsAfterFinished,omitempty"`
+	TTLAfterFinishedPolicy  *string                            `json:"ttlAfterFinishedPolicy,omitempty"`
+	PodDeletionPolicy       *string                            `json:"podDeletionPolicy,omitempty"`
+	Suspend                 *bool                              `json:"suspend,omitempty"`
 }
 
 // GreeterSpecApplyConfiguration constructs an declarative configuration of the GreeterSpec type for use with
@@ -38,6 +54,22 @@ func (b *GreeterSpecApplyConfiguration) WithSchedule(value string) *GreeterSpecA
 	return b
 }
 
+// WithCron sets the Cron field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Cron field is set to the value of the last call.
+func (b *GreeterSpecApplyConfiguration) WithCron(value string) *GreeterSpecApplyConfiguration {
+	b.Cron = &value
+	return b
+}
+
+// WithTimeZone sets the TimeZone field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the TimeZone field is set to the value of the last call.
+func (b *GreeterSpecApplyConfiguration) WithTimeZone(value string) *GreeterSpecApplyConfiguration {
+	b.TimeZone = &value
+	return b
+}
+
 // WithMessage sets the Message field in the declarative configuration to the given value
 // and returns the receiver, so that objects can be built by chaining "With" function invocations.
 // If called multiple times, the Message field is set to the value of the last call.
@@ -45,3 +77,75 @@ func (b *GreeterSpecApplyConfiguration) WithMessage(value string) *GreeterSpecAp
 	b.Message = &value
 	return b
 }
+
+// WithTemplate sets the Template field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Template field is set to the value of the last call.
+func (b *GreeterSpecApplyConfiguration) WithTemplate(value v1.PodTemplateSpec) *GreeterSpecApplyConfiguration {
+	b.Template = &value
+	return b
+}
+
+// WithDelivery sets the Delivery field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Delivery field is set to the value of the last call.
+func (b *GreeterSpecApplyConfiguration) WithDelivery(value *GreeterDeliveryApplyConfiguration) *GreeterSpecApplyConfiguration {
+	b.Delivery = value
+	return b
+}
+
+// WithBackoffLimit sets the BackoffLimit field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the BackoffLimit field is set to the value of the last call.
+func (b *GreeterSpecApplyConfiguration) WithBackoffLimit(value int32) *GreeterSpecApplyConfiguration {
+	b.BackoffLimit = &value
+	return b
+}
+
+// WithRetryBackoff sets the RetryBackoff field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the RetryBackoff field is set to the value of the last call.
+func (b *GreeterSpecApplyConfiguration) WithRetryBackoff(value metav1.Duration) *GreeterSpecApplyConfiguration {
+	b.RetryBackoff = &value
+	return b
+}
+
+// WithActiveDeadlineSeconds sets the ActiveDeadlineSeconds field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the ActiveDeadlineSeconds field is set to the value of the last call.
+func (b *GreeterSpecApplyConfiguration) WithActiveDeadlineSeconds(value int64) *GreeterSpecApplyConfiguration {
+	b.ActiveDeadlineSeconds = &value
+	return b
+}
+
+// WithTTLSecondsAfterFinished sets the TTLSecondsAfterFinished field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the TTLSecondsAfterFinished field is set to the value of the last call.
+func (b *GreeterSpecApplyConfiguration) WithTTLSecondsAfterFinished(value int32) *GreeterSpecApplyConfiguration {
+	b.TTLSecondsAfterFinished = &value
+	return b
+}
+
+// WithTTLAfterFinishedPolicy sets the TTLAfterFinishedPolicy field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the TTLAfterFinishedPolicy field is set to the value of the last call.
+func (b *GreeterSpecApplyConfiguration) WithTTLAfterFinishedPolicy(value string) *GreeterSpecApplyConfiguration {
+	b.TTLAfterFinishedPolicy = &value
+	return b
+}
+
+// WithPodDeletionPolicy sets the PodDeletionPolicy field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the PodDeletionPolicy field is set to the value of the last call.
+func (b *GreeterSpecApplyConfiguration) WithPodDeletionPolicy(value string) *GreeterSpecApplyConfiguration {
+	b.PodDeletionPolicy = &value
+	return b
+}
+
+// WithSuspend sets the Suspend field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Suspend field is set to the value of the last call.
+func (b *GreeterSpecApplyConfiguration) WithSuspend(value bool) *GreeterSpecApplyConfiguration {
+	b.Suspend = &value
+	return b
+}
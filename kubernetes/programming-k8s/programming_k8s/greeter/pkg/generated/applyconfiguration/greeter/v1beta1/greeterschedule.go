@@ -0,0 +1,69 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Code generated by applyconfiguration-gen. DO NOT EDIT.
+
+package v1beta1
+
+import (
+	v1beta1 "github.com/wjiec/programming_k8s/greeter/pkg/apis/greeter/v1beta1"
+)
+
+// GreeterScheduleApplyConfiguration represents an declarative configuration of the GreeterSchedule type for use
+// with apply.
+type GreeterScheduleApplyConfiguration struct {
+	Type     *v1beta1.ScheduleType `json:"type,omitempty"`
+	At       *string               `json:"at,omitempty"`
+	Cron     *string               `json:"cron,omitempty"`
+	TimeZone *string               `json:"timeZone,omitempty"`
+}
+
+// GreeterScheduleApplyConfiguration constructs an declarative configuration of the GreeterSchedule type for use with
+// apply.
+func GreeterSchedule() *GreeterScheduleApplyConfiguration {
+	return &GreeterScheduleApplyConfiguration{}
+}
+
+// WithType sets the Type field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Type field is set to the value of the last call.
+func (b *GreeterScheduleApplyConfiguration) WithType(value v1beta1.ScheduleType) *GreeterScheduleApplyConfiguration {
+	b.Type = &value
+	return b
+}
+
+// WithAt sets the At field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the At field is set to the value of the last call.
+func (b *GreeterScheduleApplyConfiguration) WithAt(value string) *GreeterScheduleApplyConfiguration {
+	b.At = &value
+	return b
+}
+
+// WithCron sets the Cron field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Cron field is set to the value of the last call.
+func (b *GreeterScheduleApplyConfiguration) WithCron(value string) *GreeterScheduleApplyConfiguration {
+	b.Cron = &value
+	return b
+}
+
+// WithTimeZone sets the TimeZone field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the TimeZone field is set to the value of the last call.
+func (b *GreeterScheduleApplyConfiguration) WithTimeZone(value string) *GreeterScheduleApplyConfiguration {
+	b.TimeZone = &value
+	return b
+}
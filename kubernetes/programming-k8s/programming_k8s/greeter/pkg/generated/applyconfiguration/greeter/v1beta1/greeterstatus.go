@@ -0,0 +1,180 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Code generated by applyconfiguration-gen. DO NOT EDIT.
+
+package v1beta1
+
+import (
+	v1beta1 "github.com/wjiec/programming_k8s/greeter/pkg/apis/greeter/v1beta1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GreeterStatusApplyConfiguration represents an declarative configuration of the GreeterStatus type for use
+// with apply.
+type GreeterStatusApplyConfiguration struct {
+	Phase              *v1beta1.GreeterPhase `json:"phase,omitempty"`
+	ObservedGeneration *int64                `json:"observedGeneration,omitempty"`
+	Conditions         []v1.Condition        `json:"conditions,omitempty"`
+	PodName            *string               `json:"podName,omitempty"`
+	LastScheduleTime   *v1.Time              `json:"lastScheduleTime,omitempty"`
+	NextScheduleTime   *v1.Time              `json:"nextScheduleTime,omitempty"`
+	MissedRuns         *int64                `json:"missedRuns,omitempty"`
+	LastMissedTime     *v1.Time              `json:"lastMissedTime,omitempty"`
+	StartTime          *v1.Time              `json:"startTime,omitempty"`
+	CompletionTime     *v1.Time              `json:"completionTime,omitempty"`
+	ExitCode           *int32                `json:"exitCode,omitempty"`
+	TerminationMessage *string               `json:"terminationMessage,omitempty"`
+	Attempts           *int32                `json:"attempts,omitempty"`
+	NextRetryTime      *v1.Time              `json:"nextRetryTime,omitempty"`
+	Output             *string               `json:"output,omitempty"`
+	LastRunNowToken    *string               `json:"lastRunNowToken,omitempty"`
+}
+
+// GreeterStatusApplyConfiguration constructs an declarative configuration of the GreeterStatus type for use with
+// apply.
+func GreeterStatus() *GreeterStatusApplyConfiguration {
+	return &GreeterStatusApplyConfiguration{}
+}
+
+// WithPhase sets the Phase field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Phase field is set to the value of the last call.
+func (b *GreeterStatusApplyConfiguration) WithPhase(value v1beta1.GreeterPhase) *GreeterStatusApplyConfiguration {
+	b.Phase = &value
+	return b
+}
+
+// WithObservedGeneration sets the ObservedGeneration field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the ObservedGeneration field is set to the value of the last call.
+func (b *GreeterStatusApplyConfiguration) WithObservedGeneration(value int64) *GreeterStatusApplyConfiguration {
+	b.ObservedGeneration = &value
+	return b
+}
+
+// WithConditions adds the given value to the Conditions field in the declarative configuration
+// and returns the receiver, so that objects can be build by chaining "With" function invocations.
+// If called multiple times, values provided by each call will be appended to the Conditions field.
+func (b *GreeterStatusApplyConfiguration) WithConditions(values ...v1.Condition) *GreeterStatusApplyConfiguration {
+	for i := range values {
+		b.Conditions = append(b.Conditions, values[i])
+	}
+	return b
+}
+
+// WithPodName sets the PodName field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the PodName field is set to the value of the last call.
+func (b *GreeterStatusApplyConfiguration) WithPodName(value string) *GreeterStatusApplyConfiguration {
+	b.PodName = &value
+	return b
+}
+
+// WithLastScheduleTime sets the LastScheduleTime field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the LastScheduleTime field is set to the value of the last call.
+func (b *GreeterStatusApplyConfiguration) WithLastScheduleTime(value v1.Time) *GreeterStatusApplyConfiguration {
+	b.LastScheduleTime = &value
+	return b
+}
+
+// WithNextScheduleTime sets the NextScheduleTime field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the NextScheduleTime field is set to the value of the last call.
+func (b *GreeterStatusApplyConfiguration) WithNextScheduleTime(value v1.Time) *GreeterStatusApplyConfiguration {
+	b.NextScheduleTime = &value
+	return b
+}
+
+// WithMissedRuns sets the MissedRuns field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the MissedRuns field is set to the value of the last call.
+func (b *GreeterStatusApplyConfiguration) WithMissedRuns(value int64) *GreeterStatusApplyConfiguration {
+	b.MissedRuns = &value
+	return b
+}
+
+// WithLastMissedTime sets the LastMissedTime field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the LastMissedTime field is set to the value of the last call.
+func (b *GreeterStatusApplyConfiguration) WithLastMissedTime(value v1.Time) *GreeterStatusApplyConfiguration {
+	b.LastMissedTime = &value
+	return b
+}
+
+// WithStartTime sets the StartTime field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the StartTime field is set to the value of the last call.
+func (b *GreeterStatusApplyConfiguration) WithStartTime(value v1.Time) *GreeterStatusApplyConfiguration {
+	b.StartTime = &value
+	return b
+}
+
+// WithCompletionTime sets the CompletionTime field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the CompletionTime field is set to the value of the last call.
+func (b *GreeterStatusApplyConfiguration) WithCompletionTime(value v1.Time) *GreeterStatusApplyConfiguration {
+	b.CompletionTime = &value
+	return b
+}
+
+// WithExitCode sets the ExitCode field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the ExitCode field is set to the value of the last call.
+func (b *GreeterStatusApplyConfiguration) WithExitCode(value int32) *GreeterStatusApplyConfiguration {
+	b.ExitCode = &value
+	return b
+}
+
+// WithTerminationMessage sets the TerminationMessage field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the TerminationMessage field is set to the value of the last call.
+func (b *GreeterStatusApplyConfiguration) WithTerminationMessage(value string) *GreeterStatusApplyConfiguration {
+	b.TerminationMessage = &value
+	return b
+}
+
+// WithAttempts sets the Attempts field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Attempts field is set to the value of the last call.
+func (b *GreeterStatusApplyConfiguration) WithAttempts(value int32) *GreeterStatusApplyConfiguration {
+	b.Attempts = &value
+	return b
+}
+
+// WithNextRetryTime sets the NextRetryTime field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the NextRetryTime field is set to the value of the last call.
+func (b *GreeterStatusApplyConfiguration) WithNextRetryTime(value v1.Time) *GreeterStatusApplyConfiguration {
+	b.NextRetryTime = &value
+	return b
+}
+
+// WithOutput sets the Output field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Output field is set to the value of the last call.
+func (b *GreeterStatusApplyConfiguration) WithOutput(value string) *GreeterStatusApplyConfiguration {
+	b.Output = &value
+	return b
+}
+
+// WithLastRunNowToken sets the LastRunNowToken field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the LastRunNowToken field is set to the value of the last call.
+func (b *GreeterStatusApplyConfiguration) WithLastRunNowToken(value string) *GreeterStatusApplyConfiguration {
+	b.LastRunNowToken = &value
+	return b
+}
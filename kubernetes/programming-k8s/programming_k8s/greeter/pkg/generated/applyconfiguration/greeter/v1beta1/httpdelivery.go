@@ -0,0 +1,47 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Code generated by applyconfiguration-gen. DO NOT EDIT.
+
+package v1beta1
+
+// HTTPDeliveryApplyConfiguration represents an declarative configuration of the HTTPDelivery type for use
+// with apply.
+type HTTPDeliveryApplyConfiguration struct {
+	URL          *string                             `json:"url,omitempty"`
+	SecretHeader *HTTPSecretHeaderApplyConfiguration `json:"secretHeader,omitempty"`
+}
+
+// HTTPDeliveryApplyConfiguration constructs an declarative configuration of the HTTPDelivery type for use with
+// apply.
+func HTTPDelivery() *HTTPDeliveryApplyConfiguration {
+	return &HTTPDeliveryApplyConfiguration{}
+}
+
+// WithURL sets the URL field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the URL field is set to the value of the last call.
+func (b *HTTPDeliveryApplyConfiguration) WithURL(value string) *HTTPDeliveryApplyConfiguration {
+	b.URL = &value
+	return b
+}
+
+// WithSecretHeader sets the SecretHeader field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the SecretHeader field is set to the value of the last call.
+func (b *HTTPDeliveryApplyConfiguration) WithSecretHeader(value *HTTPSecretHeaderApplyConfiguration) *HTTPDeliveryApplyConfiguration {
+	b.SecretHeader = value
+	return b
+}
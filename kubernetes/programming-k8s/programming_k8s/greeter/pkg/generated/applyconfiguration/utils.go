@@ -19,7 +19,9 @@ package applyconfiguration
 
 import (
 	v1alpha1 "github.com/wjiec/programming_k8s/greeter/pkg/apis/greeter/v1alpha1"
+	v1beta1 "github.com/wjiec/programming_k8s/greeter/pkg/apis/greeter/v1beta1"
 	greeterv1alpha1 "github.com/wjiec/programming_k8s/greeter/pkg/generated/applyconfiguration/greeter/v1alpha1"
+	greeterv1beta1 "github.com/wjiec/programming_k8s/greeter/pkg/generated/applyconfiguration/greeter/v1beta1"
 	schema "k8s.io/apimachinery/pkg/runtime/schema"
 )
 
@@ -28,12 +30,44 @@ import (
 func ForKind(kind schema.GroupVersionKind) interface{} {
 	switch kind {
 	// Group=example.org, Version=v1alpha1
+	case v1alpha1.SchemeGroupVersion.WithKind("ConfigMapDelivery"):
+		return &greeterv1alpha1.ConfigMapDeliveryApplyConfiguration{}
+	case v1alpha1.SchemeGroupVersion.WithKind("EventDelivery"):
+		return &greeterv1alpha1.EventDeliveryApplyConfiguration{}
 	case v1alpha1.SchemeGroupVersion.WithKind("Greeter"):
 		return &greeterv1alpha1.GreeterApplyConfiguration{}
+	case v1alpha1.SchemeGroupVersion.WithKind("GreeterDelivery"):
+		return &greeterv1alpha1.GreeterDeliveryApplyConfiguration{}
 	case v1alpha1.SchemeGroupVersion.WithKind("GreeterSpec"):
 		return &greeterv1alpha1.GreeterSpecApplyConfiguration{}
 	case v1alpha1.SchemeGroupVersion.WithKind("GreeterStatus"):
 		return &greeterv1alpha1.GreeterStatusApplyConfiguration{}
+	case v1alpha1.SchemeGroupVersion.WithKind("HTTPDelivery"):
+		return &greeterv1alpha1.HTTPDeliveryApplyConfiguration{}
+	case v1alpha1.SchemeGroupVersion.WithKind("HTTPSecretHeader"):
+		return &greeterv1alpha1.HTTPSecretHeaderApplyConfiguration{}
+
+		// Group=example.org, Version=v1beta1
+	case v1beta1.SchemeGroupVersion.WithKind("ConfigMapDelivery"):
+		return &greeterv1beta1.ConfigMapDeliveryApplyConfiguration{}
+	case v1beta1.SchemeGroupVersion.WithKind("EventDelivery"):
+		return &greeterv1beta1.EventDeliveryApplyConfiguration{}
+	case v1beta1.SchemeGroupVersion.WithKind("Greeter"):
+		return &greeterv1beta1.GreeterApplyConfiguration{}
+	case v1beta1.SchemeGroupVersion.WithKind("GreeterDelivery"):
+		return &greeterv1beta1.GreeterDeliveryApplyConfiguration{}
+	case v1beta1.SchemeGroupVersion.WithKind("GreeterSchedule"):
+		return &greeterv1beta1.GreeterScheduleApplyConfiguration{}
+	case v1beta1.SchemeGroupVersion.WithKind("GreeterSpec"):
+		return &greeterv1beta1.GreeterSpecApplyConfiguration{}
+	case v1beta1.SchemeGroupVersion.WithKind("GreeterStatus"):
+		return &greeterv1beta1.GreeterStatusApplyConfiguration{}
+	case v1beta1.SchemeGroupVersion.WithKind("HTTPDelivery"):
+		return &greeterv1beta1.HTTPDeliveryApplyConfiguration{}
+	case v1beta1.SchemeGroupVersion.WithKind("HTTPSecretHeader"):
+		return &greeterv1beta1.HTTPSecretHeaderApplyConfiguration{}
+	case v1beta1.SchemeGroupVersion.WithKind("PodDelivery"):
+		return &greeterv1beta1.PodDeliveryApplyConfiguration{}
 
 	}
 	return nil
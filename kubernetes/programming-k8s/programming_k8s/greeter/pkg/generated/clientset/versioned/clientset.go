@@ -22,6 +22,7 @@ import (
 	"net/http"
 
 	greeterv1alpha1 "github.com/wjiec/programming_k8s/greeter/pkg/generated/clientset/versioned/typed/greeter/v1alpha1"
+	greeterv1beta1 "github.com/wjiec/programming_k8s/greeter/pkg/generated/clientset/versioned/typed/greeter/v1beta1"
 	discovery "k8s.io/client-go/discovery"
 	rest "k8s.io/client-go/rest"
 	flowcontrol "k8s.io/client-go/util/flowcontrol"
@@ -30,12 +31,14 @@ import (
 type Interface interface {
 	Discovery() discovery.DiscoveryInterface
 	GreeterV1alpha1() greeterv1alpha1.GreeterV1alpha1Interface
+	GreeterV1beta1() greeterv1beta1.GreeterV1beta1Interface
 }
 
 // Clientset contains the clients for groups.
 type Clientset struct {
 	*discovery.DiscoveryClient
 	greeterV1alpha1 *greeterv1alpha1.GreeterV1alpha1Client
+	greeterV1beta1  *greeterv1beta1.GreeterV1beta1Client
 }
 
 // GreeterV1alpha1 retrieves the GreeterV1alpha1Client
@@ -43,6 +46,11 @@ func (c *Clientset) GreeterV1alpha1() greeterv1alpha1.GreeterV1alpha1Interface {
 	return c.greeterV1alpha1
 }
 
+// GreeterV1beta1 retrieves the GreeterV1beta1Client
+func (c *Clientset) GreeterV1beta1() greeterv1beta1.GreeterV1beta1Interface {
+	return c.greeterV1beta1
+}
+
 // Discovery retrieves the DiscoveryClient
 func (c *Clientset) Discovery() discovery.DiscoveryInterface {
 	if c == nil {
@@ -91,6 +99,10 @@ func NewForConfigAndClient(c *rest.Config, httpClient *http.Client) (*Clientset,
 	if err != nil {
 		return nil, err
 	}
+	cs.greeterV1beta1, err = greeterv1beta1.NewForConfigAndClient(&configShallowCopy, httpClient)
+	if err != nil {
+		return nil, err
+	}
 
 	cs.DiscoveryClient, err = discovery.NewDiscoveryClientForConfigAndClient(&configShallowCopy, httpClient)
 	if err != nil {
@@ -113,6 +125,7 @@ func NewForConfigOrDie(c *rest.Config) *Clientset {
 func New(c rest.Interface) *Clientset {
 	var cs Clientset
 	cs.greeterV1alpha1 = greeterv1alpha1.New(c)
+	cs.greeterV1beta1 = greeterv1beta1.New(c)
 
 	cs.DiscoveryClient = discovery.NewDiscoveryClient(c)
 	return &cs
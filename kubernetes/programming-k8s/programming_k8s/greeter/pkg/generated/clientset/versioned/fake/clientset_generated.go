@@ -21,6 +21,8 @@ import (
 	clientset "github.com/wjiec/programming_k8s/greeter/pkg/generated/clientset/versioned"
 	greeterv1alpha1 "github.com/wjiec/programming_k8s/greeter/pkg/generated/clientset/versioned/typed/greeter/v1alpha1"
 	fakegreeterv1alpha1 "github.com/wjiec/programming_k8s/greeter/pkg/generated/clientset/versioned/typed/greeter/v1alpha1/fake"
+	greeterv1beta1 "github.com/wjiec/programming_k8s/greeter/pkg/generated/clientset/versioned/typed/greeter/v1beta1"
+	fakegreeterv1beta1 "github.com/wjiec/programming_k8s/greeter/pkg/generated/clientset/versioned/typed/greeter/v1beta1/fake"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/discovery"
@@ -82,3 +84,8 @@ var (
 func (c *Clientset) GreeterV1alpha1() greeterv1alpha1.GreeterV1alpha1Interface {
 	return &fakegreeterv1alpha1.FakeGreeterV1alpha1{Fake: &c.Fake}
 }
+
+// GreeterV1beta1 retrieves the GreeterV1beta1Client
+func (c *Clientset) GreeterV1beta1() greeterv1beta1.GreeterV1beta1Interface {
+	return &fakegreeterv1beta1.FakeGreeterV1beta1{Fake: &c.Fake}
+}
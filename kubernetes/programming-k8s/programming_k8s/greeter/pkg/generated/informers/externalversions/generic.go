@@ -21,6 +21,7 @@ import (
 	"fmt"
 
 	v1alpha1 "github.com/wjiec/programming_k8s/greeter/pkg/apis/greeter/v1alpha1"
+	v1beta1 "github.com/wjiec/programming_k8s/greeter/pkg/apis/greeter/v1beta1"
 	schema "k8s.io/apimachinery/pkg/runtime/schema"
 	cache "k8s.io/client-go/tools/cache"
 )
@@ -55,6 +56,10 @@ func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource
 	case v1alpha1.SchemeGroupVersion.WithResource("greeters"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Greeter().V1alpha1().Greeters().Informer()}, nil
 
+		// Group=example.org, Version=v1beta1
+	case v1beta1.SchemeGroupVersion.WithResource("greeters"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Greeter().V1beta1().Greeters().Informer()}, nil
+
 	}
 
 	return nil, fmt.Errorf("no informer found for %v", resource)